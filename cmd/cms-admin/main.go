@@ -0,0 +1,73 @@
+// Command cms-admin keeps the DynamoDB tables in line with internal/dynamo/schema.
+//
+//	cms-admin migrate [-check] [-endpoint http://localhost:8000]
+//	cms-admin cloudformation [-stage '${self:provider.stage}'] > resources.json
+//
+// migrate creates the missing tables and indexes of the STAGE and enables their TTL. With -check
+// it only lists the differences, and fails if there are any. The changes it can't make, like
+// a different key, are listed for the operator to make by hand.
+//
+// cloudformation prints the resources block included by serverless.yml.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/ferjmc/cms/internal/dynamo"
+	"github.com/ferjmc/cms/internal/dynamo/schema"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal("usage: cms-admin migrate [-check] [-endpoint <url>] | cloudformation [-stage <stage>]")
+	}
+
+	flags := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	check := flags.Bool("check", false, "only list the differences, fail if there are any")
+	endpoint := flags.String("endpoint", "", "DynamoDB endpoint, like DynamoDB Local, instead of DYNAMODB_ENDPOINT")
+	stage := flags.String("stage", "${self:provider.stage}", "stage the table names are made for")
+	flags.Parse(os.Args[2:])
+
+	switch os.Args[1] {
+	case "migrate":
+		if *endpoint != "" {
+			os.Setenv("DYNAMODB_ENDPOINT", *endpoint)
+		}
+		migrate(*check)
+	case "cloudformation":
+		resources, err := schema.CloudFormation(schema.Tables, *stage)
+		if err != nil {
+			log.Fatalf("ERROR: %s", err)
+		}
+		fmt.Println(string(resources))
+	default:
+		log.Fatalf("ERROR: unknown command %q", os.Args[1])
+	}
+}
+
+func migrate(check bool) {
+	db := dynamo.DynamoDB()
+	changes, err := schema.Plan(db, schema.Tables)
+	if err != nil {
+		log.Fatalf("ERROR: %s", err)
+	}
+
+	for _, change := range changes {
+		fmt.Println(change)
+	}
+
+	if check {
+		if len(changes) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	err = schema.Apply(db, changes)
+	if err != nil {
+		log.Fatalf("ERROR: %s", err)
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/ferjmc/cms/internal/dynamo/schema"
+)
+
+func TestResourcesAreGenerated(t *testing.T) {
+	committed, err := ioutil.ReadFile("../../resources.json")
+	if err != nil {
+		t.Fatalf("read resources.json: %s", err)
+	}
+
+	generated, err := schema.CloudFormation(schema.Tables, "${self:provider.stage}")
+	if err != nil {
+		t.Fatalf("CloudFormation: %s", err)
+	}
+
+	if string(committed) != string(generated)+"\n" {
+		t.Errorf("resources.json differs from internal/dynamo/schema, run make resources")
+	}
+}
@@ -0,0 +1,64 @@
+// Command cms-keys manages the keys signing the JWT tokens kept in DynamoDB.
+// The env and file key stores are edited directly.
+//
+//	cms-keys list
+//	cms-keys rotate -alg EdDSA
+//	cms-keys retire -kid <kid>
+//
+// Rotation keeps the previous keys verifying tokens, retire them once the tokens they signed have
+// expired. The lambdas pick up the changes within a few minutes.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/ferjmc/cms/pkg/auth"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal("usage: cms-keys list | rotate [-alg RS256|EdDSA|HS256] | retire -kid <kid>")
+	}
+
+	store, err := auth.NewKeyStore(auth.KeyStoreDynamodb)
+	if err != nil {
+		log.Fatalf("ERROR: %s", err)
+	}
+
+	flags := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	alg := flags.String("alg", auth.AlgorithmRS256, "algorithm of the new key")
+	kid := flags.String("kid", "", "key to retire")
+	flags.Parse(os.Args[2:])
+
+	switch os.Args[1] {
+	case "list":
+		keys, err := store.Keys()
+		if err != nil {
+			log.Fatalf("ERROR: %s", err)
+		}
+		for _, key := range keys {
+			createdAt := time.Unix(0, key.CreatedAt).UTC().Format(time.RFC3339)
+			fmt.Printf("%s\t%s\t%s\t%s\n", key.Kid, key.Algorithm, key.Status, createdAt)
+		}
+	case "rotate":
+		key, err := auth.RotateKey(store, *alg)
+		if err != nil {
+			log.Fatalf("ERROR: %s", err)
+		}
+		fmt.Println(key.Kid)
+	case "retire":
+		if *kid == "" {
+			log.Fatal("ERROR: -kid is required")
+		}
+		err := auth.RetireKey(store, *kid)
+		if err != nil {
+			log.Fatalf("ERROR: %s", err)
+		}
+	default:
+		log.Fatalf("ERROR: unknown command %q", os.Args[1])
+	}
+}
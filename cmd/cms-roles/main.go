@@ -0,0 +1,54 @@
+// Command cms-roles grants and revokes the roles of the users kept in DynamoDB.
+// It's how the first admin is made, admins then manage the roles through the API.
+//
+//	cms-roles grant -username <username> -role admin
+//	cms-roles revoke -username <username> -role editor
+//
+// Roles apply from the next request of the user, its tokens don't need to be renewed.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/pkg/user"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal("usage: cms-roles grant|revoke -username <username> -role admin|editor")
+	}
+
+	flags := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	username := flags.String("username", "", "user to change")
+	role := flags.String("role", "", "role to grant or revoke")
+	flags.Parse(os.Args[2:])
+
+	if *username == "" || *role == "" {
+		log.Fatal("ERROR: -username and -role are required")
+	}
+
+	// The operator has access to the tables, it acts as an admin
+	operator := &entities.User{Roles: []string{entities.RoleAdmin}}
+	userService := user.New(user.WithDynamoDB)
+
+	var changed *entities.User
+	var err error
+	switch os.Args[1] {
+	case "grant":
+		changed, err = userService.GrantRole(operator, *username, *role)
+	case "revoke":
+		changed, err = userService.RevokeRole(operator, *username, *role)
+	default:
+		log.Fatalf("ERROR: unknown command %q", os.Args[1])
+	}
+	if err != nil {
+		log.Fatalf("ERROR: %s", err)
+	}
+
+	fmt.Printf("%s\t%s\n", changed.Username, strings.Join(changed.Roles, ","))
+}
@@ -0,0 +1,140 @@
+// Command cms-server runs every lambda of the API behind a single local HTTP server.
+//
+// By default it keeps all data in memory, so it runs without AWS:
+//
+//	go run ./cmd/cms-server -addr :8080
+//
+// Use -repository dynamodb, optionally with DYNAMODB_ENDPOINT, to use DynamoDB tables instead.
+// cms-admin migrate creates them, on DynamoDB Local too.
+// Scheduled articles are published every -publish-interval, like the scheduled lambda does,
+// and the rows of renamed users are migrated every -migrate-interval.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	adminusersrolesdelete "github.com/ferjmc/cms/functions/admin-users-roles-delete/handler"
+	adminusersrolesput "github.com/ferjmc/cms/functions/admin-users-roles-put/handler"
+	articlescommentsdelete "github.com/ferjmc/cms/functions/articles-comments-delete/handler"
+	articlescommentsget "github.com/ferjmc/cms/functions/articles-comments-get/handler"
+	articlescommentspost "github.com/ferjmc/cms/functions/articles-comments-post/handler"
+	articlesdraftsget "github.com/ferjmc/cms/functions/articles-drafts-get/handler"
+	articlesfavoritedelete "github.com/ferjmc/cms/functions/articles-favorite-delete/handler"
+	articlesfavoritepost "github.com/ferjmc/cms/functions/articles-favorite-post/handler"
+	articlesfeedget "github.com/ferjmc/cms/functions/articles-feed-get/handler"
+	articlesget "github.com/ferjmc/cms/functions/articles-get/handler"
+	articlespost "github.com/ferjmc/cms/functions/articles-post/handler"
+	articlespublishscheduled "github.com/ferjmc/cms/functions/articles-publish-scheduled/handler"
+	articlesrevisionsdiffget "github.com/ferjmc/cms/functions/articles-revisions-diff-get/handler"
+	articlesrevisionsget "github.com/ferjmc/cms/functions/articles-revisions-get/handler"
+	articlesrevisionsrestorepost "github.com/ferjmc/cms/functions/articles-revisions-restore-post/handler"
+	articlesslugdelete "github.com/ferjmc/cms/functions/articles-slug-delete/handler"
+	articlesslugget "github.com/ferjmc/cms/functions/articles-slug-get/handler"
+	articlesslugput "github.com/ferjmc/cms/functions/articles-slug-put/handler"
+	jwksget "github.com/ferjmc/cms/functions/jwks-get/handler"
+	profilesfollowdelete "github.com/ferjmc/cms/functions/profiles-follow-delete/handler"
+	profilesfollowpost "github.com/ferjmc/cms/functions/profiles-follow-post/handler"
+	profilesget "github.com/ferjmc/cms/functions/profiles-get/handler"
+	tagsget "github.com/ferjmc/cms/functions/tags-get/handler"
+	userget "github.com/ferjmc/cms/functions/user-get/handler"
+	userput "github.com/ferjmc/cms/functions/user-put/handler"
+	userverifypost "github.com/ferjmc/cms/functions/user-verify-post/handler"
+	usersloginpost "github.com/ferjmc/cms/functions/users-login-post/handler"
+	userslogoutpost "github.com/ferjmc/cms/functions/users-logout-post/handler"
+	userspasswordforgotpost "github.com/ferjmc/cms/functions/users-password-forgot-post/handler"
+	userspasswordresetpost "github.com/ferjmc/cms/functions/users-password-reset-post/handler"
+	userspost "github.com/ferjmc/cms/functions/users-post/handler"
+	usersrefreshpost "github.com/ferjmc/cms/functions/users-refresh-post/handler"
+	usersrenamemigrate "github.com/ferjmc/cms/functions/users-rename-migrate/handler"
+	usersverifypost "github.com/ferjmc/cms/functions/users-verify-post/handler"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	repository := flag.String("repository", "memory", "storage backend: memory or dynamodb")
+	publishInterval := flag.Duration("publish-interval", time.Minute, "how often scheduled articles are published")
+	migrateInterval := flag.Duration("migrate-interval", time.Minute, "how often the rows of renamed users are migrated")
+	flag.Parse()
+
+	switch *repository {
+	case "memory", "dynamodb":
+		os.Setenv("REPOSITORY", *repository)
+	default:
+		log.Fatalf("ERROR: unknown repository %q", *repository)
+	}
+
+	go publishScheduledArticles(*publishInterval)
+	go migrateRenamedUsers(*migrateInterval)
+
+	log.Printf("listening on %s with %s repositories", *addr, *repository)
+	log.Fatal(http.ListenAndServe(*addr, NewAPI()))
+}
+
+// NewAPI mounts every function with the path it has in API Gateway
+func NewAPI() *Router {
+	r := NewRouter()
+
+	r.Handle(http.MethodPost, "/users", userspost.Handle)
+	r.Handle(http.MethodPost, "/users/login", usersloginpost.Handle)
+	r.Handle(http.MethodPost, "/users/refresh", usersrefreshpost.Handle)
+	r.Handle(http.MethodPost, "/users/logout", userslogoutpost.Handle)
+	r.Handle(http.MethodPost, "/users/password/forgot", userspasswordforgotpost.Handle)
+	r.Handle(http.MethodPost, "/users/password/reset", userspasswordresetpost.Handle)
+	r.Handle(http.MethodPost, "/users/verify", usersverifypost.Handle)
+	r.Handle(http.MethodGet, "/user", userget.Handle)
+	r.Handle(http.MethodPut, "/user", userput.Handle)
+	r.Handle(http.MethodPost, "/user/verify", userverifypost.Handle)
+
+	r.Handle(http.MethodGet, "/profiles/:username", profilesget.Handle)
+	r.Handle(http.MethodPost, "/profiles/:username/follow", profilesfollowpost.Handle)
+	r.Handle(http.MethodDelete, "/profiles/:username/follow", profilesfollowdelete.Handle)
+
+	r.Handle(http.MethodGet, "/articles", articlesget.Handle)
+	r.Handle(http.MethodPost, "/articles", articlespost.Handle)
+	r.Handle(http.MethodGet, "/articles/feed", articlesfeedget.Handle)
+	r.Handle(http.MethodGet, "/articles/drafts", articlesdraftsget.Handle)
+	r.Handle(http.MethodGet, "/articles/:slug", articlesslugget.Handle)
+	r.Handle(http.MethodPut, "/articles/:slug", articlesslugput.Handle)
+	r.Handle(http.MethodDelete, "/articles/:slug", articlesslugdelete.Handle)
+	r.Handle(http.MethodPost, "/articles/:slug/favorite", articlesfavoritepost.Handle)
+	r.Handle(http.MethodDelete, "/articles/:slug/favorite", articlesfavoritedelete.Handle)
+	r.Handle(http.MethodGet, "/articles/:slug/comments", articlescommentsget.Handle)
+	r.Handle(http.MethodPost, "/articles/:slug/comments", articlescommentspost.Handle)
+	r.Handle(http.MethodDelete, "/articles/:slug/comments/:id", articlescommentsdelete.Handle)
+	r.Handle(http.MethodGet, "/articles/:slug/revisions", articlesrevisionsget.Handle)
+	r.Handle(http.MethodGet, "/articles/:slug/revisions/:revision/diff", articlesrevisionsdiffget.Handle)
+	r.Handle(http.MethodPost, "/articles/:slug/revisions/:revision/restore", articlesrevisionsrestorepost.Handle)
+
+	r.Handle(http.MethodGet, "/tags", tagsget.Handle)
+
+	r.Handle(http.MethodPut, "/admin/users/:username/roles/:role", adminusersrolesput.Handle)
+	r.Handle(http.MethodDelete, "/admin/users/:username/roles/:role", adminusersrolesdelete.Handle)
+
+	r.Handle(http.MethodGet, "/.well-known/jwks.json", jwksget.Handle)
+
+	return r
+}
+
+// publishScheduledArticles runs the scheduled lambda every interval
+func publishScheduledArticles(interval time.Duration) {
+	for range time.Tick(interval) {
+		if err := articlespublishscheduled.Handle(events.CloudWatchEvent{}); err != nil {
+			log.Printf("ERROR: publish scheduled articles: %s", err)
+		}
+	}
+}
+
+// migrateRenamedUsers runs the username migration lambda every interval
+func migrateRenamedUsers(interval time.Duration) {
+	for range time.Tick(interval) {
+		if err := usersrenamemigrate.Handle(events.CloudWatchEvent{}); err != nil {
+			log.Printf("ERROR: migrate renamed users: %s", err)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ferjmc/cms/functions"
+)
+
+// API Gateway rejects payloads bigger than 10MB
+const maxBodyBytes = 10 << 20
+
+// LambdaHandler is the signature of every functions/*/handler.Handle
+type LambdaHandler func(events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
+
+type route struct {
+	method   string
+	segments []string
+	resource string
+	handler  LambdaHandler
+}
+
+// Router mounts lambda handlers on net/http, translating requests and responses
+// the way API Gateway proxy integrations do. Patterns use :name path parameters,
+// routes are matched in the order they were added.
+type Router struct {
+	routes []route
+}
+
+func NewRouter() *Router {
+	return &Router{}
+}
+
+func (r *Router) Handle(method, pattern string, handler LambdaHandler) {
+	segments := splitPath(pattern)
+
+	// API Gateway names resources like /articles/{slug}
+	resource := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segment = "{" + segment[1:] + "}"
+		}
+		resource = append(resource, segment)
+	}
+
+	r.routes = append(r.routes, route{
+		method:   method,
+		segments: segments,
+		resource: "/" + strings.Join(resource, "/"),
+		handler:  handler,
+	})
+}
+
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// Preflight requests are answered by API Gateway itself with cors: true
+	if req.Method == http.MethodOptions {
+		writeHeaders(w, functions.CORSHeaders())
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	segments := splitPath(req.URL.Path)
+	for _, route := range r.routes {
+		if route.method != req.Method {
+			continue
+		}
+
+		pathParameters, ok := matchSegments(route.segments, segments)
+		if !ok {
+			continue
+		}
+
+		r.serveRoute(w, req, route, pathParameters)
+		return
+	}
+
+	writeHeaders(w, functions.CORSHeaders())
+	w.WriteHeader(http.StatusNotFound)
+}
+
+func (r *Router) serveRoute(w http.ResponseWriter, req *http.Request, route route, pathParameters map[string]string) {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, req.Body, maxBodyBytes))
+	if err != nil {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	input := NewProxyRequest(req, body)
+	input.Resource = route.resource
+	input.PathParameters = pathParameters
+
+	response, err := route.handler(input)
+	if err != nil {
+		// API Gateway answers a failed lambda invocation with a bare 502
+		log.Printf("ERROR: %s %s: %s", req.Method, req.URL.Path, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(`{"message": "Internal server error"}`))
+		return
+	}
+
+	WriteProxyResponse(w, response)
+}
+
+// NewProxyRequest translates an HTTP request into the event API Gateway sends to a lambda
+func NewProxyRequest(req *http.Request, body []byte) events.APIGatewayProxyRequest {
+	headers := make(map[string]string, len(req.Header))
+	for name, values := range req.Header {
+		headers[name] = values[len(values)-1]
+	}
+
+	query := req.URL.Query()
+	queryStringParameters := make(map[string]string, len(query))
+	for name, values := range query {
+		queryStringParameters[name] = values[len(values)-1]
+	}
+
+	return events.APIGatewayProxyRequest{
+		Path:                            req.URL.Path,
+		HTTPMethod:                      req.Method,
+		Headers:                         headers,
+		MultiValueHeaders:               req.Header,
+		QueryStringParameters:           queryStringParameters,
+		MultiValueQueryStringParameters: query,
+		Body:                            string(body),
+		RequestContext: events.APIGatewayProxyRequestContext{
+			HTTPMethod: req.Method,
+			Identity: events.APIGatewayRequestIdentity{
+				SourceIP:  req.RemoteAddr,
+				UserAgent: req.UserAgent(),
+			},
+		},
+	}
+}
+
+// WriteProxyResponse writes the response of a lambda the way API Gateway would
+func WriteProxyResponse(w http.ResponseWriter, response events.APIGatewayProxyResponse) {
+	writeHeaders(w, response.Headers)
+	for name, values := range response.MultiValueHeaders {
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+
+	if w.Header().Get("Content-Type") == "" && response.Body != "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+
+	statusCode := response.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+	w.WriteHeader(statusCode)
+
+	if response.IsBase64Encoded {
+		body, err := base64.StdEncoding.DecodeString(response.Body)
+		if err != nil {
+			log.Printf("ERROR: invalid base64 response body: %s", err)
+			return
+		}
+		w.Write(body)
+		return
+	}
+
+	w.Write([]byte(response.Body))
+}
+
+func writeHeaders(w http.ResponseWriter, headers map[string]string) {
+	for name, value := range headers {
+		w.Header().Set(name, value)
+	}
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+func matchSegments(pattern, segments []string) (map[string]string, bool) {
+	if len(pattern) != len(segments) {
+		return nil, false
+	}
+
+	pathParameters := make(map[string]string)
+	for i, segment := range pattern {
+		if strings.HasPrefix(segment, ":") {
+			if segments[i] == "" {
+				return nil, false
+			}
+			pathParameters[segment[1:]] = segments[i]
+		} else if segment != segments[i] {
+			return nil, false
+		}
+	}
+
+	return pathParameters, true
+}
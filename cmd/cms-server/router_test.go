@@ -0,0 +1,384 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/pkg/mail"
+	"github.com/ferjmc/cms/pkg/user"
+)
+
+func TestRouterPathParameters(t *testing.T) {
+	var got events.APIGatewayProxyRequest
+	r := NewRouter()
+	r.Handle(http.MethodGet, "/articles/feed", func(input events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{StatusCode: 204}, nil
+	})
+	r.Handle(http.MethodDelete, "/articles/:slug/comments/:id", func(input events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		got = input
+		return events.APIGatewayProxyResponse{StatusCode: 200, Body: "{}"}, nil
+	})
+
+	req := httptest.NewRequest(http.MethodDelete, "/articles/hello-1f/comments/42?x=1", nil)
+	req.Header.Set("Authorization", "Token abc")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != 200 {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if got.PathParameters["slug"] != "hello-1f" || got.PathParameters["id"] != "42" {
+		t.Errorf("unexpected path parameters %v", got.PathParameters)
+	}
+	if got.Resource != "/articles/{slug}/comments/{id}" {
+		t.Errorf("unexpected resource %s", got.Resource)
+	}
+	if got.Headers["Authorization"] != "Token abc" || got.QueryStringParameters["x"] != "1" {
+		t.Errorf("headers %v and query %v were not translated", got.Headers, got.QueryStringParameters)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/articles/feed", nil))
+	if w.Code != 204 {
+		t.Errorf("literal routes must match, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/articles/feed/extra", nil))
+	if w.Code != 404 {
+		t.Errorf("expected 404 for an unknown route, got %d", w.Code)
+	}
+}
+
+func TestAPIWithMemoryRepositories(t *testing.T) {
+	os.Setenv("REPOSITORY", "memory")
+	defer os.Unsetenv("REPOSITORY")
+
+	api := NewAPI()
+	// The memory store is shared by the whole test binary
+	jake := "jake" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	call := func(method, path, token, body string, out interface{}) int {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Token "+token)
+		}
+		w := httptest.NewRecorder()
+		api.ServeHTTP(w, req)
+		if out != nil && w.Body.Len() > 0 {
+			if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+				t.Fatalf("%s %s: invalid body %q", method, path, w.Body.String())
+			}
+		}
+		return w.Code
+	}
+
+	var registered struct {
+		User struct {
+			Token        string `json:"token"`
+			RefreshToken string `json:"refreshToken"`
+		} `json:"user"`
+	}
+	code := call(http.MethodPost, "/users", "", `{"user":{"username":"`+jake+`","email":"`+jake+`@jake.jake","password":"jakejake"}}`, &registered)
+	if code != 201 {
+		t.Fatalf("register: expected 201, got %d", code)
+	}
+
+	var taken struct {
+		Errors map[string][]string `json:"errors"`
+	}
+	code = call(http.MethodPost, "/users", "", `{"user":{"username":"`+jake+`","email":"other`+jake+`@jake.jake","password":"jakejake"}}`, &taken)
+	if code != 409 || len(taken.Errors["username"]) != 1 || len(taken.Errors["email"]) != 0 {
+		t.Errorf("register a taken username: got %d %v", code, taken.Errors)
+	}
+
+	var created struct {
+		Article struct {
+			Slug string `json:"slug"`
+		} `json:"article"`
+	}
+	code = call(http.MethodPost, "/articles", registered.User.Token, `{"article":{"title":"How to train your dragon","description":"Ever wonder how?","body":"Very carefully.","tagList":["dragons"]}}`, &created)
+	if code != 201 {
+		t.Fatalf("create article: expected 201, got %d", code)
+	}
+
+	var fetched struct {
+		Article struct {
+			Title  string `json:"title"`
+			Author struct {
+				Username string `json:"username"`
+			} `json:"author"`
+		} `json:"article"`
+	}
+	code = call(http.MethodGet, "/articles/"+created.Article.Slug, registered.User.Token, "", &fetched)
+	if code != 200 {
+		t.Fatalf("get article: expected 200, got %d", code)
+	}
+	if fetched.Article.Title != "How to train your dragon" || fetched.Article.Author.Username != jake {
+		t.Errorf("unexpected article %+v", fetched.Article)
+	}
+
+	var tags struct {
+		Tags []string `json:"tags"`
+	}
+	code = call(http.MethodGet, "/tags?prefix=drag", "", "", &tags)
+	if code != 200 || len(tags.Tags) != 1 || tags.Tags[0] != "dragons" {
+		t.Errorf("get tags: got %d %v", code, tags.Tags)
+	}
+
+	code = call(http.MethodGet, "/articles/"+created.Article.Slug, "", "", &fetched)
+	if code != 200 || fetched.Article.Author.Username != jake {
+		t.Errorf("get an article anonymously: got %d %+v", code, fetched.Article)
+	}
+	var excerpts struct {
+		Articles []map[string]interface{} `json:"articles"`
+	}
+	code = call(http.MethodGet, "/articles?author="+jake+"&excerpt=true&fields=slug,title,body", "", "", &excerpts)
+	if code != 200 || len(excerpts.Articles) != 1 || len(excerpts.Articles[0]) != 2 || excerpts.Articles[0]["slug"] != created.Article.Slug {
+		t.Errorf("list excerpts with selected fields: got %d %v", code, excerpts.Articles)
+	}
+	code = call(http.MethodGet, "/articles", "not-a-token", "", nil)
+	if code != 401 {
+		t.Errorf("list articles with an invalid token: expected 401, got %d", code)
+	}
+
+	code = call(http.MethodGet, "/articles/missing-article-zzzzzz", registered.User.Token, "", nil)
+	if code != 404 {
+		t.Errorf("get a missing article: expected 404, got %d", code)
+	}
+	code = call(http.MethodPost, "/articles", registered.User.Token, `{"article":`, nil)
+	if code != 422 {
+		t.Errorf("create an article from invalid JSON: expected 422, got %d", code)
+	}
+
+	var draft struct {
+		Article struct {
+			Slug   string `json:"slug"`
+			Status string `json:"status"`
+		} `json:"article"`
+	}
+	code = call(http.MethodPost, "/articles", registered.User.Token, `{"article":{"title":"Dragons, a draft","description":"Not yet","body":"Soon.","tagList":["dragons"],"status":"draft"}}`, &draft)
+	if code != 201 || draft.Article.Status != entities.ArticleStatusDraft {
+		t.Fatalf("create a draft: got %d %+v", code, draft.Article)
+	}
+	var drafts struct {
+		Articles []struct {
+			Slug string `json:"slug"`
+		} `json:"articles"`
+	}
+	code = call(http.MethodGet, "/articles/drafts", registered.User.Token, "", &drafts)
+	if code != 200 || len(drafts.Articles) != 1 || drafts.Articles[0].Slug != draft.Article.Slug {
+		t.Errorf("list drafts: got %d %+v", code, drafts.Articles)
+	}
+
+	register := func(username string) string {
+		t.Helper()
+		var registered struct {
+			User struct {
+				Token string `json:"token"`
+			} `json:"user"`
+		}
+		code := call(http.MethodPost, "/users", "", `{"user":{"username":"`+username+`","email":"`+username+`@cms.cms","password":"password"}}`, &registered)
+		if code != 201 {
+			t.Fatalf("register %s: expected 201, got %d", username, code)
+		}
+		return registered.User.Token
+	}
+	editor, eve := "editor"+jake, "eve"+jake
+	editorToken, eveToken := register(editor), register(eve)
+
+	code = call(http.MethodGet, "/articles/"+draft.Article.Slug, eveToken, "", nil)
+	if code != 404 {
+		t.Errorf("get the draft of someone else: expected 404, got %d", code)
+	}
+	code = call(http.MethodPut, "/articles/"+draft.Article.Slug, registered.User.Token, `{"article":{"status":"published"}}`, &draft)
+	if code != 200 || draft.Article.Status != entities.ArticleStatusPublished {
+		t.Errorf("publish a draft: got %d %+v", code, draft.Article)
+	}
+	code = call(http.MethodGet, "/articles/"+draft.Article.Slug, eveToken, "", nil)
+	if code != 200 {
+		t.Errorf("get a published draft: expected 200, got %d", code)
+	}
+
+	// The first admin is made outside of the API
+	operator := &entities.User{Roles: []string{entities.RoleAdmin}}
+	_, err := user.New(user.WithMemory).GrantRole(operator, jake, entities.RoleAdmin)
+	if err != nil {
+		t.Fatalf("GrantRole: %s", err)
+	}
+
+	code = call(http.MethodPut, "/admin/users/"+editor+"/roles/editor", eveToken, "", nil)
+	if code != 403 {
+		t.Errorf("grant a role without being an admin: expected 403, got %d", code)
+	}
+	code = call(http.MethodPut, "/admin/users/"+editor+"/roles/editor", registered.User.Token, "", nil)
+	if code != 200 {
+		t.Errorf("grant a role: expected 200, got %d", code)
+	}
+
+	code = call(http.MethodPut, "/user", registered.User.Token, `{"user":{"username":"`+jake+`","email":"`+jake+`@jake.jake","bio":"I work at statefarm"}}`, nil)
+	if code != 200 {
+		t.Fatalf("update user: expected 200, got %d", code)
+	}
+	var current struct {
+		User struct {
+			Roles []string `json:"roles"`
+		} `json:"user"`
+	}
+	code = call(http.MethodGet, "/user", registered.User.Token, "", &current)
+	if code != 200 || len(current.User.Roles) != 1 || current.User.Roles[0] != entities.RoleAdmin {
+		t.Errorf("the roles must outlive a profile update, got %d %v", code, current.User.Roles)
+	}
+
+	articleBody := `{"article":{"title":"How to train your dragon","description":"Moderated","body":"Very carefully.","tagList":["dragons"]}}`
+	code = call(http.MethodPut, "/articles/"+created.Article.Slug, eveToken, articleBody, nil)
+	if code != 403 {
+		t.Errorf("update the article of someone else: expected 403, got %d", code)
+	}
+	code = call(http.MethodPut, "/articles/"+created.Article.Slug, editorToken, articleBody, &fetched)
+	if code != 200 || fetched.Article.Author.Username != jake {
+		t.Errorf("an editor must update the article of someone else: got %d %+v", code, fetched.Article)
+	}
+	var revisions struct {
+		Revisions []struct {
+			Revision int64  `json:"revision"`
+			Editor   string `json:"editor"`
+		} `json:"revisions"`
+	}
+	code = call(http.MethodGet, "/articles/"+created.Article.Slug+"/revisions", registered.User.Token, "", &revisions)
+	if code != 200 || len(revisions.Revisions) != 2 || revisions.Revisions[0].Editor != editor {
+		t.Errorf("list revisions: got %d %+v", code, revisions.Revisions)
+	}
+	code = call(http.MethodGet, "/articles/"+created.Article.Slug+"/revisions", eveToken, "", nil)
+	if code != 403 {
+		t.Errorf("list the revisions of the article of someone else: expected 403, got %d", code)
+	}
+	var diff struct {
+		Diff struct {
+			Body string `json:"body"`
+		} `json:"diff"`
+	}
+	code = call(http.MethodGet, "/articles/"+created.Article.Slug+"/revisions/2/diff?from=0", registered.User.Token, "", &diff)
+	if code != 200 || !strings.Contains(diff.Diff.Body, "+Very carefully.") {
+		t.Errorf("diff revisions: got %d %q", code, diff.Diff.Body)
+	}
+	var restored struct {
+		Article struct {
+			Description string `json:"description"`
+			Revision    int64  `json:"revision"`
+		} `json:"article"`
+	}
+	code = call(http.MethodPost, "/articles/"+created.Article.Slug+"/revisions/1/restore", registered.User.Token, "", &restored)
+	if code != 200 || restored.Article.Description != "Ever wonder how?" || restored.Article.Revision != 3 {
+		t.Errorf("restore a revision: got %d %+v", code, restored.Article)
+	}
+
+	code = call(http.MethodDelete, "/articles/"+created.Article.Slug, editorToken, "", nil)
+	if code != 200 {
+		t.Errorf("an editor must delete the article of someone else: expected 200, got %d", code)
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+		} `json:"keys"`
+	}
+	code = call(http.MethodGet, "/.well-known/jwks.json", "", "", &jwks)
+	if code != 200 || len(jwks.Keys) == 0 {
+		t.Errorf("get jwks: got %d %v", code, jwks.Keys)
+	}
+
+	var refreshed struct {
+		User struct {
+			Token        string `json:"token"`
+			RefreshToken string `json:"refreshToken"`
+		} `json:"user"`
+	}
+	code = call(http.MethodPost, "/users/refresh", "", `{"user":{"refreshToken":"`+registered.User.RefreshToken+`"}}`, &refreshed)
+	if code != 200 || refreshed.User.Token == "" || refreshed.User.RefreshToken == registered.User.RefreshToken {
+		t.Fatalf("refresh: got %d %+v", code, refreshed.User)
+	}
+
+	code = call(http.MethodPost, "/users/refresh", "", `{"user":{"refreshToken":"`+registered.User.RefreshToken+`"}}`, nil)
+	if code != 401 {
+		t.Errorf("refresh with a used token: expected 401, got %d", code)
+	}
+
+	code = call(http.MethodPost, "/users/login", "", `{"user":{"email":"`+jake+`@jake.jake","password":"jakejake"}}`, &refreshed)
+	if code != 200 {
+		t.Fatalf("login: expected 200, got %d", code)
+	}
+
+	code = call(http.MethodPost, "/users/logout", refreshed.User.Token, `{"user":{"everywhere":true}}`, nil)
+	if code != 200 && code != 204 {
+		t.Fatalf("logout everywhere: got %d", code)
+	}
+
+	code = call(http.MethodGet, "/user", refreshed.User.Token, "", nil)
+	if code != 401 {
+		t.Errorf("get user with a revoked token: expected 401, got %d", code)
+	}
+	code = call(http.MethodPost, "/users/refresh", "", `{"user":{"refreshToken":"`+refreshed.User.RefreshToken+`"}}`, nil)
+	if code != 401 {
+		t.Errorf("refresh after logout everywhere: expected 401, got %d", code)
+	}
+
+	// The memory mailer holds the token mailed on registration
+	messages := mail.Outbox().Messages(jake + "@jake.jake")
+	if len(messages) != 1 {
+		t.Fatalf("expected a verification email, got %v", messages)
+	}
+	lines := strings.Split(strings.TrimSpace(messages[0].Body), "\n")
+	verificationToken := lines[len(lines)-1]
+
+	var verified struct {
+		User struct {
+			Verified bool `json:"verified"`
+		} `json:"user"`
+	}
+	code = call(http.MethodPost, "/users/verify", "", `{"user":{"token":"`+verificationToken+`"}}`, &verified)
+	if code != 200 || !verified.User.Verified {
+		t.Errorf("verify email: got %d %+v", code, verified.User)
+	}
+
+	code = call(http.MethodPost, "/users/password/forgot", "", `{"user":{"email":"`+jake+`@jake.jake"}}`, nil)
+	if code != 202 {
+		t.Errorf("forgot password: expected 202, got %d", code)
+	}
+	code = call(http.MethodPost, "/users/password/reset", "", `{"user":{"token":"`+verificationToken+`","password":"newjakejake"}}`, nil)
+	if code != 422 {
+		t.Errorf("reset password with a used token: expected 422, got %d", code)
+	}
+
+	var renamed struct {
+		User struct {
+			Username string `json:"username"`
+			Token    string `json:"token"`
+		} `json:"user"`
+	}
+	evelyn := "evelyn" + jake
+	code = call(http.MethodPut, "/user", eveToken, `{"user":{"username":"`+evelyn+`"}}`, &renamed)
+	if code != 200 || renamed.User.Username != evelyn || renamed.User.Token == "" {
+		t.Fatalf("rename: got %d %+v", code, renamed.User)
+	}
+	code = call(http.MethodGet, "/user", eveToken, "", nil)
+	if code != 401 {
+		t.Errorf("get user with a token of the old username: expected 401, got %d", code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/profiles/"+eve, nil)
+	req.Header.Set("Authorization", "Token "+renamed.User.Token)
+	w := httptest.NewRecorder()
+	api.ServeHTTP(w, req)
+	if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != evelyn {
+		t.Errorf("get the profile of a former username: expected a redirect to %s, got %d %q", evelyn, w.Code, w.Header().Get("Location"))
+	}
+}
@@ -0,0 +1,18 @@
+package entities
+
+const (
+	TokenPurposePasswordReset     = "password-reset"
+	TokenPurposeEmailVerification = "email-verification"
+)
+
+// AccountToken is a single use token mailed to a user to reset its password or
+// to verify its email. Only the hash of the token is stored.
+type AccountToken struct {
+	TokenHash string
+	Purpose   string
+	Username  string
+	Email     string
+	CreatedAt int64
+	ExpiresAt int64
+	TTL       int64 // ExpiresAt in seconds, lets DynamoDB delete expired tokens
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gosimple/slug"
 )
@@ -12,6 +13,17 @@ const TimestampFormat = "2006-01-02T15:04:05.000Z"
 const MaxArticleId = 0x1000000 // exclusive
 const MaxNumTagsPerArticle = 5
 
+// Only published articles are listed, tagged and written to feeds. Drafts and scheduled
+// articles are only visible to their author, archived articles are still served by slug.
+const (
+	ArticleStatusDraft     = "draft"
+	ArticleStatusScheduled = "scheduled"
+	ArticleStatusPublished = "published"
+	ArticleStatusArchived  = "archived"
+)
+
+var ArticleStatuses = []string{ArticleStatusDraft, ArticleStatusScheduled, ArticleStatusPublished, ArticleStatusArchived}
+
 type Article struct {
 	ArticleId      int64
 	Slug           string
@@ -19,11 +31,16 @@ type Article struct {
 	Description    string
 	Body           string
 	TagList        []string
-	CreatedAt      int64
+	CreatedAt      int64 // Set again when a draft or scheduled article is published, listings are sorted by it
 	UpdatedAt      int64
 	FavoritesCount int64
 	Author         string
-	Dummy          byte // Always 0, used for sorting articles by index CreatedAt
+	UpdatedBy      string // Username of the last editor
+	Revision       int64  // Number of the latest ArticleRevision, 0 for the articles written before revisions
+	Status         string // Empty for the articles written before statuses, which are published
+	PublishAt      int64  `dynamodbav:",omitempty"` // When a scheduled article is published, only stored on scheduled articles, listed by index Status
+	Dummy          byte   // Always 0, only stored on published articles, used for sorting them by index CreatedAt
+	FannedOut      bool   // Written to the timelines of the followers of the author
 }
 
 type ArticleTag struct {
@@ -63,13 +80,131 @@ func (article *Article) Validate() error {
 
 	if article.TagList == nil {
 		article.TagList = make([]string, 0)
-	} else if len(article.TagList) > MaxNumTagsPerArticle {
+	} else {
+		article.TagList = distinctTags(article.TagList)
+	}
+
+	if len(article.TagList) > MaxNumTagsPerArticle {
 		return NewInputError("tagList", fmt.Sprintf("cannot add more than %d tags per article", MaxNumTagsPerArticle))
 	}
 
 	return nil
 }
 
+// Published tells if article is listed, linked to its tags and written to feeds
+func (article *Article) Published() bool {
+	return article.Status == "" || article.Status == ArticleStatusPublished
+}
+
+// CurrentStatus is the status of article, published for the articles written before statuses
+func (article *Article) CurrentStatus() string {
+	if article.Status == "" {
+		return ArticleStatusPublished
+	}
+	return article.Status
+}
+
+// VisibleTo tells if username may read article, drafts and scheduled articles are only read by their author
+func (article *Article) VisibleTo(username string) bool {
+	if article.Published() || article.Status == ArticleStatusArchived {
+		return true
+	}
+	return username != "" && article.Author == username
+}
+
+// ValidateStatus checks the status of article at now, PublishAt is only kept for scheduled articles
+func (article *Article) ValidateStatus(now int64) error {
+	if article.Status == "" {
+		article.Status = ArticleStatusPublished
+	}
+
+	valid := false
+	for _, status := range ArticleStatuses {
+		valid = valid || article.Status == status
+	}
+	if !valid {
+		return NewInputError("status", "must be one of "+strings.Join(ArticleStatuses, ", "))
+	}
+
+	if article.Status != ArticleStatusScheduled {
+		article.PublishAt = 0
+		return nil
+	}
+
+	if article.PublishAt <= now {
+		return NewInputError("publishAt", "must be in the future")
+	}
+
+	return nil
+}
+
+// ValidateStatusChange checks that an article may go from status oldStatus to its status,
+// a published article can only be archived, it never goes back to draft
+func (article *Article) ValidateStatusChange(oldStatus string) error {
+	wasPublic := oldStatus == "" || oldStatus == ArticleStatusPublished || oldStatus == ArticleStatusArchived
+	if wasPublic && (article.Status == ArticleStatusDraft || article.Status == ArticleStatusScheduled) {
+		return NewInputError("status", "a published article can only be archived")
+	}
+
+	return nil
+}
+
+// ParseTimestamp reads an RFC 3339 timestamp into nanoseconds
+func ParseTimestamp(field, value string) (int64, error) {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return 0, NewInputError(field, "must be an RFC 3339 timestamp")
+	}
+	return t.UTC().UnixNano(), nil
+}
+
+// FormatOptionalTimestamp formats nanoseconds with TimestampFormat, zero being an empty string
+func FormatOptionalTimestamp(nanos int64) string {
+	if nanos == 0 {
+		return ""
+	}
+	return time.Unix(0, nanos).UTC().Format(TimestampFormat)
+}
+
+func distinctTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	distinct := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		distinct = append(distinct, tag)
+	}
+	return distinct
+}
+
+// DiffTags returns the tags only present in newTags and the tags only present in oldTags
+func DiffTags(oldTags, newTags []string) ([]string, []string) {
+	oldSet := make(map[string]bool, len(oldTags))
+	for _, tag := range oldTags {
+		oldSet[tag] = true
+	}
+
+	newSet := make(map[string]bool, len(newTags))
+	added := make([]string, 0)
+	for _, tag := range newTags {
+		newSet[tag] = true
+		if !oldSet[tag] {
+			added = append(added, tag)
+		}
+	}
+
+	removed := make([]string, 0)
+	for _, tag := range oldTags {
+		if !newSet[tag] {
+			removed = append(removed, tag)
+		}
+	}
+
+	return added, removed
+}
+
 func (article *Article) MakeSlug() {
 	slugPrefix := slug.Make(article.Title)
 	article.Slug = slugPrefix + "-" + strconv.FormatInt(article.ArticleId, 16)
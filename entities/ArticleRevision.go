@@ -0,0 +1,39 @@
+package entities
+
+// ArticleRevision is the content of an article as saved by Editor, revisions are never modified.
+// They are numbered from 1 for each article, Article.Revision is the number of the latest one.
+type ArticleRevision struct {
+	ArticleId   int64
+	Revision    int64
+	Title       string
+	Description string
+	Body        string
+	TagList     []string
+	Editor      string
+	CreatedAt   int64
+}
+
+// NewArticleRevision returns the revision made by the latest save of article
+func NewArticleRevision(article Article) ArticleRevision {
+	return ArticleRevision{
+		ArticleId:   article.ArticleId,
+		Revision:    article.Revision,
+		Title:       article.Title,
+		Description: article.Description,
+		Body:        article.Body,
+		TagList:     article.TagList,
+		Editor:      article.UpdatedBy,
+		CreatedAt:   article.UpdatedAt,
+	}
+}
+
+// SameContent tells if article and other have the same title, description, body and tags,
+// only a change of content makes a new revision
+func (article *Article) SameContent(other Article) bool {
+	if article.Title != other.Title || article.Description != other.Description || article.Body != other.Body {
+		return false
+	}
+
+	added, removed := DiffTags(article.TagList, other.TagList)
+	return len(added) == 0 && len(removed) == 0 && len(article.TagList) == len(other.TagList)
+}
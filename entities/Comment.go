@@ -0,0 +1,20 @@
+package entities
+
+const MaxCommentId = 0x1000000 // exclusive
+
+type Comment struct {
+	ArticleId int64
+	CommentId int64
+	CreatedAt int64
+	UpdatedAt int64
+	Body      string
+	Author    string
+}
+
+func (comment *Comment) Validate() error {
+	if comment.Body == "" {
+		return NewInputError("body", "can't be blank")
+	}
+
+	return nil
+}
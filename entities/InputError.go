@@ -2,19 +2,76 @@ package entities
 
 import "encoding/json"
 
+// Domain errors hold messages by field, like {"email": ["has already been taken"]}.
+// Their type tells what went wrong, the response layer maps each type to its own status.
+
+// InputError is a validation error, the input is well formed but not acceptable
 type InputError map[string][]string
 
-func (e InputError) Error() string {
-	js, err := json.Marshal(e)
-	if err != nil {
-		return err.Error()
-	}
+// NotFoundError tells that a resource doesn't exist
+type NotFoundError map[string][]string
 
-	return string(js)
-}
+// ConflictError tells that the request conflicts with the current state, like a
+// taken username or a resource modified meanwhile
+type ConflictError map[string][]string
+
+// ForbiddenError tells that the user isn't allowed to do it
+type ForbiddenError map[string][]string
+
+// UnauthorizedError tells that the credentials are missing or invalid
+type UnauthorizedError map[string][]string
+
+// RateLimitedError tells that too many requests were made, they may be retried later
+type RateLimitedError map[string][]string
+
+func (e InputError) Error() string        { return messagesJSON(e) }
+func (e NotFoundError) Error() string     { return messagesJSON(e) }
+func (e ConflictError) Error() string     { return messagesJSON(e) }
+func (e ForbiddenError) Error() string    { return messagesJSON(e) }
+func (e UnauthorizedError) Error() string { return messagesJSON(e) }
+func (e RateLimitedError) Error() string  { return messagesJSON(e) }
 
 func NewInputError(inputName, message string) InputError {
 	return InputError{
 		inputName: {message},
 	}
 }
+
+func NewNotFoundError(inputName, message string) NotFoundError {
+	return NotFoundError{
+		inputName: {message},
+	}
+}
+
+func NewConflictError(inputName, message string) ConflictError {
+	return ConflictError{
+		inputName: {message},
+	}
+}
+
+func NewForbiddenError(inputName, message string) ForbiddenError {
+	return ForbiddenError{
+		inputName: {message},
+	}
+}
+
+func NewUnauthorizedError(inputName, message string) UnauthorizedError {
+	return UnauthorizedError{
+		inputName: {message},
+	}
+}
+
+func NewRateLimitedError(inputName, message string) RateLimitedError {
+	return RateLimitedError{
+		inputName: {message},
+	}
+}
+
+func messagesJSON(messages map[string][]string) string {
+	js, err := json.Marshal(messages)
+	if err != nil {
+		return err.Error()
+	}
+
+	return string(js)
+}
@@ -0,0 +1,13 @@
+package entities
+
+// Session is a login of a user on a device, renewed by a refresh token which
+// changes on every use. Only hashes of the refresh tokens are stored.
+type Session struct {
+	SessionId         string
+	Username          string
+	RefreshTokenHash  []byte
+	PreviousTokenHash []byte
+	CreatedAt         int64
+	ExpiresAt         int64
+	TTL               int64 // ExpiresAt in seconds, lets DynamoDB delete expired sessions
+}
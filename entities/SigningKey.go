@@ -0,0 +1,19 @@
+package entities
+
+// Statuses of a signing key. Tokens are signed with the newest active key and
+// verified with any key which isn't retired.
+const (
+	KeyStatusActive  = "active"
+	KeyStatusVerify  = "verify"
+	KeyStatusRetired = "retired"
+)
+
+// SigningKey is a key signing the JWT tokens, Material is the secret of a HS256
+// key or the PKCS #8 DER private key of a RS256 or EdDSA key
+type SigningKey struct {
+	Kid       string
+	Algorithm string
+	Material  []byte
+	Status    string
+	CreatedAt int64
+}
@@ -0,0 +1,17 @@
+package entities
+
+// TimelineEntry links an article to the timeline of a follower of its author
+type TimelineEntry struct {
+	Follower  string
+	ArticleId int64
+	CreatedAt int64
+	Author    string
+}
+
+// Publisher counts the followers of a user. Popular is set once the user has
+// written an article while having more followers than the timeline threshold.
+type Publisher struct {
+	Publisher      string
+	FollowersCount int64
+	Popular        byte `dynamodbav:",omitempty"`
+}
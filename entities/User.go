@@ -5,7 +5,14 @@ import (
 )
 
 const MinPasswordLength = 6
-const PasswordKeyLength = 64
+
+// Roles grant permissions over the content of other users, see auth.Can
+const (
+	RoleAdmin  = "admin"
+	RoleEditor = "editor"
+)
+
+var Roles = []string{RoleAdmin, RoleEditor}
 
 type User struct {
 	Username     string
@@ -13,6 +20,40 @@ type User struct {
 	PasswordHash []byte
 	Image        string
 	Bio          string
+	// TokensRevokedAt rejects the access tokens issued before it, set on password
+	// changes and when the user logs out everywhere
+	TokensRevokedAt int64
+	// Verified is set once the user proves it owns Email, changing Email unsets it
+	Verified bool
+	Roles    []string
+}
+
+// UserPatch holds the fields of a partial profile update, nil fields keep their current value
+type UserPatch struct {
+	Username *string
+	Email    *string
+	Image    *string
+	Bio      *string
+	Password *string
+	// CurrentPassword must match the stored password for Password to change
+	CurrentPassword string
+}
+
+// Apply returns a copy of user with the fields of the patch, the password is left to the caller
+func (p UserPatch) Apply(user User) User {
+	if p.Username != nil {
+		user.Username = *p.Username
+	}
+	if p.Email != nil {
+		user.Email = *p.Email
+	}
+	if p.Image != nil {
+		user.Image = *p.Image
+	}
+	if p.Bio != nil {
+		user.Bio = *p.Bio
+	}
+	return user
 }
 
 type EmailUser struct {
@@ -34,13 +75,32 @@ func (u *User) Validate() error {
 		return NewInputError("email", "can't be blank")
 	}
 
-	if u.PasswordHash == nil || len(u.PasswordHash) != PasswordKeyLength {
+	if len(u.PasswordHash) == 0 {
 		return NewInputError("password", "can't be blank")
 	}
 
 	return nil
 }
 
+// HasRole tells if role was granted to the user
+func (u *User) HasRole(role string) bool {
+	for _, granted := range u.Roles {
+		if granted == role {
+			return true
+		}
+	}
+	return false
+}
+
+func ValidateRole(role string) error {
+	for _, known := range Roles {
+		if role == known {
+			return nil
+		}
+	}
+	return NewInputError("role", fmt.Sprintf("must be one of %v", Roles))
+}
+
 func ValidatePassword(password string) error {
 	if len(password) < MinPasswordLength {
 		return NewInputError("password", fmt.Sprintf("must be at least %d characters in length", MinPasswordLength))
@@ -0,0 +1,74 @@
+package entities
+
+// UsernameAlias reserves a former username of a user, GET /profiles/:username redirects it to
+// the current one. Aliases are never released, a user renamed twice has a chain of them.
+type UsernameAlias struct {
+	Alias     string
+	Username  string
+	CreatedAt int64
+}
+
+// The steps of a username migration, run in this order
+const (
+	UsernameMigrationStepArticles  = "articles"
+	UsernameMigrationStepComments  = "comments"
+	UsernameMigrationStepFavorites = "favorites"
+	UsernameMigrationStepFollowing = "following"
+	UsernameMigrationStepFollowers = "followers"
+)
+
+var UsernameMigrationSteps = []string{
+	UsernameMigrationStepArticles,
+	UsernameMigrationStepComments,
+	UsernameMigrationStepFavorites,
+	UsernameMigrationStepFollowing,
+	UsernameMigrationStepFollowers,
+}
+
+// UsernameMigration tracks the rewrite of the rows still referencing OldUsername after a rename.
+// Its progress is saved after every page, so it resumes where it stopped.
+type UsernameMigration struct {
+	OldUsername string
+	NewUsername string
+	Step        string // The step being run, empty once every step is done
+	Cursor      string // Position of the next page of Step, empty for its first page
+	CreatedAt   int64
+	UpdatedAt   int64
+	Pending     byte `dynamodbav:",omitempty"` // 1 until done, pending migrations are read by index Pending
+}
+
+// NewUsernameMigration returns the migration to run after oldUsername was renamed to newUsername
+func NewUsernameMigration(oldUsername, newUsername string, now int64) UsernameMigration {
+	return UsernameMigration{
+		OldUsername: oldUsername,
+		NewUsername: newUsername,
+		Step:        UsernameMigrationSteps[0],
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Pending:     1,
+	}
+}
+
+// Advance moves the migration to the page at next, or to the next step once next is empty
+func (m *UsernameMigration) Advance(next string, now int64) {
+	m.Cursor = next
+	m.UpdatedAt = now
+	if next != "" {
+		return
+	}
+
+	for i, step := range UsernameMigrationSteps {
+		if step == m.Step && i+1 < len(UsernameMigrationSteps) {
+			m.Step = UsernameMigrationSteps[i+1]
+			return
+		}
+	}
+
+	m.Step = ""
+	m.Pending = 0
+}
+
+// Done tells if every step has been run
+func (m *UsernameMigration) Done() bool {
+	return m.Step == ""
+}
@@ -2,43 +2,80 @@ package functions
 
 import (
 	"encoding/json"
+	"errors"
+	"log"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/ferjmc/cms/entities"
 )
 
-type InputErrorResponse struct {
-	Errors entities.InputError `json:"errors"`
+// ErrorResponse is the body of every error response
+type ErrorResponse struct {
+	Errors map[string][]string `json:"errors"`
 }
 
+// NewErrorResponse maps the domain errors to their status:
+//
+//	entities.InputError        422
+//	entities.NotFoundError     404
+//	entities.ConflictError     409
+//	entities.ForbiddenError    403
+//	entities.UnauthorizedError 401
+//	entities.RateLimitedError  429
+//
+// A request body which isn't valid JSON is an input error, any other error is internal (500)
 func NewErrorResponse(err error) (events.APIGatewayProxyResponse, error) {
-	inputError, ok := err.(entities.InputError)
-	if !ok {
-		// Internal server error
-		return events.APIGatewayProxyResponse{}, err
-	}
-
-	body := InputErrorResponse{
-		Errors: inputError,
+	statusCode, messages := errorStatus(err)
+	if statusCode == 500 {
+		log.Printf("ERROR: %s", err)
 	}
 
-	jsonBody, err := json.Marshal(body)
+	jsonBody, err := json.Marshal(ErrorResponse{Errors: messages})
 	if err != nil {
 		return events.APIGatewayProxyResponse{}, err
 	}
 
 	response := events.APIGatewayProxyResponse{
-		StatusCode: 422,
+		StatusCode: statusCode,
 		Body:       string(jsonBody),
 		Headers:    CORSHeaders(),
 	}
 	return response, nil
 }
 
-func NewUnauthorizedResponse() (events.APIGatewayProxyResponse, error) {
-	response := events.APIGatewayProxyResponse{
-		StatusCode: 401,
-		Headers:    CORSHeaders(),
+func errorStatus(err error) (int, map[string][]string) {
+	var inputErr entities.InputError
+	var notFoundErr entities.NotFoundError
+	var conflictErr entities.ConflictError
+	var forbiddenErr entities.ForbiddenError
+	var unauthorizedErr entities.UnauthorizedError
+	var rateLimitedErr entities.RateLimitedError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &inputErr):
+		return 422, inputErr
+	case errors.As(err, &notFoundErr):
+		return 404, notFoundErr
+	case errors.As(err, &conflictErr):
+		return 409, conflictErr
+	case errors.As(err, &forbiddenErr):
+		return 403, forbiddenErr
+	case errors.As(err, &unauthorizedErr):
+		return 401, unauthorizedErr
+	case errors.As(err, &rateLimitedErr):
+		return 429, rateLimitedErr
+	case errors.As(err, &syntaxErr):
+		return 422, entities.NewInputError("body", "must be valid JSON")
+	case errors.As(err, &typeErr):
+		field := typeErr.Field
+		if field == "" {
+			field = "body"
+		}
+		return 422, entities.NewInputError(field, "must be a "+typeErr.Value)
+	default:
+		// The cause of internal errors is logged, not returned
+		return 500, map[string][]string{"server": {"internal error"}}
 	}
-	return response, nil
 }
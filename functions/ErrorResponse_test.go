@@ -0,0 +1,64 @@
+package functions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/ferjmc/cms/entities"
+)
+
+func TestNewErrorResponse(t *testing.T) {
+	var request struct {
+		User struct {
+			Password string `json:"password"`
+		} `json:"user"`
+	}
+	syntaxErr := json.Unmarshal([]byte(`{"user":`), &request)
+	typeErr := json.Unmarshal([]byte(`{"user":{"password":123456}}`), &request)
+
+	tests := []struct {
+		name       string
+		err        error
+		statusCode int
+		field      string
+	}{
+		{"input", entities.NewInputError("title", "can't be blank"), 422, "title"},
+		{"not found", entities.NewNotFoundError("article", "not found"), 404, "article"},
+		{"conflict", entities.NewConflictError("email", "has already been taken"), 409, "email"},
+		{"forbidden", entities.NewForbiddenError("article", "only the author can modify an article"), 403, "article"},
+		{"unauthorized", entities.NewUnauthorizedError("Authorization", "token expired"), 401, "Authorization"},
+		{"rate limited", entities.NewRateLimitedError("email", "try again later"), 429, "email"},
+		{"wrapped", fmt.Errorf("get article: %w", entities.NewNotFoundError("article", "not found")), 404, "article"},
+		{"invalid JSON", syntaxErr, 422, "body"},
+		{"invalid JSON type", typeErr, 422, "user.password"},
+		{"internal", errors.New("connection reset"), 500, "server"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, err := NewErrorResponse(test.err)
+			if err != nil {
+				t.Fatalf("NewErrorResponse: %s", err)
+			}
+			if response.StatusCode != test.statusCode {
+				t.Errorf("expected status %d, got %d", test.statusCode, response.StatusCode)
+			}
+
+			var body ErrorResponse
+			err = json.Unmarshal([]byte(response.Body), &body)
+			if err != nil {
+				t.Fatalf("invalid body %q", response.Body)
+			}
+			fields := make([]string, 0, len(body.Errors))
+			for field := range body.Errors {
+				fields = append(fields, field)
+			}
+			if !reflect.DeepEqual(fields, []string{test.field}) {
+				t.Errorf("expected errors of %s, got %s", test.field, response.Body)
+			}
+		})
+	}
+}
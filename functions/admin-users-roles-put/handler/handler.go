@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ferjmc/cms/functions"
+	"github.com/ferjmc/cms/pkg/user"
+)
+
+type Response struct {
+	User UserResponse `json:"user"`
+}
+
+type UserResponse struct {
+	Username string   `json:"username"`
+	Roles    []string `json:"roles"`
+}
+
+// Handle adds a role to a user, the current user must be an admin
+func Handle(input events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	userService := user.New()
+	admin, _, err := userService.GetCurrentUser(input.Headers["Authorization"])
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	user, err := userService.GrantRole(admin, input.PathParameters["username"], input.PathParameters["role"])
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	roles := user.Roles
+	if roles == nil {
+		roles = make([]string, 0)
+	}
+
+	response := Response{
+		User: UserResponse{
+			Username: user.Username,
+			Roles:    roles,
+		},
+	}
+
+	return functions.NewSuccessResponse(200, response)
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/functions"
+	"github.com/ferjmc/cms/pkg/article"
+	"github.com/ferjmc/cms/pkg/comment"
+	"github.com/ferjmc/cms/pkg/user"
+)
+
+func Handle(input events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	user, _, err := user.New().GetCurrentUser(input.Headers["Authorization"])
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	commentId, err := strconv.ParseInt(input.PathParameters["id"], 10, 64)
+	if err != nil {
+		return functions.NewErrorResponse(entities.NewInputError("id", "invalid"))
+	}
+
+	foundArticle, err := article.New().GetArticleBySlug(user, input.PathParameters["slug"])
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	err = comment.New().DeleteComment(user, *foundArticle, commentId)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	return functions.NewSuccessResponse(200, nil)
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ferjmc/cms/functions"
+	"github.com/ferjmc/cms/functions/dto"
+	"github.com/ferjmc/cms/pkg/article"
+	"github.com/ferjmc/cms/pkg/comment"
+	"github.com/ferjmc/cms/pkg/user"
+)
+
+type Response struct {
+	Comments []CommentResponse `json:"comments"`
+}
+
+type CommentResponse struct {
+	Id        int64              `json:"id"`
+	CreatedAt string             `json:"createdAt"`
+	UpdatedAt string             `json:"updatedAt"`
+	Body      string             `json:"body"`
+	Author    dto.AuthorResponse `json:"author"`
+}
+
+func Handle(input events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	user, _, err := user.New().GetOptionalUser(input.Headers["Authorization"])
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	foundArticle, err := article.New().GetArticleBySlug(user, input.PathParameters["slug"])
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	commentService := comment.New()
+	comments, err := commentService.GetComments(*foundArticle)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	authors, following, err := commentService.GetCommentRelatedProperties(user, comments)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	commentResponses := make([]CommentResponse, 0, len(comments))
+
+	for i, comment := range comments {
+		commentResponses = append(commentResponses, CommentResponse{
+			Id:        comment.CommentId,
+			CreatedAt: dto.FormatTimestamp(comment.CreatedAt),
+			UpdatedAt: dto.FormatTimestamp(comment.UpdatedAt),
+			Body:      comment.Body,
+			Author:    dto.NewAuthorResponse(authors[i], following[i]),
+		})
+	}
+
+	response := Response{
+		Comments: commentResponses,
+	}
+
+	return functions.NewSuccessResponse(200, response)
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/functions"
+	"github.com/ferjmc/cms/functions/dto"
+	"github.com/ferjmc/cms/pkg/article"
+	"github.com/ferjmc/cms/pkg/comment"
+	"github.com/ferjmc/cms/pkg/user"
+)
+
+type Request struct {
+	Comment CommentRequest `json:"comment"`
+}
+
+type CommentRequest struct {
+	Body string `json:"body"`
+}
+
+type Response struct {
+	Comment CommentResponse `json:"comment"`
+}
+
+type CommentResponse struct {
+	Id        int64              `json:"id"`
+	CreatedAt string             `json:"createdAt"`
+	UpdatedAt string             `json:"updatedAt"`
+	Body      string             `json:"body"`
+	Author    dto.AuthorResponse `json:"author"`
+}
+
+func Handle(input events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	user, _, err := user.New().GetCurrentUser(input.Headers["Authorization"])
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	request := Request{}
+	err = json.Unmarshal([]byte(input.Body), &request)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	foundArticle, err := article.New().GetArticleBySlug(user, input.PathParameters["slug"])
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	newComment := entities.Comment{
+		Body: request.Comment.Body,
+	}
+
+	err = comment.New().PutComment(user, *foundArticle, &newComment)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	response := Response{
+		Comment: CommentResponse{
+			Id:        newComment.CommentId,
+			CreatedAt: dto.FormatTimestamp(newComment.CreatedAt),
+			UpdatedAt: dto.FormatTimestamp(newComment.UpdatedAt),
+			Body:      newComment.Body,
+			Author:    dto.NewAuthorResponse(*user, false),
+		},
+	}
+
+	return functions.NewSuccessResponse(201, response)
+}
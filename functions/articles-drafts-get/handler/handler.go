@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ferjmc/cms/functions"
+	"github.com/ferjmc/cms/functions/dto"
+	"github.com/ferjmc/cms/pkg/article"
+	"github.com/ferjmc/cms/pkg/user"
+)
+
+type Response struct {
+	Articles      []dto.ArticleResponse `json:"articles"`
+	ArticlesCount int                   `json:"articlesCount"`
+	NextCursor    string                `json:"nextCursor,omitempty"`
+}
+
+// Handle lists the drafts, scheduled and archived articles of the current user
+func Handle(input events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	user, _, err := user.New().GetCurrentUser(input.Headers["Authorization"])
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	selection, err := dto.ArticleListSelection(input.QueryStringParameters)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	limit, err := strconv.Atoi(input.QueryStringParameters["limit"])
+	if err != nil {
+		limit = 20
+	}
+
+	articleService := article.New()
+	articles, nextCursor, err := articleService.GetUnpublishedArticlesPage(user, input.QueryStringParameters["cursor"], limit)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	isFavorited, _, _, err := articleService.GetArticleRelatedProperties(user, articles, false)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	articleResponses := make([]dto.ArticleResponse, 0, len(articles))
+	for i, article := range articles {
+		articleResponses = append(articleResponses, dto.NewArticleResponse(article, *user, isFavorited[i], false))
+	}
+	dto.SelectArticles(articleResponses, selection)
+
+	response := Response{
+		Articles:      articleResponses,
+		ArticlesCount: len(articleResponses),
+		NextCursor:    nextCursor,
+	}
+
+	return functions.NewSuccessResponse(200, response)
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/functions"
+	"github.com/ferjmc/cms/functions/dto"
+	"github.com/ferjmc/cms/pkg/article"
+	"github.com/ferjmc/cms/pkg/user"
+)
+
+type Response struct {
+	Article dto.ArticleResponse `json:"article"`
+}
+
+func Handle(input events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	user, _, err := user.New().GetCurrentUser(input.Headers["Authorization"])
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	articleService := article.New()
+	foundArticle, err := articleService.GetArticleBySlug(user, input.PathParameters["slug"])
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	err = articleService.Favorite(user, foundArticle)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	articles := []entities.Article{*foundArticle}
+	_, authors, following, err := articleService.GetArticleRelatedProperties(user, articles, true)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	response := Response{
+		Article: dto.NewArticleResponse(*foundArticle, authors[0], true, following[0]),
+	}
+
+	return functions.NewSuccessResponse(200, response)
+}
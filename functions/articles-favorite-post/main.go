@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/ferjmc/cms/functions/articles-favorite-post/handler"
+)
+
+func main() {
+	lambda.Start(handler.Handle)
+}
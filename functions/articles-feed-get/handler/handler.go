@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/functions"
+	"github.com/ferjmc/cms/functions/dto"
+	"github.com/ferjmc/cms/pkg/article"
+	"github.com/ferjmc/cms/pkg/user"
+)
+
+type Response struct {
+	Articles      []dto.ArticleResponse `json:"articles"`
+	ArticlesCount int                   `json:"articlesCount"`
+	NextCursor    string                `json:"nextCursor,omitempty"`
+}
+
+func Handle(input events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	user, _, err := user.New().GetCurrentUser(input.Headers["Authorization"])
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	selection, err := dto.ArticleListSelection(input.QueryStringParameters)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	limit, err := strconv.Atoi(input.QueryStringParameters["limit"])
+	if err != nil {
+		limit = 20
+	}
+
+	articleService := article.New()
+	var articles []entities.Article
+	var nextCursor string
+
+	// offset is still accepted, otherwise pages are chained with ?cursor=
+	if offsetParameter, ok := input.QueryStringParameters["offset"]; ok {
+		offset, parseErr := strconv.Atoi(offsetParameter)
+		if parseErr != nil {
+			offset = 0
+		}
+		articles, err = articleService.GetFeed(user.Username, offset, limit)
+	} else {
+		articles, nextCursor, err = articleService.GetFeedPage(user.Username, input.QueryStringParameters["cursor"], limit)
+	}
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	isFavorited, authors, _, err := articleService.GetArticleRelatedProperties(user, articles, false)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	articleResponses := dto.NewArticleResponses(articles, isFavorited, authors, nil)
+	for i := range articleResponses {
+		// The feed only has articles of the followed authors
+		articleResponses[i].Author.Following = true
+	}
+	dto.SelectArticles(articleResponses, selection)
+
+	response := Response{
+		Articles:      articleResponses,
+		ArticlesCount: len(articleResponses),
+		NextCursor:    nextCursor,
+	}
+
+	return functions.NewSuccessResponse(200, response)
+}
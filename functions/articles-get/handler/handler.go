@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/functions"
+	"github.com/ferjmc/cms/functions/dto"
+	"github.com/ferjmc/cms/pkg/article"
+	"github.com/ferjmc/cms/pkg/user"
+)
+
+type Response struct {
+	Articles      []dto.ArticleResponse `json:"articles"`
+	ArticlesCount int                   `json:"articlesCount"`
+	NextCursor    string                `json:"nextCursor,omitempty"`
+}
+
+func Handle(input events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	userService := user.New()
+	user, _, err := userService.GetOptionalUser(input.Headers["Authorization"])
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	selection, err := dto.ArticleListSelection(input.QueryStringParameters)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	limit, err := strconv.Atoi(input.QueryStringParameters["limit"])
+	if err != nil {
+		limit = 20
+	}
+
+	author := input.QueryStringParameters["author"]
+	tag := input.QueryStringParameters["tag"]
+	favorited := input.QueryStringParameters["favorited"]
+
+	articleService := article.New()
+	var articles []entities.Article
+	var nextCursor string
+
+	// offset is still accepted, otherwise pages are chained with ?cursor=
+	if offsetParameter, ok := input.QueryStringParameters["offset"]; ok {
+		offset, parseErr := strconv.Atoi(offsetParameter)
+		if parseErr != nil {
+			offset = 0
+		}
+		articles, err = articleService.GetArticles(offset, limit, author, tag, favorited)
+	} else {
+		articles, nextCursor, err = articleService.GetArticlesPage(input.QueryStringParameters["cursor"], limit, author, tag, favorited)
+	}
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	isFavorited, authors, following, err := articleService.GetArticleRelatedProperties(user, articles, true)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	articleResponses := dto.NewArticleResponses(articles, isFavorited, authors, following)
+	dto.SelectArticles(articleResponses, selection)
+
+	response := Response{
+		Articles:      articleResponses,
+		ArticlesCount: len(articleResponses),
+		NextCursor:    nextCursor,
+	}
+
+	return functions.NewSuccessResponse(200, response)
+}
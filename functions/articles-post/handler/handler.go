@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/functions"
+	"github.com/ferjmc/cms/functions/dto"
+	"github.com/ferjmc/cms/pkg/article"
+	"github.com/ferjmc/cms/pkg/user"
+)
+
+type Request struct {
+	Article ArticleRequest `json:"article"`
+}
+
+type ArticleRequest struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Body        string   `json:"body"`
+	TagList     []string `json:"tagList"`
+	Status      string   `json:"status"`
+	PublishAt   string   `json:"publishAt"`
+}
+
+type Response struct {
+	Article dto.ArticleResponse `json:"article"`
+}
+
+func Handle(input events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	user, _, err := user.New().GetCurrentUser(input.Headers["Authorization"])
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	request := Request{}
+	err = json.Unmarshal([]byte(input.Body), &request)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	now := time.Now().UnixNano()
+
+	newArticle := entities.Article{
+		Title:       request.Article.Title,
+		Description: request.Article.Description,
+		Body:        request.Article.Body,
+		TagList:     request.Article.TagList,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Author:      user.Username,
+		Status:      request.Article.Status,
+	}
+
+	if request.Article.PublishAt != "" {
+		newArticle.PublishAt, err = entities.ParseTimestamp("publishAt", request.Article.PublishAt)
+		if err != nil {
+			return functions.NewErrorResponse(err)
+		}
+	}
+
+	err = article.New().PutArticle(&newArticle)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	response := Response{
+		Article: dto.NewArticleResponse(newArticle, *user, false, false),
+	}
+
+	return functions.NewSuccessResponse(201, response)
+}
@@ -0,0 +1,18 @@
+package handler
+
+import (
+	"log"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ferjmc/cms/pkg/article"
+)
+
+// Handle publishes the scheduled articles which are due, it runs on a schedule
+func Handle(event events.CloudWatchEvent) error {
+	published, err := article.New().PublishScheduledArticles(time.Now().UTC().UnixNano())
+	if published > 0 {
+		log.Printf("published %d scheduled articles", published)
+	}
+	return err
+}
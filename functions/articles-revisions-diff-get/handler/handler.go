@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/functions"
+	"github.com/ferjmc/cms/pkg/article"
+	"github.com/ferjmc/cms/pkg/user"
+)
+
+type Response struct {
+	Diff DiffResponse `json:"diff"`
+}
+
+type DiffResponse struct {
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+	// Body is the unified diff of the bodies, empty when they are the same
+	Body string `json:"body"`
+}
+
+// Handle compares the body of revision :revision with the one of ?from=, by default the previous revision
+func Handle(input events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	user, _, err := user.New().GetCurrentUser(input.Headers["Authorization"])
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	to, err := strconv.ParseInt(input.PathParameters["revision"], 10, 64)
+	if err != nil {
+		return functions.NewErrorResponse(entities.NewInputError("revision", "must be a number"))
+	}
+
+	from := to - 1
+	if fromParameter, ok := input.QueryStringParameters["from"]; ok {
+		from, err = strconv.ParseInt(fromParameter, 10, 64)
+		if err != nil {
+			return functions.NewErrorResponse(entities.NewInputError("from", "must be a number"))
+		}
+	}
+
+	articleService := article.New()
+	foundArticle, err := articleService.GetArticleBySlug(user, input.PathParameters["slug"])
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	unified, err := articleService.DiffRevisions(user, *foundArticle, from, to)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	response := Response{
+		Diff: DiffResponse{
+			From: from,
+			To:   to,
+			Body: unified,
+		},
+	}
+
+	return functions.NewSuccessResponse(200, response)
+}
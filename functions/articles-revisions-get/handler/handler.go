@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ferjmc/cms/functions"
+	"github.com/ferjmc/cms/functions/dto"
+	"github.com/ferjmc/cms/pkg/article"
+	"github.com/ferjmc/cms/pkg/user"
+)
+
+type Response struct {
+	Revisions      []RevisionResponse `json:"revisions"`
+	RevisionsCount int                `json:"revisionsCount"`
+	NextCursor     string             `json:"nextCursor,omitempty"`
+}
+
+type RevisionResponse struct {
+	Revision    int64    `json:"revision"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Body        string   `json:"body"`
+	TagList     []string `json:"tagList"`
+	Editor      string   `json:"editor"`
+	CreatedAt   string   `json:"createdAt"`
+}
+
+func Handle(input events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	user, _, err := user.New().GetCurrentUser(input.Headers["Authorization"])
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	limit, err := strconv.Atoi(input.QueryStringParameters["limit"])
+	if err != nil {
+		limit = 20
+	}
+
+	articleService := article.New()
+	foundArticle, err := articleService.GetArticleBySlug(user, input.PathParameters["slug"])
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	revisions, nextCursor, err := articleService.GetRevisionsPage(user, *foundArticle, input.QueryStringParameters["cursor"], limit)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	revisionResponses := make([]RevisionResponse, 0, len(revisions))
+	for _, revision := range revisions {
+		tagList := revision.TagList
+		if tagList == nil {
+			tagList = make([]string, 0)
+		}
+
+		revisionResponses = append(revisionResponses, RevisionResponse{
+			Revision:    revision.Revision,
+			Title:       revision.Title,
+			Description: revision.Description,
+			Body:        revision.Body,
+			TagList:     tagList,
+			Editor:      revision.Editor,
+			CreatedAt:   dto.FormatTimestamp(revision.CreatedAt),
+		})
+	}
+
+	response := Response{
+		Revisions:      revisionResponses,
+		RevisionsCount: len(revisionResponses),
+		NextCursor:     nextCursor,
+	}
+
+	return functions.NewSuccessResponse(200, response)
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/functions"
+	"github.com/ferjmc/cms/functions/dto"
+	"github.com/ferjmc/cms/pkg/article"
+	"github.com/ferjmc/cms/pkg/user"
+)
+
+type Response struct {
+	Article dto.ArticleResponse `json:"article"`
+}
+
+func Handle(input events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	user, _, err := user.New().GetCurrentUser(input.Headers["Authorization"])
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	revision, err := strconv.ParseInt(input.PathParameters["revision"], 10, 64)
+	if err != nil {
+		return functions.NewErrorResponse(entities.NewInputError("revision", "must be a number"))
+	}
+
+	articleService := article.New()
+	oldArticle, err := articleService.GetArticleBySlug(user, input.PathParameters["slug"])
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	newArticle, err := articleService.RestoreRevision(user, *oldArticle, revision)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	articles := []entities.Article{*newArticle}
+	isFavorited, authors, following, err := articleService.GetArticleRelatedProperties(user, articles, true)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	response := Response{
+		Article: dto.NewArticleResponse(*newArticle, authors[0], isFavorited[0], following[0]),
+	}
+
+	return functions.NewSuccessResponse(200, response)
+}
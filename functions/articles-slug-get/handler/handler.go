@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/functions"
+	"github.com/ferjmc/cms/functions/dto"
+	"github.com/ferjmc/cms/pkg/article"
+	"github.com/ferjmc/cms/pkg/user"
+)
+
+type Response struct {
+	Article dto.ArticleResponse `json:"article"`
+}
+
+func Handle(input events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	user, _, err := user.New().GetOptionalUser(input.Headers["Authorization"])
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	selection, err := dto.ArticleSelection(input.QueryStringParameters)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	articleService := article.New()
+	foundArticle, err := articleService.GetArticleBySlug(user, input.PathParameters["slug"])
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	articles := []entities.Article{*foundArticle}
+	isFavorited, authors, following, err := articleService.GetArticleRelatedProperties(user, articles, true)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	response := Response{
+		Article: dto.NewArticleResponse(*foundArticle, authors[0], isFavorited[0], following[0]),
+	}
+	response.Article.Select(selection)
+
+	res, err := functions.NewSuccessResponse(200, response)
+	if err == nil && input.PathParameters["slug"] != foundArticle.Slug {
+		// The title prefix is stale, the article is still served but clients
+		// are told about the canonical slug
+		res.Headers["Content-Location"] = "/articles/" + foundArticle.Slug
+	}
+	return res, err
+}
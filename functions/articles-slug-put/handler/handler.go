@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/functions"
+	"github.com/ferjmc/cms/functions/dto"
+	"github.com/ferjmc/cms/pkg/article"
+	"github.com/ferjmc/cms/pkg/user"
+)
+
+type Request struct {
+	Article ArticleRequest `json:"article"`
+}
+
+type ArticleRequest struct {
+	Title       *string   `json:"title"`
+	Description *string   `json:"description"`
+	Body        *string   `json:"body"`
+	TagList     *[]string `json:"tagList"`
+	Status      string    `json:"status"`
+	PublishAt   string    `json:"publishAt"`
+}
+
+type Response struct {
+	Article dto.ArticleResponse `json:"article"`
+}
+
+func Handle(input events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	user, _, err := user.New().GetCurrentUser(input.Headers["Authorization"])
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	request := Request{}
+	err = json.Unmarshal([]byte(input.Body), &request)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	articleService := article.New()
+	oldArticle, err := articleService.GetArticleBySlug(user, input.PathParameters["slug"])
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	// Fields missing from the request keep their current value
+	newArticle := *oldArticle
+	if request.Article.Title != nil {
+		newArticle.Title = *request.Article.Title
+	}
+	if request.Article.Description != nil {
+		newArticle.Description = *request.Article.Description
+	}
+	if request.Article.Body != nil {
+		newArticle.Body = *request.Article.Body
+	}
+	if request.Article.TagList != nil {
+		newArticle.TagList = *request.Article.TagList
+	}
+	// An empty status keeps the current one
+	newArticle.Status = request.Article.Status
+	if request.Article.PublishAt != "" {
+		newArticle.PublishAt, err = entities.ParseTimestamp("publishAt", request.Article.PublishAt)
+		if err != nil {
+			return functions.NewErrorResponse(err)
+		}
+	}
+
+	err = articleService.UpdateArticle(user, *oldArticle, &newArticle)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	articles := []entities.Article{newArticle}
+	isFavorited, authors, following, err := articleService.GetArticleRelatedProperties(user, articles, true)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	response := Response{
+		Article: dto.NewArticleResponse(newArticle, authors[0], isFavorited[0], following[0]),
+	}
+
+	return functions.NewSuccessResponse(200, response)
+}
@@ -0,0 +1,94 @@
+// Package dto maps the entities to the JSON bodies shared by the functions
+package dto
+
+import (
+	"time"
+
+	"github.com/ferjmc/cms/entities"
+)
+
+type ArticleResponse struct {
+	Slug           string         `json:"slug"`
+	Title          string         `json:"title"`
+	Description    string         `json:"description"`
+	Body           string         `json:"body"`
+	TagList        []string       `json:"tagList"`
+	CreatedAt      string         `json:"createdAt"`
+	UpdatedAt      string         `json:"updatedAt"`
+	Favorited      bool           `json:"favorited"`
+	FavoritesCount int64          `json:"favoritesCount"`
+	Author         AuthorResponse `json:"author"`
+	Status         string         `json:"status"`
+	PublishAt      string         `json:"publishAt,omitempty"`
+	Revision       int64          `json:"revision"`
+
+	selection Selection
+}
+
+type AuthorResponse struct {
+	Username  string `json:"username"`
+	Bio       string `json:"bio"`
+	Image     string `json:"image"`
+	Following bool   `json:"following"`
+}
+
+// NewArticleResponse maps an article with its author, and whether the current user
+// favorited the article and follows the author
+func NewArticleResponse(article entities.Article, author entities.User, favorited, following bool) ArticleResponse {
+	return ArticleResponse{
+		Slug:           article.Slug,
+		Title:          article.Title,
+		Description:    article.Description,
+		Body:           article.Body,
+		TagList:        article.TagList,
+		CreatedAt:      FormatTimestamp(article.CreatedAt),
+		UpdatedAt:      FormatTimestamp(article.UpdatedAt),
+		Favorited:      favorited,
+		FavoritesCount: article.FavoritesCount,
+		Author:         NewAuthorResponse(author, following),
+		Status:         article.CurrentStatus(),
+		PublishAt:      entities.FormatOptionalTimestamp(article.PublishAt),
+		Revision:       article.Revision,
+	}
+}
+
+// NewArticleResponses maps articles with the properties GetArticleRelatedProperties returned
+// for them. following is empty when they were loaded without it.
+func NewArticleResponses(articles []entities.Article, isFavorited []bool, authors []entities.User, following []bool) []ArticleResponse {
+	responses := make([]ArticleResponse, 0, len(articles))
+	for i, article := range articles {
+		responses = append(responses, NewArticleResponse(article, authors[i], isFavorited[i], i < len(following) && following[i]))
+	}
+	return responses
+}
+
+func NewAuthorResponse(author entities.User, following bool) AuthorResponse {
+	return AuthorResponse{
+		Username:  author.Username,
+		Bio:       author.Bio,
+		Image:     author.Image,
+		Following: following,
+	}
+}
+
+// Select keeps only the selected fields when the article is marshaled
+func (r *ArticleResponse) Select(selection Selection) {
+	r.selection = selection
+}
+
+// SelectArticles keeps only the selected fields of every article
+func SelectArticles(responses []ArticleResponse, selection Selection) {
+	for i := range responses {
+		responses[i].Select(selection)
+	}
+}
+
+func (r ArticleResponse) MarshalJSON() ([]byte, error) {
+	type article ArticleResponse
+	return r.selection.marshal(article(r))
+}
+
+// FormatTimestamp formats nanoseconds since the epoch with entities.TimestampFormat
+func FormatTimestamp(nanos int64) string {
+	return time.Unix(0, nanos).UTC().Format(entities.TimestampFormat)
+}
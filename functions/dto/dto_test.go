@@ -0,0 +1,93 @@
+package dto
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/ferjmc/cms/entities"
+)
+
+func TestNewArticleResponses(t *testing.T) {
+	articles := []entities.Article{
+		{Slug: "a-1", Title: "A", Body: "body", Author: "alice", CreatedAt: 1e9, UpdatedAt: 2e9, FavoritesCount: 3},
+		{Slug: "b-2", Title: "B", Body: "body", Author: "bob", Status: entities.ArticleStatusDraft},
+	}
+	authors := []entities.User{{Username: "alice", Bio: "bio"}, {Username: "bob"}}
+
+	responses := NewArticleResponses(articles, []bool{true, false}, authors, nil)
+
+	first := responses[0]
+	if first.CreatedAt != "1970-01-01T00:00:01.000Z" || first.UpdatedAt != "1970-01-01T00:00:02.000Z" {
+		t.Errorf("unexpected timestamps %s %s", first.CreatedAt, first.UpdatedAt)
+	}
+	if !first.Favorited || first.FavoritesCount != 3 || first.Author.Username != "alice" || first.Author.Bio != "bio" {
+		t.Errorf("unexpected response %+v", first)
+	}
+	if first.Author.Following || responses[1].Author.Following {
+		t.Errorf("authors must not be followed without the following flags")
+	}
+	if first.Status != entities.ArticleStatusPublished || responses[1].Status != entities.ArticleStatusDraft {
+		t.Errorf("unexpected statuses %s %s", first.Status, responses[1].Status)
+	}
+}
+
+func TestArticleSelection(t *testing.T) {
+	article := NewArticleResponse(entities.Article{Slug: "a-1", Title: "A", Body: "body"}, entities.User{Username: "alice"}, false, false)
+
+	tests := []struct {
+		name   string
+		query  map[string]string
+		list   bool
+		fields []string
+	}{
+		{"every field", map[string]string{}, true, nil},
+		{"selected fields", map[string]string{"fields": "slug, title"}, false, []string{"slug", "title"}},
+		{"excerpt", map[string]string{"excerpt": "true", "fields": "slug,body"}, true, []string{"slug"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selection, err := ArticleSelection(test.query)
+			if test.list {
+				selection, err = ArticleListSelection(test.query)
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %s", err)
+			}
+
+			selected := article
+			selected.Select(selection)
+			body, err := json.Marshal(selected)
+			if err != nil {
+				t.Fatalf("marshal: %s", err)
+			}
+
+			var fields map[string]interface{}
+			json.Unmarshal(body, &fields)
+			if test.fields == nil {
+				if len(fields) != len(jsonNames(reflect.TypeOf(article)))-1 || fields["body"] != "body" {
+					t.Errorf("expected every field but publishAt, got %s", body)
+				}
+				return
+			}
+
+			names := make([]string, 0, len(fields))
+			for name := range fields {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, test.fields) {
+				t.Errorf("expected %v, got %s", test.fields, body)
+			}
+		})
+	}
+
+	for _, query := range []map[string]string{{"fields": "slug,password"}, {"fields": ","}, {"excerpt": "yes"}} {
+		_, err := ArticleListSelection(query)
+		if _, ok := err.(entities.InputError); !ok {
+			t.Errorf("%v: expected an input error, got %v", query, err)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package dto
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/ferjmc/cms/entities"
+)
+
+// Selection is the set of fields a client asked for with ?fields=slug,title, the zero
+// value selects every field
+type Selection struct {
+	only    map[string]bool
+	omitted map[string]bool
+}
+
+// ArticleSelection reads the ?fields= parameter of the endpoints returning one article
+func ArticleSelection(query map[string]string) (Selection, error) {
+	return parseSelection(query["fields"], ArticleResponse{})
+}
+
+// ArticleListSelection also reads ?excerpt=true, which leaves out the body of every
+// article in a list
+func ArticleListSelection(query map[string]string) (Selection, error) {
+	selection, err := ArticleSelection(query)
+	if err != nil {
+		return Selection{}, err
+	}
+
+	switch query["excerpt"] {
+	case "", "false":
+	case "true":
+		selection.omitted = map[string]bool{"body": true}
+	default:
+		return Selection{}, entities.NewInputError("excerpt", "must be true or false")
+	}
+
+	return selection, nil
+}
+
+// Includes tells whether the field with the JSON name is selected
+func (s Selection) Includes(name string) bool {
+	return (s.only == nil || s.only[name]) && !s.omitted[name]
+}
+
+func (s Selection) marshal(v interface{}) ([]byte, error) {
+	body, err := json.Marshal(v)
+	if err != nil || (s.only == nil && s.omitted == nil) {
+		return body, err
+	}
+
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(body, &fields)
+	if err != nil {
+		return nil, err
+	}
+
+	for name := range fields {
+		if !s.Includes(name) {
+			delete(fields, name)
+		}
+	}
+
+	return json.Marshal(fields)
+}
+
+func parseSelection(parameter string, response interface{}) (Selection, error) {
+	if parameter == "" {
+		return Selection{}, nil
+	}
+
+	known := jsonNames(reflect.TypeOf(response))
+	only := make(map[string]bool)
+	for _, name := range strings.Split(parameter, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !known[name] {
+			return Selection{}, entities.NewInputError("fields", "has an unknown field "+name)
+		}
+		only[name] = true
+	}
+
+	if len(only) == 0 {
+		return Selection{}, entities.NewInputError("fields", "can't be blank")
+	}
+
+	return Selection{only: only}, nil
+}
+
+// jsonNames returns the names the exported fields of a struct are marshaled with
+func jsonNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" {
+			name = field.Name
+		}
+		if name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}
@@ -0,0 +1,53 @@
+package dto
+
+import "github.com/ferjmc/cms/entities"
+
+type UserResponse struct {
+	Username string   `json:"username"`
+	Email    string   `json:"email"`
+	Image    string   `json:"image"`
+	Bio      string   `json:"bio"`
+	Verified bool     `json:"verified"`
+	Roles    []string `json:"roles"`
+	Token    string   `json:"token,omitempty"`
+	// RefreshToken of the session started by the request, if any
+	RefreshToken string `json:"refreshToken,omitempty"`
+}
+
+type ProfileResponse struct {
+	Username  string `json:"username"`
+	Image     string `json:"image"`
+	Bio       string `json:"bio"`
+	Following bool   `json:"following"`
+}
+
+// NewUserResponse maps the current user with the tokens of their session, the refresh
+// token is only known when the session starts
+func NewUserResponse(user entities.User, token, refreshToken string) UserResponse {
+	roles := user.Roles
+	if roles == nil {
+		roles = make([]string, 0)
+	}
+
+	return UserResponse{
+		Username:     user.Username,
+		Email:        user.Email,
+		Image:        user.Image,
+		Bio:          user.Bio,
+		Verified:     user.Verified,
+		Roles:        roles,
+		Token:        token,
+		RefreshToken: refreshToken,
+	}
+}
+
+// NewProfileResponse maps the public profile of a user, following tells whether the
+// current user follows them
+func NewProfileResponse(user entities.User, following bool) ProfileResponse {
+	return ProfileResponse{
+		Username:  user.Username,
+		Image:     user.Image,
+		Bio:       user.Bio,
+		Following: following,
+	}
+}
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ferjmc/cms/functions"
+	"github.com/ferjmc/cms/pkg/auth"
+)
+
+// Handle publishes the JWKS document, other services verify our tokens with it
+func Handle(input events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	jwks, err := auth.New().JWKS()
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	response, err := functions.NewSuccessResponse(200, jwks)
+	if err != nil {
+		return response, err
+	}
+
+	// Verifiers fetch the document again on an unknown kid
+	response.Headers["Cache-Control"] = "public, max-age=300"
+
+	return response, nil
+}
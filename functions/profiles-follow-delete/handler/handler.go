@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ferjmc/cms/functions"
+	"github.com/ferjmc/cms/functions/dto"
+	"github.com/ferjmc/cms/pkg/follow"
+	"github.com/ferjmc/cms/pkg/user"
+)
+
+type Response struct {
+	Profile dto.ProfileResponse `json:"profile"`
+}
+
+func Handle(input events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	userService := user.New()
+	user, _, err := userService.GetCurrentUser(input.Headers["Authorization"])
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	publisher, err := userService.GetUserByUsername(input.PathParameters["username"])
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	followService := follow.New()
+	err = followService.Unfollow(user.Username, publisher.Username)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	response := Response{
+		Profile: dto.NewProfileResponse(*publisher, false),
+	}
+
+	return functions.NewSuccessResponse(200, response)
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ferjmc/cms/functions"
+	"github.com/ferjmc/cms/functions/dto"
+	"github.com/ferjmc/cms/pkg/follow"
+	"github.com/ferjmc/cms/pkg/user"
+)
+
+type Response struct {
+	Profile dto.ProfileResponse `json:"profile"`
+}
+
+func Handle(input events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	userService := user.New()
+	user, _, err := userService.GetOptionalUser(input.Headers["Authorization"])
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	// A former username redirects to the profile under the current one
+	publisher, err := userService.GetUserByUsernameOrAlias(input.PathParameters["username"])
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	followService := follow.New()
+	following, err := followService.IsFollowing(user, []string{publisher.Username})
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	response := Response{
+		Profile: dto.NewProfileResponse(*publisher, following[0]),
+	}
+
+	if publisher.Username != input.PathParameters["username"] {
+		redirect, err := functions.NewSuccessResponse(http.StatusMovedPermanently, response)
+		// Relative to /profiles/:username, whatever the stage prefix
+		redirect.Headers["Location"] = url.PathEscape(publisher.Username)
+		return redirect, err
+	}
+
+	return functions.NewSuccessResponse(200, response)
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ferjmc/cms/functions"
+	"github.com/ferjmc/cms/pkg/tag"
+)
+
+type Response struct {
+	Tags []string `json:"tags"`
+}
+
+func Handle(input events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	limit, err := strconv.Atoi(input.QueryStringParameters["limit"])
+	if err != nil {
+		limit = 20
+	}
+
+	prefix := input.QueryStringParameters["prefix"]
+
+	tags, err := tag.New().GetTags(prefix, limit)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	tagNames := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tagNames = append(tagNames, tag.Tag)
+	}
+
+	response := Response{
+		Tags: tagNames,
+	}
+
+	return functions.NewSuccessResponse(200, response)
+}
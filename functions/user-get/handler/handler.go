@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ferjmc/cms/functions"
+	"github.com/ferjmc/cms/functions/dto"
+	"github.com/ferjmc/cms/pkg/user"
+)
+
+type Response struct {
+	User dto.UserResponse `json:"user"`
+}
+
+func Handle(input events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	userService := user.New()
+	user, token, err := userService.GetCurrentUser(input.Headers["Authorization"])
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	response := Response{
+		User: dto.NewUserResponse(*user, token, ""),
+	}
+	return functions.NewSuccessResponse(200, response)
+}
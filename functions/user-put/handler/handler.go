@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/functions"
+	"github.com/ferjmc/cms/functions/dto"
+	"github.com/ferjmc/cms/pkg/account"
+	"github.com/ferjmc/cms/pkg/session"
+	"github.com/ferjmc/cms/pkg/user"
+)
+
+type Request struct {
+	User UserRequest `json:"user"`
+}
+
+// UserRequest fields left out of the request keep their current value
+type UserRequest struct {
+	Username *string `json:"username"`
+	Email    *string `json:"email"`
+	Password *string `json:"password"`
+	// CurrentPassword is required to change the password
+	CurrentPassword string  `json:"currentPassword"`
+	Image           *string `json:"image"`
+	Bio             *string `json:"bio"`
+}
+
+type Response struct {
+	User dto.UserResponse `json:"user"`
+}
+
+func Handle(input events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	var request Request
+	err := json.Unmarshal([]byte(input.Body), &request)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	patch := entities.UserPatch{
+		Username:        request.User.Username,
+		Email:           request.User.Email,
+		Password:        request.User.Password,
+		CurrentPassword: request.User.CurrentPassword,
+		Image:           request.User.Image,
+		Bio:             request.User.Bio,
+	}
+
+	userService := user.New()
+	oldUser, token, err := userService.GetCurrentUser(input.Headers["Authorization"])
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	user, err := userService.UpdateUser(oldUser, patch)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	// The new email must be verified, the update succeeds anyway
+	if user.Email != oldUser.Email {
+		err = account.New().RequestEmailVerification(*user)
+		if err != nil {
+			log.Printf("ERROR: email verification of %s: %s", user.Username, err)
+		}
+	}
+
+	// The password or username change revoked every token, the other sessions end and a new one starts
+	var refreshToken string
+	if patch.Password != nil || user.Username != oldUser.Username {
+		sessions := session.New()
+		err = sessions.LogoutEverywhere(oldUser.Username)
+		if err != nil {
+			return functions.NewErrorResponse(err)
+		}
+
+		tokens, err := sessions.Create(user.Username)
+		if err != nil {
+			return functions.NewErrorResponse(err)
+		}
+		token, refreshToken = tokens.Token, tokens.RefreshToken
+	}
+
+	response := Response{
+		User: dto.NewUserResponse(*user, token, refreshToken),
+	}
+
+	return functions.NewSuccessResponse(200, response)
+}
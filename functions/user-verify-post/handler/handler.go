@@ -0,0 +1,23 @@
+package handler
+
+import (
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ferjmc/cms/functions"
+	"github.com/ferjmc/cms/pkg/account"
+	"github.com/ferjmc/cms/pkg/user"
+)
+
+// Handle mails a new verification token to the email of the current user
+func Handle(input events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	user, _, err := user.New().GetCurrentUser(input.Headers["Authorization"])
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	err = account.New().RequestEmailVerification(*user)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	return functions.NewSuccessResponse(202, nil)
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ferjmc/cms/functions"
+	"github.com/ferjmc/cms/functions/dto"
+	"github.com/ferjmc/cms/pkg/session"
+	"github.com/ferjmc/cms/pkg/user"
+)
+
+type Request struct {
+	User UserRequest `json:"user"`
+}
+
+type UserRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type Response struct {
+	User dto.UserResponse `json:"user"`
+}
+
+func Handle(input events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	request := Request{}
+	err := json.Unmarshal([]byte(input.Body), &request)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	serv := user.New()
+
+	user, err := serv.Login(request.User.Email, request.User.Password)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	tokens, err := session.New().Create(user.Username)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	response := Response{
+		User: dto.NewUserResponse(*user, tokens.Token, tokens.RefreshToken),
+	}
+
+	return functions.NewSuccessResponse(200, response)
+}
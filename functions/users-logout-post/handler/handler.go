@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ferjmc/cms/functions"
+	"github.com/ferjmc/cms/pkg/session"
+	"github.com/ferjmc/cms/pkg/user"
+)
+
+type Request struct {
+	User UserRequest `json:"user"`
+}
+
+type UserRequest struct {
+	RefreshToken string `json:"refreshToken"`
+	// Everywhere ends every session of the current user and revokes its access tokens
+	Everywhere bool `json:"everywhere"`
+}
+
+func Handle(input events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	request := Request{}
+	err := json.Unmarshal([]byte(input.Body), &request)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	sessions := session.New()
+
+	if !request.User.Everywhere {
+		err = sessions.Logout(request.User.RefreshToken)
+		if err != nil {
+			return functions.NewErrorResponse(err)
+		}
+		return functions.NewSuccessResponse(200, nil)
+	}
+
+	userService := user.New()
+	currentUser, _, err := userService.GetCurrentUser(input.Headers["Authorization"])
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	err = userService.RevokeTokens(currentUser)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	err = sessions.LogoutEverywhere(currentUser.Username)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	return functions.NewSuccessResponse(200, nil)
+}
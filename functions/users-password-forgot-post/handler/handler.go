@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ferjmc/cms/functions"
+	"github.com/ferjmc/cms/pkg/account"
+)
+
+type Request struct {
+	User UserRequest `json:"user"`
+}
+
+type UserRequest struct {
+	Email string `json:"email"`
+}
+
+// Handle mails a password reset token, the response is the same whether the email is registered or not
+func Handle(input events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	request := Request{}
+	err := json.Unmarshal([]byte(input.Body), &request)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	err = account.New().RequestPasswordReset(request.User.Email)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	return functions.NewSuccessResponse(202, nil)
+}
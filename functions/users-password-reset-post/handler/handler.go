@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ferjmc/cms/functions"
+	"github.com/ferjmc/cms/functions/dto"
+	"github.com/ferjmc/cms/pkg/account"
+	"github.com/ferjmc/cms/pkg/session"
+	"github.com/ferjmc/cms/pkg/user"
+)
+
+type Request struct {
+	User UserRequest `json:"user"`
+}
+
+type UserRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type Response struct {
+	User dto.UserResponse `json:"user"`
+}
+
+func Handle(input events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	request := Request{}
+	err := json.Unmarshal([]byte(input.Body), &request)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	username, err := account.New().ResetPassword(request.User.Token, request.User.Password)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	// Whoever knew the old password is logged out, and the user is logged in
+	sessions := session.New()
+	err = sessions.LogoutEverywhere(username)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	tokens, err := sessions.Create(username)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	user, err := user.New().GetUserByUsername(username)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	response := Response{
+		User: dto.NewUserResponse(*user, tokens.Token, tokens.RefreshToken),
+	}
+
+	return functions.NewSuccessResponse(200, response)
+}
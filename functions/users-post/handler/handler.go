@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/functions"
+	"github.com/ferjmc/cms/functions/dto"
+	"github.com/ferjmc/cms/pkg/account"
+	"github.com/ferjmc/cms/pkg/session"
+	"github.com/ferjmc/cms/pkg/user"
+)
+
+type Request struct {
+	User UserRequest `json:"user"`
+}
+
+type UserRequest struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type Response struct {
+	User dto.UserResponse `json:"user"`
+}
+
+func Handle(input events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	request := Request{}
+	err := json.Unmarshal([]byte(input.Body), &request)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	service := user.New()
+
+	newUser := entities.User{
+		Username: request.User.Username,
+		Email:    request.User.Email,
+	}
+
+	err = service.PutUser(newUser, request.User.Password)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	// The user can ask for another email later, the registration succeeds anyway
+	err = account.New().RequestEmailVerification(newUser)
+	if err != nil {
+		log.Printf("ERROR: email verification of %s: %s", newUser.Username, err)
+	}
+
+	tokens, err := session.New().Create(newUser.Username)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	response := Response{
+		User: dto.NewUserResponse(newUser, tokens.Token, tokens.RefreshToken),
+	}
+
+	return functions.NewSuccessResponse(201, response)
+}
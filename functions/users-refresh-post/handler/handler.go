@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ferjmc/cms/functions"
+	"github.com/ferjmc/cms/functions/dto"
+	"github.com/ferjmc/cms/pkg/session"
+	"github.com/ferjmc/cms/pkg/user"
+)
+
+type Request struct {
+	User UserRequest `json:"user"`
+}
+
+type UserRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+type Response struct {
+	User dto.UserResponse `json:"user"`
+}
+
+func Handle(input events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	request := Request{}
+	err := json.Unmarshal([]byte(input.Body), &request)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	username, tokens, err := session.New().Refresh(request.User.RefreshToken)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	user, err := user.New().GetUserByUsername(username)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	response := Response{
+		User: dto.NewUserResponse(*user, tokens.Token, tokens.RefreshToken),
+	}
+
+	return functions.NewSuccessResponse(200, response)
+}
@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"log"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ferjmc/cms/pkg/rename"
+)
+
+// Budget of a run, below the timeout of the function. Unfinished migrations resume on the next run.
+const Budget = 50 * time.Second
+
+// Handle rewrites the rows still naming the former usernames of renamed users, it runs on a schedule
+func Handle(event events.CloudWatchEvent) error {
+	completed, err := rename.New().MigratePending(time.Now().Add(Budget))
+	if completed > 0 {
+		log.Printf("completed %d username migrations", completed)
+	}
+	return err
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ferjmc/cms/functions"
+	"github.com/ferjmc/cms/functions/dto"
+	"github.com/ferjmc/cms/pkg/account"
+)
+
+type Request struct {
+	User UserRequest `json:"user"`
+}
+
+type UserRequest struct {
+	Token string `json:"token"`
+}
+
+type Response struct {
+	User dto.UserResponse `json:"user"`
+}
+
+// Handle verifies an email with the token mailed to it, the user doesn't need to be logged in
+func Handle(input events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	request := Request{}
+	err := json.Unmarshal([]byte(input.Body), &request)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	user, err := account.New().VerifyEmail(request.User.Token)
+	if err != nil {
+		return functions.NewErrorResponse(err)
+	}
+
+	response := Response{
+		User: dto.NewUserResponse(*user, "", ""),
+	}
+
+	return functions.NewSuccessResponse(200, response)
+}
@@ -6,6 +6,6 @@ require (
 	github.com/aws/aws-lambda-go v1.26.0
 	github.com/aws/aws-sdk-go v1.40.26
 	github.com/dgrijalva/jwt-go v3.2.0+incompatible
-	github.com/gosimple/slug v1.10.0 // indirect
+	github.com/gosimple/slug v1.10.0
 	golang.org/x/crypto v0.0.0-20210817164053-32db794688a5
 )
@@ -1,7 +1,9 @@
 #!/bin/bash
-for r in route/*; do
-    if [ -d "$r" ]; then
+set -e
+for r in functions/*; do
+    # Shared packages like functions/dto aren't lambdas
+    if [ -f "$r/main.go" ]; then
         r=$(basename "$r")
         env GO111MODULE=on GOOS=linux GOARCH=amd64 go build -ldflags="-s -w" -o bin/$r functions/$r/main.go
     fi
-done
\ No newline at end of file
+done
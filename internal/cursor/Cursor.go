@@ -0,0 +1,49 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/ferjmc/cms/entities"
+)
+
+// Encode turns a pagination position into an opaque url safe token
+func Encode(position interface{}) (string, error) {
+	js, err := json.Marshal(position)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(js), nil
+}
+
+// Decode reads back a token made by Encode, an empty token is the first page
+func Decode(token string, position interface{}) (bool, error) {
+	if token == "" {
+		return false, nil
+	}
+
+	js, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return false, entities.NewInputError("cursor", "invalid")
+	}
+
+	err = json.Unmarshal(js, position)
+	if err != nil {
+		return false, entities.NewInputError("cursor", "invalid")
+	}
+
+	return true, nil
+}
+
+// Position is a place in a list sorted by descending Key then descending Id,
+// like articles sorted by CreatedAt
+type Position struct {
+	Key int64 `json:"k"`
+	Id  int64 `json:"i"`
+}
+
+// After reports whether an item with key and id comes after the position in the list
+func (p Position) After(key, id int64) bool {
+	return key < p.Key || (key == p.Key && id < p.Id)
+}
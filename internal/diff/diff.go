@@ -0,0 +1,247 @@
+// Package diff compares texts line by line and prints the differences in the
+// unified format of diff -u. Edits are found with the Myers algorithm, after the
+// common prefix and suffix are trimmed, so small edits of long texts stay cheap.
+// Texts too different to be compared within maxEdits are replaced whole.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxEdits bounds the rounds of the Myers algorithm, which keeps O(D²) memory for D edits
+var maxEdits = 1000
+
+type Op int
+
+const (
+	Equal Op = iota
+	Delete
+	Insert
+)
+
+// Edit is a line kept, deleted from the old text or inserted in the new text
+type Edit struct {
+	Op   Op
+	Line string
+}
+
+// Lines returns the shortest list of edits turning a into b. When more than maxEdits
+// are needed, the lines between the common prefix and suffix are deleted and inserted whole.
+func Lines(a, b []string) []Edit {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	edits := make([]Edit, 0, len(a)+len(b)-prefix-suffix)
+	for _, line := range a[:prefix] {
+		edits = append(edits, Edit{Op: Equal, Line: line})
+	}
+	edits = append(edits, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		edits = append(edits, Edit{Op: Equal, Line: line})
+	}
+
+	return edits
+}
+
+// myers finds the edits with the greedy algorithm of "An O(ND) Difference Algorithm and Its Variations".
+// Every round d keeps the furthest x reached on each diagonal k=x-y, the edits are read back from them.
+func myers(a, b []string) []Edit {
+	n, m := len(a), len(b)
+	max := n + m
+	if max == 0 {
+		return nil
+	}
+
+	// v[offset+k] is the furthest x on diagonal k, trace[d] holds the diagonals -d..d after round d
+	offset := max + 1
+	v := make([]int, 2*max+2)
+	trace := make([][]int, 0)
+
+	for d := 0; d <= max && d <= maxEdits; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+				return backtrack(a, b, trace)
+			}
+		}
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+	}
+
+	return replace(a, b)
+}
+
+// replace deletes every line of a and inserts every line of b
+func replace(a, b []string) []Edit {
+	edits := make([]Edit, 0, len(a)+len(b))
+	for _, line := range a {
+		edits = append(edits, Edit{Op: Delete, Line: line})
+	}
+	for _, line := range b {
+		edits = append(edits, Edit{Op: Insert, Line: line})
+	}
+	return edits
+}
+
+func backtrack(a, b []string, trace [][]int) []Edit {
+	// furthest returns the x reached on diagonal k after round d
+	furthest := func(d, k int) int {
+		return trace[d][k+d]
+	}
+
+	edits := make([]Edit, 0, len(a)+len(b))
+	x, y := len(a), len(b)
+
+	for d := len(trace) - 1; d > 0; d-- {
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && furthest(d-1, k-1) < furthest(d-1, k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := furthest(d-1, prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			edits = append(edits, Edit{Op: Equal, Line: a[x-1]})
+			x--
+			y--
+		}
+
+		if x == prevX {
+			edits = append(edits, Edit{Op: Insert, Line: b[y-1]})
+		} else {
+			edits = append(edits, Edit{Op: Delete, Line: a[x-1]})
+		}
+		x, y = prevX, prevY
+	}
+
+	// Round 0 only follows the diagonal from the origin
+	for x > 0 && y > 0 {
+		edits = append(edits, Edit{Op: Equal, Line: a[x-1]})
+		x--
+		y--
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// SplitLines splits text into lines, a final newline doesn't start an empty line
+func SplitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// Unified returns the differences between a and b with context lines around every change,
+// headed by fromName and toName. It is empty when the texts have the same lines.
+func Unified(fromName, toName, a, b string, context int) string {
+	edits := Lines(SplitLines(a), SplitLines(b))
+
+	// Line of a and b before every edit
+	aLines := make([]int, len(edits)+1)
+	bLines := make([]int, len(edits)+1)
+	changes := make([]int, 0)
+	for i, edit := range edits {
+		aLines[i+1], bLines[i+1] = aLines[i], bLines[i]
+		if edit.Op != Insert {
+			aLines[i+1]++
+		}
+		if edit.Op != Delete {
+			bLines[i+1]++
+		}
+		if edit.Op != Equal {
+			changes = append(changes, i)
+		}
+	}
+
+	if len(changes) == 0 {
+		return ""
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+
+	for first := 0; first < len(changes); {
+		// Changes closer than two contexts share a hunk
+		last := first
+		for last+1 < len(changes) && changes[last+1]-changes[last]-1 <= 2*context {
+			last++
+		}
+
+		start := maxInt(0, changes[first]-context)
+		end := minInt(len(edits), changes[last]+context+1)
+
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n",
+			hunkRange(aLines[start], aLines[end]-aLines[start]),
+			hunkRange(bLines[start], bLines[end]-bLines[start]))
+
+		for _, edit := range edits[start:end] {
+			switch edit.Op {
+			case Equal:
+				out.WriteString(" ")
+			case Delete:
+				out.WriteString("-")
+			case Insert:
+				out.WriteString("+")
+			}
+			out.WriteString(edit.Line)
+			out.WriteString("\n")
+		}
+
+		first = last + 1
+	}
+
+	return out.String()
+}
+
+// hunkRange formats the lines of a hunk like diff -u, an empty range names the line before it
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, count)
+	}
+}
+
+func minInt(x, y int) int {
+	if x < y {
+		return x
+	}
+	return y
+}
+
+func maxInt(x, y int) int {
+	if x > y {
+		return x
+	}
+	return y
+}
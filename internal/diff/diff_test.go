@@ -0,0 +1,144 @@
+package diff
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	cases := []struct {
+		name     string
+		a, b     string
+		expected string
+	}{
+		{
+			name: "same lines",
+			a:    "one\ntwo\n",
+			b:    "one\ntwo",
+		},
+		{
+			name:     "from empty",
+			a:        "",
+			b:        "one\ntwo\n",
+			expected: "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+one\n+two\n",
+		},
+		{
+			name:     "changed line with context",
+			a:        "1\n2\n3\n4\n5\n6\n7\n8\n",
+			b:        "1\n2\n3\n4\nfive\n6\n7\n8\n",
+			expected: "--- a\n+++ b\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name:     "distant changes make two hunks",
+			a:        "a\n1\n2\n3\n4\n5\n6\n7\n8\nz\n",
+			b:        "A\n1\n2\n3\n4\n5\n6\n7\n8\n",
+			expected: "--- a\n+++ b\n@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n 3\n@@ -7,4 +7,3 @@\n 6\n 7\n 8\n-z\n",
+		},
+		{
+			name:     "close changes share a hunk",
+			a:        "a\n1\n2\nb\n",
+			b:        "1\n2\n",
+			expected: "--- a\n+++ b\n@@ -1,4 +1,2 @@\n-a\n 1\n 2\n-b\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := Unified("a", "b", c.a, c.b, 3)
+			if got != c.expected {
+				t.Errorf("expected\n%s\ngot\n%s", c.expected, got)
+			}
+		})
+	}
+}
+
+func TestLinesRebuildsBothTexts(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	randomLines := func() []string {
+		lines := make([]string, random.Intn(20))
+		for i := range lines {
+			lines[i] = string(rune('a' + random.Intn(4)))
+		}
+		return lines
+	}
+
+	for i := 0; i < 500; i++ {
+		a, b := randomLines(), randomLines()
+		var gotA, gotB []string
+		changes := 0
+		for _, edit := range Lines(a, b) {
+			if edit.Op != Equal {
+				changes++
+			}
+			if edit.Op != Insert {
+				gotA = append(gotA, edit.Line)
+			}
+			if edit.Op != Delete {
+				gotB = append(gotB, edit.Line)
+			}
+		}
+
+		if strings.Join(gotA, ",") != strings.Join(a, ",") || strings.Join(gotB, ",") != strings.Join(b, ",") {
+			t.Fatalf("edits of %v -> %v rebuild %v -> %v", a, b, gotA, gotB)
+		}
+		if shortest := len(a) + len(b) - 2*lcsLength(a, b); changes != shortest {
+			t.Fatalf("edits of %v -> %v: expected %d changes, got %d", a, b, shortest, changes)
+		}
+	}
+}
+
+func lcsLength(a, b []string) int {
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else if lengths[i+1][j] > lengths[i][j+1] {
+				lengths[i][j] = lengths[i+1][j]
+			} else {
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+	return lengths[0][0]
+}
+
+func TestLinesReplacesTextsTooDifferent(t *testing.T) {
+	defer func(previous int) { maxEdits = previous }(maxEdits)
+	maxEdits = 4
+
+	a := []string{"same", "a", "b", "c", "end"}
+	b := []string{"same", "x", "y", "z", "end"}
+	expected := []Edit{
+		{Equal, "same"},
+		{Delete, "a"}, {Delete, "b"}, {Delete, "c"},
+		{Insert, "x"}, {Insert, "y"}, {Insert, "z"},
+		{Equal, "end"},
+	}
+
+	edits := Lines(a, b)
+	if len(edits) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, edits)
+	}
+	for i := range expected {
+		if edits[i] != expected[i] {
+			t.Errorf("edit %d: expected %v, got %v", i, expected[i], edits[i])
+		}
+	}
+
+	// Within the bound the edits stay the shortest
+	b[2] = "b"
+	changes := 0
+	for _, edit := range Lines(a, b) {
+		if edit.Op != Equal {
+			changes++
+		}
+	}
+	if changes != 4 {
+		t.Errorf("expected 4 changes, got %d", changes)
+	}
+}
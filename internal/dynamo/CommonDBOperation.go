@@ -1,9 +1,15 @@
 package dynamo
 
 import (
+	"bytes"
+	"regexp"
+	"time"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/cursor"
 )
 
 func GetItemByKey(tableName string, key AWSObject, out interface{}) (bool, error) {
@@ -54,6 +60,116 @@ func QueryItems(queryInput *dynamodb.QueryInput, offset, cap int) ([]AWSObject,
 	return items, nil
 }
 
+// QueryPage reads at most limit items starting after cursor, and returns the cursor
+// of the next page built from LastEvaluatedKey, empty when the query is exhausted.
+// Unlike QueryItems, the cost is proportional to limit whatever the depth of the page.
+func QueryPage(queryInput *dynamodb.QueryInput, cursorToken string, limit int) ([]AWSObject, string, error) {
+	var startKey cursorKey
+	hasCursor, err := cursor.Decode(cursorToken, &startKey)
+	if err != nil {
+		return nil, "", err
+	}
+	if hasCursor {
+		if !startKey.inPartition(queryInput) {
+			return nil, "", entities.NewInputError("cursor", "invalid")
+		}
+		queryInput.ExclusiveStartKey = startKey.toKey()
+	}
+
+	items := make([]AWSObject, 0, limit)
+
+	// A filter expression may leave pages short, keep reading until limit is reached.
+	// Limit never exceeds the missing count, so LastEvaluatedKey always matches the last item.
+	for {
+		queryInput.Limit = aws.Int64(int64(limit - len(items)))
+
+		output, err := DynamoDB().Query(queryInput)
+		if hasCursor && isValidationError(err) {
+			// The cursor was made for another table or index
+			return nil, "", entities.NewInputError("cursor", "invalid")
+		}
+		if err != nil {
+			return nil, "", err
+		}
+
+		items = append(items, output.Items...)
+
+		if len(output.LastEvaluatedKey) == 0 {
+			return items, "", nil
+		}
+
+		if len(items) >= limit {
+			next, err := cursor.Encode(newCursorKey(output.LastEvaluatedKey))
+			if err != nil {
+				return nil, "", err
+			}
+			return items, next, nil
+		}
+
+		queryInput.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+}
+
+// cursorKey is the compact JSON form of a key, keys only hold strings, numbers and binaries
+type cursorKey map[string]cursorValue
+
+type cursorValue struct {
+	S *string `json:"s,omitempty"`
+	N *string `json:"n,omitempty"`
+	B []byte  `json:"b,omitempty"`
+}
+
+func newCursorKey(key AWSObject) cursorKey {
+	ck := make(cursorKey, len(key))
+	for name, value := range key {
+		ck[name] = cursorValue{
+			S: value.S,
+			N: value.N,
+			B: value.B,
+		}
+	}
+	return ck
+}
+
+// hashCondition matches the partition key equality starting a KeyConditionExpression
+var hashCondition = regexp.MustCompile(`^\s*([#\w]+)\s*=\s*(:\w+)`)
+
+// inPartition tells whether the key can start the query, it must be in the partition queried.
+// A cursor of another list, like the articles of another author, is rejected.
+func (ck cursorKey) inPartition(queryInput *dynamodb.QueryInput) bool {
+	match := hashCondition.FindStringSubmatch(aws.StringValue(queryInput.KeyConditionExpression))
+	if match == nil {
+		return true
+	}
+
+	name := match[1]
+	if alias, ok := queryInput.ExpressionAttributeNames[name]; ok {
+		name = aws.StringValue(alias)
+	}
+
+	value, ok := ck[name]
+	expected := queryInput.ExpressionAttributeValues[match[2]]
+	if !ok || expected == nil {
+		return false
+	}
+
+	return aws.StringValue(value.S) == aws.StringValue(expected.S) &&
+		aws.StringValue(value.N) == aws.StringValue(expected.N) &&
+		bytes.Equal(value.B, expected.B)
+}
+
+func (ck cursorKey) toKey() AWSObject {
+	key := make(AWSObject, len(ck))
+	for name, value := range ck {
+		key[name] = &dynamodb.AttributeValue{
+			S: value.S,
+			N: value.N,
+			B: value.B,
+		}
+	}
+	return key
+}
+
 func BatchGetItems(batchGetInput *dynamodb.BatchGetItemInput, cap int) ([]map[string][]AWSObject, error) {
 	responses := make([]map[string][]AWSObject, 0, cap)
 
@@ -69,6 +185,45 @@ func BatchGetItems(batchGetInput *dynamodb.BatchGetItemInput, cap int) ([]map[st
 	return responses, nil
 }
 
+// batchWriteSize is the maximum number of requests of a BatchWriteItem call
+const batchWriteSize = 25
+const maxBatchWriteAttempt = 5
+
+// BatchWriteItems writes requests into tableName, in batches of 25, retrying the unprocessed ones
+func BatchWriteItems(tableName string, requests []*dynamodb.WriteRequest) error {
+	for start := 0; start < len(requests); start += batchWriteSize {
+		end := start + batchWriteSize
+		if end > len(requests) {
+			end = len(requests)
+		}
+
+		pending := map[string][]*dynamodb.WriteRequest{
+			tableName: requests[start:end],
+		}
+
+		for attempt := 0; len(pending) > 0; attempt++ {
+			if attempt >= maxBatchWriteAttempt {
+				// DynamoDB leaves items unprocessed when the table is out of throughput
+				return newRateLimitedError()
+			}
+			if attempt > 0 {
+				time.Sleep(time.Duration(attempt*50) * time.Millisecond)
+			}
+
+			output, err := DynamoDB().BatchWriteItem(&dynamodb.BatchWriteItemInput{
+				RequestItems: pending,
+			})
+			if err != nil {
+				return err
+			}
+
+			pending = output.UnprocessedItems
+		}
+	}
+
+	return nil
+}
+
 func maxInt(x, y int) int {
 	if x < y {
 		return y
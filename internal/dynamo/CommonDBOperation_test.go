@@ -0,0 +1,78 @@
+package dynamo
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/cursor"
+)
+
+func TestQueryPageRejectsTheCursorOfAnotherList(t *testing.T) {
+	// The last key of a page of /articles?author=jake
+	jakeCursor, err := cursor.Encode(newCursorKey(AWSObject{
+		"Author":    StringValue("jake"),
+		"CreatedAt": Int64Value(1600000000000000000),
+		"ArticleId": Int64Value(42),
+	}))
+	if err != nil {
+		t.Fatalf("Encode: %s", err)
+	}
+
+	authorQuery := func(author string) *dynamodb.QueryInput {
+		return &dynamodb.QueryInput{
+			TableName:                 aws.String(ArticleTableName),
+			IndexName:                 aws.String("Author"),
+			KeyConditionExpression:    aws.String("Author=:author"),
+			ExpressionAttributeValues: StringKey(":author", author),
+		}
+	}
+
+	tests := []struct {
+		name  string
+		query *dynamodb.QueryInput
+	}{
+		{"another endpoint", &dynamodb.QueryInput{
+			TableName:                 aws.String(ArticleTagTableName),
+			IndexName:                 aws.String("CreatedAt"),
+			KeyConditionExpression:    aws.String("Tag=:tag"),
+			ExpressionAttributeValues: StringKey(":tag", "dragons"),
+		}},
+		{"another author", authorQuery("eve")},
+		{"an aliased name", &dynamodb.QueryInput{
+			TableName:                 aws.String(ArticleTableName),
+			IndexName:                 aws.String("Status"),
+			KeyConditionExpression:    aws.String("#status=:scheduled AND PublishAt<=:until"),
+			ExpressionAttributeNames:  map[string]*string{"#status": aws.String("Status")},
+			ExpressionAttributeValues: AWSObject{":scheduled": StringValue("scheduled"), ":until": Int64Value(0)},
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := QueryPage(test.query, jakeCursor, 10)
+			if _, ok := err.(entities.InputError); !ok {
+				t.Errorf("expected an input error, got %v", err)
+			}
+		})
+	}
+
+	var startKey cursorKey
+	if _, err := cursor.Decode(jakeCursor, &startKey); err != nil || !startKey.inPartition(authorQuery("jake")) {
+		t.Errorf("the cursor must start the next page of the articles of jake (%v)", err)
+	}
+}
+
+func TestIsValidationError(t *testing.T) {
+	err := awserr.New("ValidationException", "The provided starting key is invalid", nil)
+	if !isValidationError(err) || !isValidationError(fmt.Errorf("query: %w", err)) {
+		t.Errorf("expected a validation error")
+	}
+	if isValidationError(errors.New("connection reset")) {
+		t.Errorf("expected another error not to be a validation error")
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"os"
 	"sync"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 )
@@ -20,7 +22,20 @@ func initializeSingletons() {
 	if err != nil {
 		log.Fatalf("ERROR: %s", err)
 	}
-	svc = dynamodb.New(sess)
+	// DYNAMODB_ENDPOINT points the client to another endpoint, like DynamoDB Local
+	config := aws.NewConfig()
+	if endpoint := os.Getenv("DYNAMODB_ENDPOINT"); endpoint != "" {
+		config = config.WithEndpoint(endpoint)
+	}
+	svc = newDynamoDB(sess, config)
+}
+
+// newDynamoDB makes a client whose throttled requests, once its retries are exhausted,
+// fail with a RateLimitedError
+func newDynamoDB(p client.ConfigProvider, cfgs ...*aws.Config) *dynamodb.DynamoDB {
+	db := dynamodb.New(p, cfgs...)
+	db.Handlers.AfterRetry.PushBackNamed(rateLimitHandler)
+	return db
 }
 
 func DynamoDB() *dynamodb.DynamoDB {
@@ -34,11 +49,24 @@ var UserTableName = makeTableName("user")
 var EmailUserTableName = makeTableName("email-user")
 var FollowTableName = makeTableName("follow")
 var ArticleTableName = makeTableName("article")
+var ArticleRevisionTableName = makeTableName("article-revision")
 var ArticleTagTableName = makeTableName("article-tag")
 var TagTableName = makeTableName("tag")
 var FavoriteArticleTableName = makeTableName("favorite-article")
 var CommentTableName = makeTableName("comment")
+var TimelineTableName = makeTableName("timeline")
+var PublisherTableName = makeTableName("publisher")
+var SigningKeyTableName = makeTableName("signing-key")
+var SessionTableName = makeTableName("session")
+var AccountTokenTableName = makeTableName("account-token")
+var UsernameAliasTableName = makeTableName("username-alias")
+var UsernameMigrationTableName = makeTableName("username-migration")
 
 func makeTableName(suffix string) string {
-	return fmt.Sprintf("cms-%s-%s", Stage, suffix)
+	return TableNamePrefix(Stage) + suffix
+}
+
+// TableNamePrefix is the start of the names of the tables of stage
+func TableNamePrefix(stage string) string {
+	return fmt.Sprintf("cms-%s-", stage)
 }
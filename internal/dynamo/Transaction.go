@@ -0,0 +1,83 @@
+package dynamo
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Codes of the cancellation reasons of the items of a transaction
+const (
+	ReasonNone                   = "None"
+	ReasonConditionalCheckFailed = "ConditionalCheckFailed"
+	ReasonTransactionConflict    = "TransactionConflict"
+)
+
+// TransactionCanceledError tells why a TransactWriteItems call was canceled,
+// Reasons holds a code per item of the transaction, in the same order
+type TransactionCanceledError struct {
+	Reasons []string
+	err     error
+}
+
+func (e *TransactionCanceledError) Error() string {
+	return e.err.Error()
+}
+
+func (e *TransactionCanceledError) Unwrap() error {
+	return e.err
+}
+
+// ConditionFailed reports whether the condition of the item at index failed
+func (e *TransactionCanceledError) ConditionFailed(index int) bool {
+	return index >= 0 && index < len(e.Reasons) && e.Reasons[index] == ReasonConditionalCheckFailed
+}
+
+// AnyConditionFailed reports whether the condition of any item failed
+func (e *TransactionCanceledError) AnyConditionFailed() bool {
+	for i := range e.Reasons {
+		if e.ConditionFailed(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// reasonsInMessage matches the reasons listed at the end of the message, like
+// "Transaction cancelled, please refer cancellation reasons for specific reasons [ConditionalCheckFailed, None]"
+var reasonsInMessage = regexp.MustCompile(`\[([A-Za-z, ]*)\]\s*$`)
+
+// AsTransactionCanceled returns the cancellation reasons of err if it's a canceled transaction
+func AsTransactionCanceled(err error) (*TransactionCanceledError, bool) {
+	var canceled *TransactionCanceledError
+	if errors.As(err, &canceled) {
+		return canceled, true
+	}
+
+	var exception *dynamodb.TransactionCanceledException
+	if errors.As(err, &exception) && len(exception.CancellationReasons) > 0 {
+		reasons := make([]string, 0, len(exception.CancellationReasons))
+		for _, reason := range exception.CancellationReasons {
+			reasons = append(reasons, aws.StringValue(reason.Code))
+		}
+		return &TransactionCanceledError{Reasons: reasons, err: err}, true
+	}
+
+	// Some implementations, like DynamoDB Local, only list the reasons in the message
+	var aerr awserr.Error
+	if !errors.As(err, &aerr) || aerr.Code() != dynamodb.ErrCodeTransactionCanceledException {
+		return nil, false
+	}
+
+	reasons := make([]string, 0)
+	if match := reasonsInMessage.FindStringSubmatch(aerr.Message()); match != nil {
+		for _, reason := range strings.Split(match[1], ",") {
+			reasons = append(reasons, strings.TrimSpace(reason))
+		}
+	}
+	return &TransactionCanceledError{Reasons: reasons, err: err}, true
+}
@@ -0,0 +1,69 @@
+package dynamo
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestAsTransactionCanceled(t *testing.T) {
+	typed := &dynamodb.TransactionCanceledException{
+		Message_: aws.String("Transaction cancelled"),
+		CancellationReasons: []*dynamodb.CancellationReason{
+			{Code: aws.String(ReasonNone)},
+			{Code: aws.String(ReasonConditionalCheckFailed), Message: aws.String("The conditional request failed")},
+		},
+	}
+	inMessage := awserr.New(dynamodb.ErrCodeTransactionCanceledException,
+		"Transaction cancelled, please refer cancellation reasons for specific reasons [ConditionalCheckFailed, None]", nil)
+
+	tests := []struct {
+		name     string
+		err      error
+		canceled bool
+		reasons  []string
+	}{
+		{"typed exception", typed, true, []string{ReasonNone, ReasonConditionalCheckFailed}},
+		{"wrapped typed exception", fmt.Errorf("put user: %w", typed), true, []string{ReasonNone, ReasonConditionalCheckFailed}},
+		{"reasons in message", inMessage, true, []string{ReasonConditionalCheckFailed, ReasonNone}},
+		{"no reasons", awserr.New(dynamodb.ErrCodeTransactionCanceledException, "Transaction cancelled", nil), true, []string{}},
+		{"other aws error", awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "The conditional request failed", nil), false, nil},
+		{"other error", fmt.Errorf("boom"), false, nil},
+		{"no error", nil, false, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			canceled, ok := AsTransactionCanceled(test.err)
+			if ok != test.canceled {
+				t.Fatalf("expected canceled %v, got %v", test.canceled, ok)
+			}
+			if ok && !reflect.DeepEqual(canceled.Reasons, test.reasons) {
+				t.Errorf("expected reasons %v, got %v", test.reasons, canceled.Reasons)
+			}
+		})
+	}
+}
+
+func TestIsConditionalCheckFailed(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		failed bool
+	}{
+		{"single write", awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "The conditional request failed", nil), true},
+		{"transaction", awserr.New(dynamodb.ErrCodeTransactionCanceledException, "Transaction cancelled [None, ConditionalCheckFailed]", nil), true},
+		{"conflicting transaction", awserr.New(dynamodb.ErrCodeTransactionCanceledException, "Transaction cancelled [TransactionConflict, None]", nil), false},
+		{"other error", fmt.Errorf("boom"), false},
+	}
+
+	for _, test := range tests {
+		if failed := IsConditionalCheckFailed(test.err); failed != test.failed {
+			t.Errorf("%s: expected %v, got %v", test.name, test.failed, failed)
+		}
+	}
+}
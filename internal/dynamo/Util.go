@@ -1,14 +1,16 @@
 package dynamo
 
 import (
+	"errors"
 	"reflect"
 	"strconv"
-	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+	"github.com/ferjmc/cms/entities"
 )
 
 type AWSObject = map[string]*dynamodb.AttributeValue
@@ -67,25 +69,48 @@ func IsUpdateBuilderEmpty(update expression.UpdateBuilder) bool {
 	return reflect.ValueOf(&update).Elem().FieldByName("operationList").IsNil()
 }
 
+// IsConditionalCheckFailed reports whether err is a failed condition, of a single
+// write or of any item of a transaction
 func IsConditionalCheckFailed(err error) bool {
-	aerr, ok := err.(awserr.Error)
-	if !ok {
-		return false
+	if canceled, ok := AsTransactionCanceled(err); ok {
+		return canceled.AnyConditionFailed()
 	}
 
+	var aerr awserr.Error
+	return errors.As(err, &aerr) && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException
+}
+
+// isValidationError tells whether DynamoDB rejected the parameters of a request
+func isValidationError(err error) bool {
+	var aerr awserr.Error
+	return errors.As(err, &aerr) && aerr.Code() == "ValidationException"
+}
+
+// isThrottled tells whether DynamoDB rejected a request for exceeding the throughput
+// of a table or of the account
+func isThrottled(err error) bool {
+	var aerr awserr.Error
+	if !errors.As(err, &aerr) {
+		return false
+	}
 	switch aerr.Code() {
-	case dynamodb.ErrCodeConditionalCheckFailedException:
+	case dynamodb.ErrCodeProvisionedThroughputExceededException, "ThrottlingException", dynamodb.ErrCodeRequestLimitExceeded:
 		return true
-	case dynamodb.ErrCodeTransactionCanceledException:
-		// TODO: DynamoDB Go client doesn't provide individual cancellation reasons for a transaction.
-		// "If using Java, DynamoDB lists the cancellation reasons on the CancellationReasons
-		// property. This property is not set for other languages."
-		// https://docs.aws.amazon.com/sdk-for-go/api/service/dynamodb/#DynamoDB.TransactWriteItems
-		// Here we depend on awserr.Error.Message(), which looks like
-		// "Transaction cancelled, please refer cancellation reasons for specific reasons [ConditionalCheckFailed, None]"
-		// https://github.com/aws/aws-sdk-go/issues/2318
-		return strings.Contains(aerr.Message(), "ConditionalCheckFailed")
-	default:
-		return false
 	}
+	return false
+}
+
+func newRateLimitedError() entities.RateLimitedError {
+	return entities.NewRateLimitedError("server", "too many requests, please retry later")
+}
+
+// rateLimitHandler runs after the SDK decided whether to retry a failed request: the
+// error left is final, a throttling one becomes a RateLimitedError (429)
+var rateLimitHandler = request.NamedHandler{
+	Name: "cms.RateLimitHandler",
+	Fn: func(r *request.Request) {
+		if r.Error != nil && isThrottled(r.Error) {
+			r.Error = newRateLimitedError()
+		}
+	},
 }
@@ -0,0 +1,58 @@
+package dynamo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/ferjmc/cms/entities"
+)
+
+func TestThrottledRequestsAreRateLimited(t *testing.T) {
+	tests := []struct {
+		code      string
+		throttled bool
+	}{
+		{"ProvisionedThroughputExceededException", true},
+		{"ThrottlingException", true},
+		{"RequestLimitExceeded", true},
+		{"ResourceNotFoundException", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#` + tt.code + `","message":"rejected"}`))
+			}))
+			defer server.Close()
+
+			db := newDynamoDB(session.Must(session.NewSession()), aws.NewConfig().
+				WithEndpoint(server.URL).
+				WithRegion("us-east-1").
+				WithCredentials(credentials.NewStaticCredentials("id", "secret", "")).
+				WithMaxRetries(0))
+
+			_, err := db.GetItem(&dynamodb.GetItemInput{
+				TableName: aws.String(UserTableName),
+				Key:       StringKey("Username", "jake"),
+			})
+			if err == nil {
+				t.Fatal("GetItem: expected an error")
+			}
+
+			_, rateLimited := err.(entities.RateLimitedError)
+			if rateLimited != tt.throttled {
+				t.Errorf("GetItem: got %T %s, rate limited: %v, want %v", err, err, rateLimited, tt.throttled)
+			}
+			if isThrottled(err) {
+				t.Errorf("GetItem: the throttling error %s wasn't mapped", err)
+			}
+		})
+	}
+}
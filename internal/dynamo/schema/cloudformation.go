@@ -0,0 +1,139 @@
+package schema
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/ferjmc/cms/internal/dynamo"
+)
+
+// The CloudFormation resources, their fields are in the order CloudFormation documents them
+type resources struct {
+	Resources map[string]resource
+}
+
+type resource struct {
+	Type       string
+	Properties tableProperties
+}
+
+type tableProperties struct {
+	TableName               string
+	AttributeDefinitions    []attributeDefinition
+	KeySchema               []keySchemaElement
+	GlobalSecondaryIndexes  []globalSecondaryIndex `json:",omitempty"`
+	LocalSecondaryIndexes   []localSecondaryIndex  `json:",omitempty"`
+	BillingMode             string
+	ProvisionedThroughput   provisionedThroughputProperty
+	TimeToLiveSpecification *timeToLiveSpecification `json:",omitempty"`
+}
+
+type attributeDefinition struct {
+	AttributeName string
+	AttributeType string
+}
+
+type keySchemaElement struct {
+	AttributeName string
+	KeyType       string
+}
+
+type globalSecondaryIndex struct {
+	IndexName             string
+	KeySchema             []keySchemaElement
+	Projection            projection
+	ProvisionedThroughput provisionedThroughputProperty
+}
+
+type localSecondaryIndex struct {
+	IndexName  string
+	KeySchema  []keySchemaElement
+	Projection projection
+}
+
+type projection struct {
+	ProjectionType string
+}
+
+type provisionedThroughputProperty struct {
+	ReadCapacityUnits  int64
+	WriteCapacityUnits int64
+}
+
+type timeToLiveSpecification struct {
+	AttributeName string
+	Enabled       bool
+}
+
+// CloudFormation returns the resources block of the tables, in JSON which serverless.yml
+// includes with ${file(...)}. The table names are made for stage, which can be a variable
+// like ${self:provider.stage}.
+func CloudFormation(tables []Table, stage string) ([]byte, error) {
+	block := resources{Resources: make(map[string]resource, len(tables))}
+	for _, table := range tables {
+		block.Resources[LogicalId(table)] = resource{
+			Type:       "AWS::DynamoDB::Table",
+			Properties: tableResource(table, stage),
+		}
+	}
+
+	return json.MarshalIndent(block, "", "  ")
+}
+
+// LogicalId names the resource of the table after its suffix, like EmailUserTable
+func LogicalId(table Table) string {
+	var id strings.Builder
+	for _, word := range strings.Split(table.Suffix(), "-") {
+		id.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	id.WriteString("Table")
+	return id.String()
+}
+
+func tableResource(table Table, stage string) tableProperties {
+	properties := tableProperties{
+		TableName:             dynamo.TableNamePrefix(stage) + table.Suffix(),
+		KeySchema:             keySchemaProperty(table.Key),
+		BillingMode:           dynamodb.BillingModeProvisioned,
+		ProvisionedThroughput: provisionedThroughputProperty{ReadCapacityUnits: Capacity, WriteCapacityUnits: Capacity},
+	}
+
+	for _, a := range table.Attributes() {
+		properties.AttributeDefinitions = append(properties.AttributeDefinitions, attributeDefinition{
+			AttributeName: a.Name,
+			AttributeType: a.Type,
+		})
+	}
+
+	for _, index := range table.Indexes {
+		properties.GlobalSecondaryIndexes = append(properties.GlobalSecondaryIndexes, globalSecondaryIndex{
+			IndexName:             index.Name,
+			KeySchema:             keySchemaProperty(index.Key),
+			Projection:            projection{ProjectionType: dynamodb.ProjectionTypeAll},
+			ProvisionedThroughput: provisionedThroughputProperty{ReadCapacityUnits: Capacity, WriteCapacityUnits: Capacity},
+		})
+	}
+
+	for _, index := range table.LocalIndexes {
+		properties.LocalSecondaryIndexes = append(properties.LocalSecondaryIndexes, localSecondaryIndex{
+			IndexName:  index.Name,
+			KeySchema:  keySchemaProperty(index.Key),
+			Projection: projection{ProjectionType: dynamodb.ProjectionTypeAll},
+		})
+	}
+
+	if table.TTL != "" {
+		properties.TimeToLiveSpecification = &timeToLiveSpecification{AttributeName: table.TTL, Enabled: true}
+	}
+
+	return properties
+}
+
+func keySchemaProperty(key Key) []keySchemaElement {
+	elements := make([]keySchemaElement, 0, 2)
+	for _, element := range key.keySchema() {
+		elements = append(elements, keySchemaElement{AttributeName: *element.AttributeName, KeyType: *element.KeyType})
+	}
+	return elements
+}
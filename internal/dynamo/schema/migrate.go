@@ -0,0 +1,268 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// pollInterval is how often Apply checks whether a new index is active
+var pollInterval = 5 * time.Second
+
+// Change is a difference between a table of the schema and the table of the endpoint
+type Change struct {
+	Table       string
+	Description string
+	apply       func(db dynamodbiface.DynamoDBAPI) error // nil when it must be made by hand
+}
+
+func (c Change) String() string {
+	return c.Table + ": " + c.Description
+}
+
+// Applicable tells whether Apply makes the change, the keys of an existing table or index
+// can't be changed in place
+func (c Change) Applicable() bool {
+	return c.apply != nil
+}
+
+// Plan compares the tables with the ones of the endpoint, it doesn't change anything
+func Plan(db dynamodbiface.DynamoDBAPI, tables []Table) ([]Change, error) {
+	changes := make([]Change, 0)
+	for _, table := range tables {
+		tableChanges, err := planTable(db, table)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, tableChanges...)
+	}
+	return changes, nil
+}
+
+// Apply makes the applicable changes in order, waiting for each of them to be done.
+// The changes it can't make are returned in the error.
+func Apply(db dynamodbiface.DynamoDBAPI, changes []Change) error {
+	manual := make([]string, 0)
+	for _, change := range changes {
+		if !change.Applicable() {
+			manual = append(manual, change.String())
+			continue
+		}
+
+		err := change.apply(db)
+		if err != nil {
+			return fmt.Errorf("%s: %w", change, err)
+		}
+	}
+
+	if len(manual) > 0 {
+		return fmt.Errorf("changes to make by hand: %s", strings.Join(manual, "; "))
+	}
+
+	return nil
+}
+
+func planTable(db dynamodbiface.DynamoDBAPI, table Table) ([]Change, error) {
+	output, err := db.DescribeTable(&dynamodb.DescribeTableInput{TableName: aws.String(table.Name)})
+	if isResourceNotFound(err) {
+		changes := []Change{{Table: table.Name, Description: "create table", apply: createTable(table)}}
+		if table.TTL != "" {
+			changes = append(changes, Change{Table: table.Name, Description: "enable TTL on " + table.TTL, apply: enableTTL(table)})
+		}
+		return changes, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	description := output.Table
+	types := make(map[string]string)
+	for _, definition := range description.AttributeDefinitions {
+		types[aws.StringValue(definition.AttributeName)] = aws.StringValue(definition.AttributeType)
+	}
+
+	changes := make([]Change, 0)
+	if actual := describeKey(description.KeySchema, types); actual != table.Key.String() {
+		changes = append(changes, Change{
+			Table:       table.Name,
+			Description: fmt.Sprintf("key is %s instead of %s", actual, table.Key),
+		})
+	}
+
+	indexes := make(map[string]*dynamodb.GlobalSecondaryIndexDescription)
+	for _, index := range description.GlobalSecondaryIndexes {
+		indexes[aws.StringValue(index.IndexName)] = index
+	}
+
+	for _, index := range table.Indexes {
+		existing, ok := indexes[index.Name]
+		delete(indexes, index.Name)
+		if !ok {
+			changes = append(changes, Change{
+				Table:       table.Name,
+				Description: "create index " + index.Name,
+				apply:       createIndex(table, index),
+			})
+			continue
+		}
+
+		if actual := describeKey(existing.KeySchema, types); actual != index.Key.String() {
+			changes = append(changes, Change{
+				Table:       table.Name,
+				Description: fmt.Sprintf("index %s key is %s instead of %s", index.Name, actual, index.Key),
+			})
+		}
+	}
+
+	localIndexes := make(map[string]*dynamodb.LocalSecondaryIndexDescription)
+	for _, index := range description.LocalSecondaryIndexes {
+		localIndexes[aws.StringValue(index.IndexName)] = index
+	}
+
+	for _, index := range table.LocalIndexes {
+		existing, ok := localIndexes[index.Name]
+		delete(localIndexes, index.Name)
+		if !ok {
+			// A global index of the same name is replaced by the local one
+			delete(indexes, index.Name)
+			changes = append(changes, Change{
+				Table:       table.Name,
+				Description: "local index " + index.Name + " is missing, it can only be created with the table",
+			})
+			continue
+		}
+
+		if actual := describeKey(existing.KeySchema, types); actual != index.Key.String() {
+			changes = append(changes, Change{
+				Table:       table.Name,
+				Description: fmt.Sprintf("local index %s key is %s instead of %s", index.Name, actual, index.Key),
+			})
+		}
+	}
+
+	unknown := make([]string, 0, len(indexes)+len(localIndexes))
+	for name := range indexes {
+		unknown = append(unknown, "index "+name)
+	}
+	for name := range localIndexes {
+		unknown = append(unknown, "local index "+name)
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		changes = append(changes, Change{Table: table.Name, Description: name + " isn't in the schema"})
+	}
+
+	if table.TTL != "" {
+		ttl, err := db.DescribeTimeToLive(&dynamodb.DescribeTimeToLiveInput{TableName: aws.String(table.Name)})
+		if err != nil {
+			return nil, err
+		}
+
+		status := ttl.TimeToLiveDescription
+		enabled := status != nil && aws.StringValue(status.AttributeName) == table.TTL &&
+			(aws.StringValue(status.TimeToLiveStatus) == dynamodb.TimeToLiveStatusEnabled ||
+				aws.StringValue(status.TimeToLiveStatus) == dynamodb.TimeToLiveStatusEnabling)
+		if !enabled {
+			changes = append(changes, Change{Table: table.Name, Description: "enable TTL on " + table.TTL, apply: enableTTL(table)})
+		}
+	}
+
+	return changes, nil
+}
+
+func createTable(table Table) func(db dynamodbiface.DynamoDBAPI) error {
+	return func(db dynamodbiface.DynamoDBAPI) error {
+		_, err := db.CreateTable(table.CreateTableInput())
+		if err != nil {
+			return err
+		}
+
+		return db.WaitUntilTableExists(&dynamodb.DescribeTableInput{TableName: aws.String(table.Name)})
+	}
+}
+
+func createIndex(table Table, index Index) func(db dynamodbiface.DynamoDBAPI) error {
+	return func(db dynamodbiface.DynamoDBAPI) error {
+		create := table.CreateTableInput()
+		_, err := db.UpdateTable(&dynamodb.UpdateTableInput{
+			TableName:            aws.String(table.Name),
+			AttributeDefinitions: create.AttributeDefinitions,
+			GlobalSecondaryIndexUpdates: []*dynamodb.GlobalSecondaryIndexUpdate{
+				{Create: &dynamodb.CreateGlobalSecondaryIndexAction{
+					IndexName:             aws.String(index.Name),
+					KeySchema:             index.Key.keySchema(),
+					Projection:            &dynamodb.Projection{ProjectionType: aws.String(dynamodb.ProjectionTypeAll)},
+					ProvisionedThroughput: provisionedThroughput(),
+				}},
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		// The table accepts one index creation at a time, and the index can't be queried
+		// until it is backfilled
+		for {
+			output, err := db.DescribeTable(&dynamodb.DescribeTableInput{TableName: aws.String(table.Name)})
+			if err != nil {
+				return err
+			}
+
+			for _, created := range output.Table.GlobalSecondaryIndexes {
+				if aws.StringValue(created.IndexName) == index.Name &&
+					aws.StringValue(created.IndexStatus) == dynamodb.IndexStatusActive {
+					return nil
+				}
+			}
+
+			time.Sleep(pollInterval)
+		}
+	}
+}
+
+func enableTTL(table Table) func(db dynamodbiface.DynamoDBAPI) error {
+	return func(db dynamodbiface.DynamoDBAPI) error {
+		_, err := db.UpdateTimeToLive(&dynamodb.UpdateTimeToLiveInput{
+			TableName: aws.String(table.Name),
+			TimeToLiveSpecification: &dynamodb.TimeToLiveSpecification{
+				AttributeName: aws.String(table.TTL),
+				Enabled:       aws.Bool(true),
+			},
+		})
+		return err
+	}
+}
+
+// String describes the key like Follower (S), Publisher (S)
+func (k Key) String() string {
+	parts := make([]string, 0, 2)
+	for _, a := range k.attributes() {
+		parts = append(parts, fmt.Sprintf("%s (%s)", a.Name, a.Type))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func describeKey(keySchema []*dynamodb.KeySchemaElement, types map[string]string) string {
+	var key Key
+	for _, element := range keySchema {
+		a := attribute(aws.StringValue(element.AttributeName), types[aws.StringValue(element.AttributeName)])
+		if aws.StringValue(element.KeyType) == dynamodb.KeyTypeRange {
+			key.Range = &a
+		} else {
+			key.Hash = a
+		}
+	}
+	return key.String()
+}
+
+func isResourceNotFound(err error) bool {
+	var aerr awserr.Error
+	return errors.As(err, &aerr) && aerr.Code() == dynamodb.ErrCodeResourceNotFoundException
+}
@@ -0,0 +1,262 @@
+// Package schema describes the DynamoDB tables and indexes queried by the repositories.
+// It creates or verifies them against an endpoint, and generates their CloudFormation resources.
+package schema
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/ferjmc/cms/internal/dynamo"
+)
+
+const (
+	String = dynamodb.ScalarAttributeTypeS
+	Number = dynamodb.ScalarAttributeTypeN
+)
+
+// Capacity of every table and index, in read and write units
+const Capacity = 2
+
+type Attribute struct {
+	Name string
+	Type string
+}
+
+// Key is the primary key of a table or index, Range is optional
+type Key struct {
+	Hash  Attribute
+	Range *Attribute
+}
+
+type Index struct {
+	Name string
+	Key  Key
+}
+
+type Table struct {
+	Name    string
+	Key     Key
+	Indexes []Index // Global secondary indexes, projecting every attribute
+	// Local secondary indexes, projecting every attribute. They share the hash key of
+	// the table and can be read consistently, but only be created with the table.
+	LocalIndexes []Index
+	TTL          string // Attribute with the expiration time in seconds, if any
+}
+
+func attribute(name, attributeType string) Attribute {
+	return Attribute{Name: name, Type: attributeType}
+}
+
+func hashKey(hash Attribute) Key {
+	return Key{Hash: hash}
+}
+
+func compositeKey(hash, rangeAttribute Attribute) Key {
+	return Key{Hash: hash, Range: &rangeAttribute}
+}
+
+// Tables are the tables of the current stage
+var Tables = []Table{
+	{
+		Name: dynamo.UserTableName,
+		Key:  hashKey(attribute("Username", String)),
+	},
+	{
+		Name: dynamo.EmailUserTableName,
+		Key:  hashKey(attribute("Email", String)),
+	},
+	{
+		Name: dynamo.FollowTableName,
+		Key:  compositeKey(attribute("Follower", String), attribute("Publisher", String)),
+		Indexes: []Index{
+			{Name: "Publisher", Key: compositeKey(attribute("Publisher", String), attribute("Follower", String))},
+		},
+	},
+	{
+		Name: dynamo.ArticleTableName,
+		Key:  hashKey(attribute("ArticleId", Number)),
+		Indexes: []Index{
+			// Dummy is only set on the published articles
+			{Name: "CreatedAt", Key: compositeKey(attribute("Dummy", Number), attribute("CreatedAt", Number))},
+			{Name: "Author", Key: compositeKey(attribute("Author", String), attribute("CreatedAt", Number))},
+			{Name: "Status", Key: compositeKey(attribute("Status", String), attribute("PublishAt", Number))},
+		},
+	},
+	{
+		Name: dynamo.ArticleRevisionTableName,
+		Key:  compositeKey(attribute("ArticleId", Number), attribute("Revision", Number)),
+	},
+	{
+		Name: dynamo.ArticleTagTableName,
+		Key:  compositeKey(attribute("Tag", String), attribute("ArticleId", Number)),
+		Indexes: []Index{
+			{Name: "CreatedAt", Key: compositeKey(attribute("Tag", String), attribute("CreatedAt", Number))},
+		},
+	},
+	{
+		Name: dynamo.TagTableName,
+		Key:  hashKey(attribute("Tag", String)),
+		Indexes: []Index{
+			{Name: "ArticleCount", Key: compositeKey(attribute("Dummy", Number), attribute("ArticleCount", Number))},
+		},
+	},
+	{
+		Name: dynamo.FavoriteArticleTableName,
+		Key:  compositeKey(attribute("Username", String), attribute("ArticleId", Number)),
+		Indexes: []Index{
+			{Name: "FavoritedAt", Key: compositeKey(attribute("Username", String), attribute("FavoritedAt", Number))},
+		},
+	},
+	{
+		Name: dynamo.CommentTableName,
+		Key:  compositeKey(attribute("ArticleId", Number), attribute("CommentId", Number)),
+		Indexes: []Index{
+			{Name: "CreatedAt", Key: compositeKey(attribute("ArticleId", Number), attribute("CreatedAt", Number))},
+			{Name: "Author", Key: compositeKey(attribute("Author", String), attribute("CreatedAt", Number))},
+		},
+	},
+	{
+		Name: dynamo.TimelineTableName,
+		Key:  compositeKey(attribute("Follower", String), attribute("ArticleId", Number)),
+		LocalIndexes: []Index{
+			// Read consistently by timeline.Query
+			{Name: "CreatedAt", Key: compositeKey(attribute("Follower", String), attribute("CreatedAt", Number))},
+		},
+	},
+	{
+		Name: dynamo.PublisherTableName,
+		Key:  hashKey(attribute("Publisher", String)),
+	},
+	{
+		Name: dynamo.SigningKeyTableName,
+		Key:  hashKey(attribute("Kid", String)),
+	},
+	{
+		Name: dynamo.SessionTableName,
+		Key:  hashKey(attribute("SessionId", String)),
+		Indexes: []Index{
+			{Name: "Username", Key: hashKey(attribute("Username", String))},
+		},
+		TTL: "TTL",
+	},
+	{
+		Name: dynamo.AccountTokenTableName,
+		Key:  hashKey(attribute("TokenHash", String)),
+		TTL:  "TTL",
+	},
+	{
+		Name: dynamo.UsernameAliasTableName,
+		Key:  hashKey(attribute("Alias", String)),
+	},
+	{
+		Name: dynamo.UsernameMigrationTableName,
+		Key:  hashKey(attribute("OldUsername", String)),
+		Indexes: []Index{
+			// Pending is removed once the migration is done
+			{Name: "Pending", Key: hashKey(attribute("Pending", Number))},
+		},
+	},
+}
+
+// Suffix is the name of the table without the stage prefix, like user
+func (t Table) Suffix() string {
+	return strings.TrimPrefix(t.Name, dynamo.TableNamePrefix(dynamo.Stage))
+}
+
+// Attributes are the key attributes of the table and its indexes, each once
+func (t Table) Attributes() []Attribute {
+	attributes := make([]Attribute, 0)
+	seen := make(map[string]bool)
+	add := func(key Key) {
+		for _, a := range key.attributes() {
+			if !seen[a.Name] {
+				seen[a.Name] = true
+				attributes = append(attributes, a)
+			}
+		}
+	}
+
+	add(t.Key)
+	for _, index := range t.Indexes {
+		add(index.Key)
+	}
+	for _, index := range t.LocalIndexes {
+		add(index.Key)
+	}
+
+	return attributes
+}
+
+// CreateTableInput creates the table with its indexes, the TTL has to be enabled afterwards
+func (t Table) CreateTableInput() *dynamodb.CreateTableInput {
+	attributeDefinitions := make([]*dynamodb.AttributeDefinition, 0)
+	for _, a := range t.Attributes() {
+		attributeDefinitions = append(attributeDefinitions, &dynamodb.AttributeDefinition{
+			AttributeName: aws.String(a.Name),
+			AttributeType: aws.String(a.Type),
+		})
+	}
+
+	input := &dynamodb.CreateTableInput{
+		TableName:             aws.String(t.Name),
+		AttributeDefinitions:  attributeDefinitions,
+		KeySchema:             t.Key.keySchema(),
+		BillingMode:           aws.String(dynamodb.BillingModeProvisioned),
+		ProvisionedThroughput: provisionedThroughput(),
+	}
+
+	for _, index := range t.Indexes {
+		input.GlobalSecondaryIndexes = append(input.GlobalSecondaryIndexes, index.globalSecondaryIndex())
+	}
+	for _, index := range t.LocalIndexes {
+		input.LocalSecondaryIndexes = append(input.LocalSecondaryIndexes, index.localSecondaryIndex())
+	}
+
+	return input
+}
+
+func (k Key) attributes() []Attribute {
+	if k.Range == nil {
+		return []Attribute{k.Hash}
+	}
+	return []Attribute{k.Hash, *k.Range}
+}
+
+func (k Key) keySchema() []*dynamodb.KeySchemaElement {
+	keySchema := []*dynamodb.KeySchemaElement{
+		{AttributeName: aws.String(k.Hash.Name), KeyType: aws.String(dynamodb.KeyTypeHash)},
+	}
+	if k.Range != nil {
+		keySchema = append(keySchema, &dynamodb.KeySchemaElement{
+			AttributeName: aws.String(k.Range.Name),
+			KeyType:       aws.String(dynamodb.KeyTypeRange),
+		})
+	}
+	return keySchema
+}
+
+func (i Index) globalSecondaryIndex() *dynamodb.GlobalSecondaryIndex {
+	return &dynamodb.GlobalSecondaryIndex{
+		IndexName:             aws.String(i.Name),
+		KeySchema:             i.Key.keySchema(),
+		Projection:            &dynamodb.Projection{ProjectionType: aws.String(dynamodb.ProjectionTypeAll)},
+		ProvisionedThroughput: provisionedThroughput(),
+	}
+}
+
+// localSecondaryIndex uses the throughput of its table
+func (i Index) localSecondaryIndex() *dynamodb.LocalSecondaryIndex {
+	return &dynamodb.LocalSecondaryIndex{
+		IndexName:  aws.String(i.Name),
+		KeySchema:  i.Key.keySchema(),
+		Projection: &dynamodb.Projection{ProjectionType: aws.String(dynamodb.ProjectionTypeAll)},
+	}
+}
+
+func provisionedThroughput() *dynamodb.ProvisionedThroughput {
+	return &dynamodb.ProvisionedThroughput{
+		ReadCapacityUnits:  aws.Int64(Capacity),
+		WriteCapacityUnits: aws.Int64(Capacity),
+	}
+}
@@ -0,0 +1,227 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/ferjmc/cms/internal/dynamo"
+)
+
+// fakeDynamoDB keeps the descriptions of the tables it created
+type fakeDynamoDB struct {
+	dynamodbiface.DynamoDBAPI
+	tables map[string]*dynamodb.TableDescription
+	ttl    map[string]string
+}
+
+func newFakeDynamoDB() *fakeDynamoDB {
+	return &fakeDynamoDB{
+		tables: make(map[string]*dynamodb.TableDescription),
+		ttl:    make(map[string]string),
+	}
+}
+
+func (f *fakeDynamoDB) DescribeTable(input *dynamodb.DescribeTableInput) (*dynamodb.DescribeTableOutput, error) {
+	table, ok := f.tables[*input.TableName]
+	if !ok {
+		return nil, awserr.New(dynamodb.ErrCodeResourceNotFoundException, "Requested resource not found", nil)
+	}
+	return &dynamodb.DescribeTableOutput{Table: table}, nil
+}
+
+func (f *fakeDynamoDB) CreateTable(input *dynamodb.CreateTableInput) (*dynamodb.CreateTableOutput, error) {
+	table := &dynamodb.TableDescription{
+		TableName:            input.TableName,
+		AttributeDefinitions: input.AttributeDefinitions,
+		KeySchema:            input.KeySchema,
+	}
+	for _, index := range input.GlobalSecondaryIndexes {
+		table.GlobalSecondaryIndexes = append(table.GlobalSecondaryIndexes, &dynamodb.GlobalSecondaryIndexDescription{
+			IndexName:   index.IndexName,
+			KeySchema:   index.KeySchema,
+			IndexStatus: aws.String(dynamodb.IndexStatusActive),
+		})
+	}
+	for _, index := range input.LocalSecondaryIndexes {
+		table.LocalSecondaryIndexes = append(table.LocalSecondaryIndexes, &dynamodb.LocalSecondaryIndexDescription{
+			IndexName: index.IndexName,
+			KeySchema: index.KeySchema,
+		})
+	}
+	f.tables[*input.TableName] = table
+	return &dynamodb.CreateTableOutput{TableDescription: table}, nil
+}
+
+func (f *fakeDynamoDB) WaitUntilTableExists(input *dynamodb.DescribeTableInput) error {
+	_, err := f.DescribeTable(input)
+	return err
+}
+
+func (f *fakeDynamoDB) UpdateTable(input *dynamodb.UpdateTableInput) (*dynamodb.UpdateTableOutput, error) {
+	table := f.tables[*input.TableName]
+	table.AttributeDefinitions = input.AttributeDefinitions
+	for _, update := range input.GlobalSecondaryIndexUpdates {
+		table.GlobalSecondaryIndexes = append(table.GlobalSecondaryIndexes, &dynamodb.GlobalSecondaryIndexDescription{
+			IndexName:   update.Create.IndexName,
+			KeySchema:   update.Create.KeySchema,
+			IndexStatus: aws.String(dynamodb.IndexStatusActive),
+		})
+	}
+	return &dynamodb.UpdateTableOutput{TableDescription: table}, nil
+}
+
+func (f *fakeDynamoDB) DescribeTimeToLive(input *dynamodb.DescribeTimeToLiveInput) (*dynamodb.DescribeTimeToLiveOutput, error) {
+	status := &dynamodb.TimeToLiveDescription{TimeToLiveStatus: aws.String(dynamodb.TimeToLiveStatusDisabled)}
+	if attribute, ok := f.ttl[*input.TableName]; ok {
+		status = &dynamodb.TimeToLiveDescription{
+			AttributeName:    aws.String(attribute),
+			TimeToLiveStatus: aws.String(dynamodb.TimeToLiveStatusEnabled),
+		}
+	}
+	return &dynamodb.DescribeTimeToLiveOutput{TimeToLiveDescription: status}, nil
+}
+
+func (f *fakeDynamoDB) UpdateTimeToLive(input *dynamodb.UpdateTimeToLiveInput) (*dynamodb.UpdateTimeToLiveOutput, error) {
+	f.ttl[*input.TableName] = *input.TimeToLiveSpecification.AttributeName
+	return &dynamodb.UpdateTimeToLiveOutput{}, nil
+}
+
+func TestTables(t *testing.T) {
+	names := make(map[string]bool)
+	for _, table := range Tables {
+		if names[table.Name] {
+			t.Errorf("%s is described twice", table.Name)
+		}
+		names[table.Name] = true
+
+		types := make(map[string]string)
+		keys := append([]Key{table.Key}, indexKeys(table)...)
+		for _, key := range keys {
+			for _, a := range key.attributes() {
+				if previous, ok := types[a.Name]; ok && previous != a.Type {
+					t.Errorf("%s: %s is both %s and %s", table.Name, a.Name, previous, a.Type)
+				}
+				types[a.Name] = a.Type
+			}
+		}
+
+		indexes := make(map[string]bool)
+		for _, index := range append(append([]Index{}, table.Indexes...), table.LocalIndexes...) {
+			if indexes[index.Name] {
+				t.Errorf("%s: index %s is described twice", table.Name, index.Name)
+			}
+			indexes[index.Name] = true
+		}
+		for _, index := range table.LocalIndexes {
+			if index.Key.Hash != table.Key.Hash || index.Key.Range == nil {
+				t.Errorf("%s: local index %s must have the hash key of the table and a range key", table.Name, index.Name)
+			}
+		}
+	}
+}
+
+func TestPlanAndApply(t *testing.T) {
+	db := newFakeDynamoDB()
+
+	changes, err := Plan(db, Tables)
+	if err != nil {
+		t.Fatalf("Plan: %s", err)
+	}
+	if len(changes) != len(Tables)+2 {
+		t.Errorf("expected every table to be created and 2 TTLs enabled, got %v", changes)
+	}
+
+	err = Apply(db, changes)
+	if err != nil {
+		t.Fatalf("Apply: %s", err)
+	}
+
+	changes, err = Plan(db, Tables)
+	if err != nil || len(changes) != 0 {
+		t.Errorf("expected no changes once applied, got %v (%v)", changes, err)
+	}
+
+	t.Run("It must create a missing index", func(t *testing.T) {
+		description := db.tables[dynamo.ArticleTableName]
+		description.GlobalSecondaryIndexes = description.GlobalSecondaryIndexes[:1]
+
+		changes, err := Plan(db, Tables)
+		if err != nil || len(changes) != 2 || !changes[0].Applicable() || changes[0].Description != "create index Author" {
+			t.Fatalf("expected the missing indexes to be created, got %v (%v)", changes, err)
+		}
+
+		err = Apply(db, changes)
+		if err != nil {
+			t.Fatalf("Apply: %s", err)
+		}
+	})
+
+	t.Run("It must report a local index missing from an existing table", func(t *testing.T) {
+		description := db.tables[dynamo.TimelineTableName]
+		localIndexes := description.LocalSecondaryIndexes
+		defer func() {
+			description.LocalSecondaryIndexes = localIndexes
+			description.GlobalSecondaryIndexes = nil
+		}()
+
+		// The CreatedAt index of the timeline used to be a global one
+		description.LocalSecondaryIndexes = nil
+		description.GlobalSecondaryIndexes = []*dynamodb.GlobalSecondaryIndexDescription{
+			{IndexName: localIndexes[0].IndexName, KeySchema: localIndexes[0].KeySchema},
+		}
+
+		changes, err := Plan(db, Tables)
+		if err != nil || len(changes) != 1 || changes[0].Applicable() {
+			t.Fatalf("expected a change to make by hand, got %v (%v)", changes, err)
+		}
+		if !strings.Contains(changes[0].String(), "local index CreatedAt is missing") {
+			t.Errorf("unexpected change %s", changes[0])
+		}
+	})
+
+	t.Run("It must report a different key", func(t *testing.T) {
+		description := db.tables[dynamo.FavoriteArticleTableName]
+		description.KeySchema = description.KeySchema[:1]
+
+		changes, err := Plan(db, Tables)
+		if err != nil || len(changes) != 1 || changes[0].Applicable() {
+			t.Fatalf("expected a change to make by hand, got %v (%v)", changes, err)
+		}
+		if !strings.Contains(changes[0].String(), "key is Username (S) instead of Username (S), ArticleId (N)") {
+			t.Errorf("unexpected change %s", changes[0])
+		}
+
+		err = Apply(db, changes)
+		if err == nil || !strings.Contains(err.Error(), "by hand") {
+			t.Errorf("expected Apply to list the change to make by hand, got %v", err)
+		}
+	})
+}
+
+func TestCloudFormation(t *testing.T) {
+	resources, err := CloudFormation(Tables, "prod")
+	if err != nil {
+		t.Fatalf("CloudFormation: %s", err)
+	}
+
+	for _, expected := range []string{`"EmailUserTable": {`, `"TableName": "cms-prod-email-user"`, `"IndexName": "ArticleCount"`, `"LocalSecondaryIndexes": [`} {
+		if !strings.Contains(string(resources), expected) {
+			t.Errorf("expected %s in the resources", expected)
+		}
+	}
+}
+
+func indexKeys(table Table) []Key {
+	keys := make([]Key, 0, len(table.Indexes)+len(table.LocalIndexes))
+	for _, index := range table.Indexes {
+		keys = append(keys, index.Key)
+	}
+	for _, index := range table.LocalIndexes {
+		keys = append(keys, index.Key)
+	}
+	return keys
+}
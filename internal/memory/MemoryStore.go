@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"os"
+	"sync"
+
+	"github.com/ferjmc/cms/entities"
+)
+
+// Store keeps every table in process memory, it is shared by the memory
+// repositories the same way the DynamoDB tables are shared by the dynamo ones
+type Store struct {
+	sync.RWMutex
+	Users              map[string]entities.User
+	EmailUsers         map[string]entities.EmailUser
+	Follows            map[entities.Follow]bool
+	Articles           map[int64]entities.Article
+	ArticleRevisions   map[int64]map[int64]entities.ArticleRevision
+	ArticleTags        map[string]map[int64]entities.ArticleTag
+	Tags               map[string]entities.Tag
+	FavoriteArticles   map[entities.FavoriteArticleKey]entities.FavoriteArticle
+	Comments           map[int64]map[int64]entities.Comment
+	SigningKeys        map[string]entities.SigningKey
+	Sessions           map[string]entities.Session
+	AccountTokens      map[string]entities.AccountToken
+	UsernameAliases    map[string]entities.UsernameAlias
+	UsernameMigrations map[string]entities.UsernameMigration
+}
+
+var once sync.Once
+var store *Store
+
+func initializeSingletons() {
+	store = NewStore()
+}
+
+// DB returns the process wide store used by the InstanceMemory repositories
+func DB() *Store {
+	once.Do(initializeSingletons)
+	return store
+}
+
+func NewStore() *Store {
+	return &Store{
+		Users:              make(map[string]entities.User),
+		EmailUsers:         make(map[string]entities.EmailUser),
+		Follows:            make(map[entities.Follow]bool),
+		Articles:           make(map[int64]entities.Article),
+		ArticleRevisions:   make(map[int64]map[int64]entities.ArticleRevision),
+		ArticleTags:        make(map[string]map[int64]entities.ArticleTag),
+		Tags:               make(map[string]entities.Tag),
+		FavoriteArticles:   make(map[entities.FavoriteArticleKey]entities.FavoriteArticle),
+		Comments:           make(map[int64]map[int64]entities.Comment),
+		SigningKeys:        make(map[string]entities.SigningKey),
+		Sessions:           make(map[string]entities.Session),
+		AccountTokens:      make(map[string]entities.AccountToken),
+		UsernameAliases:    make(map[string]entities.UsernameAlias),
+		UsernameMigrations: make(map[string]entities.UsernameMigration),
+	}
+}
+
+// Enabled reports whether services built without options should use the memory
+// repositories instead of DynamoDB, set REPOSITORY=memory to run without AWS
+func Enabled() bool {
+	return os.Getenv("REPOSITORY") == "memory"
+}
+
+// Page returns the bounds of the offset/limit window over a list of length items
+func Page(length, offset, limit int) (int, int) {
+	start := offset
+	if start > length {
+		start = length
+	}
+
+	end := start + limit
+	if end > length {
+		end = length
+	}
+
+	return start, end
+}
+
+func CopyStrings(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	return append(make([]string, 0, len(values)), values...)
+}
+
+func CopyBytes(values []byte) []byte {
+	if values == nil {
+		return nil
+	}
+	return append(make([]byte, 0, len(values)), values...)
+}
@@ -0,0 +1,364 @@
+// Package timeline keeps, for every follower, the ids of the articles written by
+// the authors they follow (fan-out-on-write), so most of the feed is read with a
+// single query. Authors with more followers than the threshold are not fanned
+// out, their articles are read from the article table when the feed is built
+// (fan-out-on-read).
+//
+// Tables:
+//
+//	timeline:  Follower (hash), ArticleId (range), local index CreatedAt (range), read consistently
+//	publisher: Publisher (hash), Popular read for the publishers followed by a reader
+//	follow:    global index Publisher (hash), Follower (range)
+package timeline
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/cursor"
+	"github.com/ferjmc/cms/internal/dynamo"
+)
+
+// Threshold is the maximum number of followers of an author whose articles are
+// fanned out on write, read from TIMELINE_THRESHOLD. The timeline is disabled
+// when it is not set, and every feed is read from the article table.
+func Threshold() (int64, bool) {
+	value := os.Getenv("TIMELINE_THRESHOLD")
+	if value == "" {
+		return 0, false
+	}
+
+	threshold, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || threshold < 0 {
+		log.Printf("ERROR: invalid TIMELINE_THRESHOLD %q, timeline disabled", value)
+		return 0, false
+	}
+
+	return threshold, true
+}
+
+func Enabled() bool {
+	_, enabled := Threshold()
+	return enabled
+}
+
+// ShouldFanOut tells whether a new article of publisher must be written to the timelines of its followers
+func ShouldFanOut(publisher string) (bool, error) {
+	threshold, enabled := Threshold()
+	if !enabled {
+		return false, nil
+	}
+
+	var p entities.Publisher
+	_, err := dynamo.GetItemByKey(dynamo.PublisherTableName, dynamo.StringKey("Publisher", publisher), &p)
+	if err != nil {
+		return false, err
+	}
+
+	return p.FollowersCount <= threshold, nil
+}
+
+// MarkPopularItem flags publisher as popular, in the transaction writing an article which is not fanned out,
+// so feeds read its articles from the article table from then on
+func MarkPopularItem(publisher string) *dynamodb.TransactWriteItem {
+	return &dynamodb.TransactWriteItem{
+		Update: &dynamodb.Update{
+			TableName:                 aws.String(dynamo.PublisherTableName),
+			Key:                       dynamo.StringKey("Publisher", publisher),
+			UpdateExpression:          aws.String("SET Popular=:one"),
+			ExpressionAttributeValues: dynamo.IntKey(":one", 1),
+		},
+	}
+}
+
+// MarkPopular flags publisher as popular outside of a transaction, when a popular publisher is renamed
+func MarkPopular(publisher string) error {
+	_, err := dynamo.DynamoDB().UpdateItem(&dynamodb.UpdateItemInput{
+		TableName:                 aws.String(dynamo.PublisherTableName),
+		Key:                       dynamo.StringKey("Publisher", publisher),
+		UpdateExpression:          aws.String("SET Popular=:one"),
+		ExpressionAttributeValues: dynamo.IntKey(":one", 1),
+	})
+	return err
+}
+
+// CountFollowerItem adds delta to the followers count of publisher, in the transaction of a follow or unfollow
+func CountFollowerItem(publisher string, delta int) *dynamodb.TransactWriteItem {
+	return &dynamodb.TransactWriteItem{
+		Update: &dynamodb.Update{
+			TableName:                 aws.String(dynamo.PublisherTableName),
+			Key:                       dynamo.StringKey("Publisher", publisher),
+			UpdateExpression:          aws.String("ADD FollowersCount :delta"),
+			ExpressionAttributeValues: dynamo.IntKey(":delta", delta),
+		},
+	}
+}
+
+// FanOut writes article into the timeline of every follower of its author
+func FanOut(article entities.Article) error {
+	followers, err := getFollowers(article.Author)
+	if err != nil {
+		return err
+	}
+
+	requests := make([]*dynamodb.WriteRequest, 0, len(followers))
+	for _, follower := range followers {
+		request, err := putEntryRequest(follower, article.Author, article.ArticleId, article.CreatedAt)
+		if err != nil {
+			return err
+		}
+		requests = append(requests, request)
+	}
+
+	return dynamo.BatchWriteItems(dynamo.TimelineTableName, requests)
+}
+
+// Remove deletes article from the timeline of every follower of its author
+func Remove(article entities.Article) error {
+	followers, err := getFollowers(article.Author)
+	if err != nil {
+		return err
+	}
+
+	requests := make([]*dynamodb.WriteRequest, 0, len(followers))
+	for _, follower := range followers {
+		requests = append(requests, deleteEntryRequest(follower, article.ArticleId))
+	}
+
+	return dynamo.BatchWriteItems(dynamo.TimelineTableName, requests)
+}
+
+// Backfill writes the fanned out articles of publisher into the timeline of a new follower
+func Backfill(follower, publisher string) error {
+	entries, err := getFannedOutEntries(publisher)
+	if err != nil {
+		return err
+	}
+
+	requests := make([]*dynamodb.WriteRequest, 0, len(entries))
+	for _, entry := range entries {
+		request, err := putEntryRequest(follower, publisher, entry.ArticleId, entry.CreatedAt)
+		if err != nil {
+			return err
+		}
+		requests = append(requests, request)
+	}
+
+	return dynamo.BatchWriteItems(dynamo.TimelineTableName, requests)
+}
+
+// Prune deletes the articles of publisher from the timeline of a former follower
+func Prune(follower, publisher string) error {
+	entries, err := getFannedOutEntries(publisher)
+	if err != nil {
+		return err
+	}
+
+	requests := make([]*dynamodb.WriteRequest, 0, len(entries))
+	for _, entry := range entries {
+		requests = append(requests, deleteEntryRequest(follower, entry.ArticleId))
+	}
+
+	return dynamo.BatchWriteItems(dynamo.TimelineTableName, requests)
+}
+
+// Query reads at most pageSize entries of the timeline of follower, newest first, starting after startKey.
+// With a boundary, only entries placed after it are returned. The returned key is empty once
+// the timeline has no more entries.
+func Query(ctx context.Context, follower string, startKey dynamo.AWSObject, boundary *cursor.Position, pageSize int) ([]entities.TimelineEntry, dynamo.AWSObject, error) {
+	queryEntries := dynamodb.QueryInput{
+		TableName:                 aws.String(dynamo.TimelineTableName),
+		IndexName:                 aws.String("CreatedAt"),
+		KeyConditionExpression:    aws.String("Follower=:follower"),
+		ExpressionAttributeValues: dynamo.StringKey(":follower", follower),
+		ScanIndexForward:          aws.Bool(false),
+		Limit:                     aws.Int64(int64(pageSize)),
+		// The entries written up to the boundary must all be read
+		ConsistentRead: aws.Bool(true),
+	}
+	if len(startKey) > 0 {
+		queryEntries.ExclusiveStartKey = startKey
+	}
+	if boundary != nil {
+		queryEntries.KeyConditionExpression = aws.String("Follower=:follower AND CreatedAt<=:createdAt")
+		queryEntries.ExpressionAttributeValues[":createdAt"] = dynamo.Int64Value(boundary.Key)
+	}
+
+	output, err := dynamo.DynamoDB().QueryWithContext(ctx, &queryEntries)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entries := make([]entities.TimelineEntry, 0, len(output.Items))
+	err = dynamodbattribute.UnmarshalListOfMaps(output.Items, &entries)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if boundary != nil {
+		// Entries sharing the boundary CreatedAt may have been returned already
+		after := entries[:0]
+		for _, entry := range entries {
+			if boundary.After(entry.CreatedAt, entry.ArticleId) {
+				after = append(after, entry)
+			}
+		}
+		entries = after
+	}
+
+	return entries, output.LastEvaluatedKey, nil
+}
+
+// PopularPublishers returns the popular ones of publishers, the ones followed by a reader.
+// Their articles which were not fanned out are missing from the timeline of the reader.
+func PopularPublishers(ctx context.Context, publishers []string) ([]string, error) {
+	popular := make([]string, 0)
+
+	// BatchGetItem reads at most 100 keys per call
+	const batchGetSize = 100
+	for start := 0; start < len(publishers); start += batchGetSize {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		end := start + batchGetSize
+		if end > len(publishers) {
+			end = len(publishers)
+		}
+
+		keys := make([]dynamo.AWSObject, 0, end-start)
+		for _, publisher := range publishers[start:end] {
+			keys = append(keys, dynamo.StringKey("Publisher", publisher))
+		}
+
+		batchGetPublishers := dynamodb.BatchGetItemInput{
+			RequestItems: map[string]*dynamodb.KeysAndAttributes{
+				dynamo.PublisherTableName: {
+					Keys:                 keys,
+					ProjectionExpression: aws.String("Publisher, Popular"),
+				},
+			},
+		}
+
+		responses, err := dynamo.BatchGetItems(&batchGetPublishers, end-start)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, response := range responses {
+			items := make([]entities.Publisher, 0)
+			err = dynamodbattribute.UnmarshalListOfMaps(response[dynamo.PublisherTableName], &items)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, publisher := range items {
+				if publisher.Popular == 1 {
+					popular = append(popular, publisher.Publisher)
+				}
+			}
+		}
+	}
+
+	return popular, nil
+}
+
+func putEntryRequest(follower, author string, articleId, createdAt int64) (*dynamodb.WriteRequest, error) {
+	item, err := dynamodbattribute.MarshalMap(entities.TimelineEntry{
+		Follower:  follower,
+		ArticleId: articleId,
+		CreatedAt: createdAt,
+		Author:    author,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dynamodb.WriteRequest{
+		PutRequest: &dynamodb.PutRequest{
+			Item: item,
+		},
+	}, nil
+}
+
+func deleteEntryRequest(follower string, articleId int64) *dynamodb.WriteRequest {
+	return &dynamodb.WriteRequest{
+		DeleteRequest: &dynamodb.DeleteRequest{
+			Key: dynamo.AWSObject{
+				"Follower":  dynamo.StringValue(follower),
+				"ArticleId": dynamo.Int64Value(articleId),
+			},
+		},
+	}
+}
+
+func getFollowers(publisher string) ([]string, error) {
+	queryFollowers := dynamodb.QueryInput{
+		TableName:                 aws.String(dynamo.FollowTableName),
+		IndexName:                 aws.String("Publisher"),
+		KeyConditionExpression:    aws.String("Publisher=:publisher"),
+		ExpressionAttributeValues: dynamo.StringKey(":publisher", publisher),
+		ProjectionExpression:      aws.String("Follower"),
+	}
+
+	followers := make([]string, 0)
+	var unmarshalErr error
+
+	err := dynamo.DynamoDB().QueryPages(&queryFollowers, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		follows := make([]entities.Follow, 0, len(page.Items))
+		unmarshalErr = dynamodbattribute.UnmarshalListOfMaps(page.Items, &follows)
+		if unmarshalErr != nil {
+			return false
+		}
+
+		for _, follow := range follows {
+			followers = append(followers, follow.Follower)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return followers, unmarshalErr
+}
+
+// getFannedOutEntries returns the articles of publisher which were written to timelines
+func getFannedOutEntries(publisher string) ([]entities.TimelineEntry, error) {
+	queryArticles := dynamodb.QueryInput{
+		TableName:              aws.String(dynamo.ArticleTableName),
+		IndexName:              aws.String("Author"),
+		KeyConditionExpression: aws.String("Author=:author"),
+		FilterExpression:       aws.String("FannedOut=:true"),
+		ExpressionAttributeValues: dynamo.AWSObject{
+			":author": dynamo.StringValue(publisher),
+			":true":   {BOOL: aws.Bool(true)},
+		},
+		ProjectionExpression: aws.String("ArticleId, CreatedAt"),
+	}
+
+	entries := make([]entities.TimelineEntry, 0)
+	var unmarshalErr error
+
+	err := dynamo.DynamoDB().QueryPages(&queryArticles, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		pageEntries := make([]entities.TimelineEntry, 0, len(page.Items))
+		unmarshalErr = dynamodbattribute.UnmarshalListOfMaps(page.Items, &pageEntries)
+		if unmarshalErr != nil {
+			return false
+		}
+
+		entries = append(entries, pageEntries...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, unmarshalErr
+}
@@ -0,0 +1,33 @@
+package account
+
+import (
+	"errors"
+
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/memory"
+)
+
+const (
+	InstanceDynamodb int = iota
+	InstanceMemory
+)
+
+var ErrTokenNotFound = errors.New("account token not found")
+
+type TokenRepository interface {
+	PutToken(token entities.AccountToken) error
+	// ConsumeToken deletes and returns the token of tokenHash if it was issued for purpose,
+	// so that it can only be used once. It fails with ErrTokenNotFound otherwise.
+	ConsumeToken(tokenHash, purpose string) (*entities.AccountToken, error)
+}
+
+func NewTokenRepository(instance int) (TokenRepository, error) {
+	switch instance {
+	case InstanceDynamodb:
+		return &dynamoRepository{}, nil
+	case InstanceMemory:
+		return NewMemoryRepository(memory.DB()), nil
+	default:
+		return nil, errors.New("repository instance not found")
+	}
+}
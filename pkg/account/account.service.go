@@ -0,0 +1,215 @@
+package account
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/memory"
+	"github.com/ferjmc/cms/pkg/mail"
+	"github.com/ferjmc/cms/pkg/user"
+)
+
+const (
+	PasswordResetExpiration     = time.Hour
+	EmailVerificationExpiration = 48 * time.Hour
+)
+
+type AccountService interface {
+	// RequestPasswordReset mails a password reset token to email. It succeeds even if
+	// no user has email, so that it can't tell which emails are registered.
+	RequestPasswordReset(email string) error
+	// ResetPassword replaces the password of the user of a password reset token, and returns its current username
+	ResetPassword(token, password string) (string, error)
+	// RequestEmailVerification mails an email verification token to the email of user
+	RequestEmailVerification(user entities.User) error
+	// VerifyEmail marks the email of an email verification token as verified
+	VerifyEmail(token string) (*entities.User, error)
+}
+
+func NewAccountService(r TokenRepository, users user.UserService, mailer mail.Mailer) AccountService {
+	return &accountService{
+		repository: r,
+		users:      users,
+		mailer:     mailer,
+	}
+}
+
+func New(opts ...func(AccountService) AccountService) AccountService {
+	var serv AccountService
+	for _, opt := range opts {
+		serv = opt(serv)
+	}
+	// whitout opts retrieves service with dynamo by default
+	if len(opts) <= 0 {
+		if memory.Enabled() {
+			return WithMemory(serv)
+		}
+		return WithDynamoDB(serv)
+	}
+	return serv
+}
+
+func WithDynamoDB(serv AccountService) AccountService {
+	repo, err := NewTokenRepository(InstanceDynamodb)
+	if err != nil {
+		return serv
+	}
+	return NewAccountService(repo, user.New(user.WithDynamoDB), mail.New())
+}
+
+func WithMemory(serv AccountService) AccountService {
+	repo, err := NewTokenRepository(InstanceMemory)
+	if err != nil {
+		return serv
+	}
+	return NewAccountService(repo, user.New(user.WithMemory), mail.New())
+}
+
+type accountService struct {
+	repository TokenRepository
+	users      user.UserService
+	mailer     mail.Mailer
+}
+
+func (s *accountService) RequestPasswordReset(email string) error {
+	if email == "" {
+		return entities.NewInputError("email", "can't be blank")
+	}
+
+	user, err := s.users.GetUserByEmail(email)
+	if _, ok := err.(entities.NotFoundError); ok {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := s.issueToken(*user, entities.TokenPurposePasswordReset, PasswordResetExpiration)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(mail.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password of your account. "+
+			"If it was you, follow this link within an hour to choose a new one:\n\n%s\n\n"+
+			"Otherwise you can ignore this email, your password won't change.\n",
+			user.Username, link("reset-password", token)),
+	})
+}
+
+func (s *accountService) ResetPassword(token, password string) (string, error) {
+	// Checked first, an invalid password must not use up the token
+	err := entities.ValidatePassword(password)
+	if err != nil {
+		return "", err
+	}
+
+	accountToken, err := s.consumeToken(token, entities.TokenPurposePasswordReset)
+	if err != nil {
+		return "", err
+	}
+
+	// The user may have been renamed since the token was sent
+	return s.users.ResetPassword(accountToken.Username, password)
+}
+
+func (s *accountService) RequestEmailVerification(user entities.User) error {
+	if user.Verified {
+		return entities.NewConflictError("email", "is already verified")
+	}
+
+	token, err := s.issueToken(user, entities.TokenPurposeEmailVerification, EmailVerificationExpiration)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(mail.Message{
+		To:      user.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf("Hi %s,\n\nFollow this link within two days to verify your email:\n\n%s\n",
+			user.Username, link("verify-email", token)),
+	})
+}
+
+func (s *accountService) VerifyEmail(token string) (*entities.User, error) {
+	accountToken, err := s.consumeToken(token, entities.TokenPurposeEmailVerification)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.users.VerifyEmail(accountToken.Username, accountToken.Email)
+}
+
+// issueToken stores a new token of user for purpose and returns it
+func (s *accountService) issueToken(user entities.User, purpose string, expiration time.Duration) (string, error) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(expiration)
+
+	err = s.repository.PutToken(entities.AccountToken{
+		TokenHash: hashToken(token),
+		Purpose:   purpose,
+		Username:  user.Username,
+		Email:     user.Email,
+		CreatedAt: now.UnixNano(),
+		ExpiresAt: expiresAt.UnixNano(),
+		TTL:       expiresAt.Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// consumeToken returns the unexpired token of purpose, which can't be used again
+func (s *accountService) consumeToken(token, purpose string) (*entities.AccountToken, error) {
+	if token == "" {
+		return nil, entities.NewInputError("token", "can't be blank")
+	}
+
+	accountToken, err := s.repository.ConsumeToken(hashToken(token), purpose)
+	if err == ErrTokenNotFound {
+		return nil, entities.NewInputError("token", "invalid")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if time.Now().UTC().UnixNano() >= accountToken.ExpiresAt {
+		return nil, entities.NewInputError("token", "expired")
+	}
+
+	return accountToken, nil
+}
+
+// Tokens are random, a fast hash is enough to keep them out of the table
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// link returns the page of the web app at APP_URL handling token, or the bare token without it
+func link(page, token string) string {
+	appURL := strings.TrimSuffix(os.Getenv("APP_URL"), "/")
+	if appURL == "" {
+		return token
+	}
+	return fmt.Sprintf("%s/%s?token=%s", appURL, page, url.QueryEscape(token))
+}
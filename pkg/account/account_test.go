@@ -0,0 +1,213 @@
+package account
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/memory"
+	"github.com/ferjmc/cms/pkg/mail"
+	"github.com/ferjmc/cms/pkg/user"
+)
+
+func newTestService(t *testing.T) (*accountService, *mail.MemoryMailer) {
+	t.Helper()
+	return newTestServiceWithStore(t, memory.NewStore())
+}
+
+// newTestServiceWithStore registers alice in store, which the test can change behind the service
+func newTestServiceWithStore(t *testing.T, store *memory.Store) (*accountService, *mail.MemoryMailer) {
+	t.Helper()
+	mailer := &mail.MemoryMailer{}
+	users := user.NewUserService(user.NewMemoryRepository(store))
+
+	err := users.PutUser(entities.User{Username: "alice", Email: "alice@fake.com"}, "123456")
+	if err != nil {
+		t.Fatalf("PutUser: %s", err)
+	}
+
+	return &accountService{
+		repository: NewMemoryRepository(store),
+		users:      users,
+		mailer:     mailer,
+	}, mailer
+}
+
+// lastToken returns the token of the last message sent to to, the message
+// holds it on its own line when APP_URL isn't set
+func lastToken(t *testing.T, mailer *mail.MemoryMailer, to string) string {
+	t.Helper()
+	messages := mailer.Messages(to)
+	if len(messages) == 0 {
+		t.Fatalf("no message sent to %s", to)
+	}
+	for _, line := range strings.Split(messages[len(messages)-1].Body, "\n") {
+		if len(line) == 43 && !strings.Contains(line, " ") {
+			return line
+		}
+	}
+	t.Fatalf("no token in %q", messages[len(messages)-1].Body)
+	return ""
+}
+
+func isInputError(err error) bool {
+	_, ok := err.(entities.InputError)
+	return ok
+}
+
+func isUnauthorizedError(err error) bool {
+	_, ok := err.(entities.UnauthorizedError)
+	return ok
+}
+
+func TestPasswordReset(t *testing.T) {
+	s, mailer := newTestService(t)
+
+	err := s.RequestPasswordReset("nobody@fake.com")
+	if err != nil {
+		t.Errorf("an unknown email must not be told apart, got %s", err)
+	}
+	if len(mailer.Messages("nobody@fake.com")) != 0 {
+		t.Errorf("no message must be sent to an unknown email")
+	}
+
+	err = s.RequestPasswordReset("alice@fake.com")
+	if err != nil {
+		t.Fatalf("RequestPasswordReset: %s", err)
+	}
+	token := lastToken(t, mailer, "alice@fake.com")
+
+	_, err = s.ResetPassword(token, "short")
+	if !isInputError(err) {
+		t.Errorf("expected an input error for a short password, got %v", err)
+	}
+
+	username, err := s.ResetPassword(token, "654321")
+	if err != nil || username != "alice" {
+		t.Fatalf("ResetPassword = %s, %v", username, err)
+	}
+
+	if _, err = s.users.Login("alice@fake.com", "654321"); err != nil {
+		t.Errorf("the new password must be accepted: %s", err)
+	}
+	if _, err = s.users.Login("alice@fake.com", "123456"); !isUnauthorizedError(err) {
+		t.Errorf("the old password must be rejected, got %v", err)
+	}
+
+	_, err = s.ResetPassword(token, "abcdef")
+	if !isInputError(err) {
+		t.Errorf("a token must only be used once, got %v", err)
+	}
+}
+
+func TestPasswordResetAfterRename(t *testing.T) {
+	store := memory.NewStore()
+	s, mailer := newTestServiceWithStore(t, store)
+
+	err := s.RequestPasswordReset("alice@fake.com")
+	if err != nil {
+		t.Fatalf("RequestPasswordReset: %s", err)
+	}
+	token := lastToken(t, mailer, "alice@fake.com")
+
+	alice, err := s.users.GetUserByUsername("alice")
+	if err != nil {
+		t.Fatalf("GetUserByUsername: %s", err)
+	}
+	renamed := *alice
+	renamed.Username = "alicia"
+	err = user.NewMemoryRepository(store).RenameUser(*alice, renamed)
+	if err != nil {
+		t.Fatalf("RenameUser: %s", err)
+	}
+
+	username, err := s.ResetPassword(token, "654321")
+	if err != nil || username != "alicia" {
+		t.Fatalf("expected the password of the renamed user to be reset, got %s, %v", username, err)
+	}
+
+	if _, err = s.users.Login("alice@fake.com", "654321"); err != nil {
+		t.Errorf("the new password must be accepted: %s", err)
+	}
+}
+
+func TestEmailVerification(t *testing.T) {
+	s, mailer := newTestService(t)
+
+	alice, err := s.users.GetUserByUsername("alice")
+	if err != nil {
+		t.Fatalf("GetUserByUsername: %s", err)
+	}
+
+	err = s.RequestEmailVerification(*alice)
+	if err != nil {
+		t.Fatalf("RequestEmailVerification: %s", err)
+	}
+	token := lastToken(t, mailer, "alice@fake.com")
+
+	_, err = s.ResetPassword(token, "654321")
+	if !isInputError(err) {
+		t.Errorf("a verification token must not reset the password, got %v", err)
+	}
+
+	verified, err := s.VerifyEmail(token)
+	if err != nil {
+		t.Fatalf("VerifyEmail: %s", err)
+	}
+	if !verified.Verified {
+		t.Errorf("expected a verified user")
+	}
+
+	err = s.RequestEmailVerification(*verified)
+	if _, ok := err.(entities.ConflictError); !ok {
+		t.Errorf("expected a conflict error verifying a verified email, got %v", err)
+	}
+}
+
+func TestEmailVerificationOfAChangedEmail(t *testing.T) {
+	s, mailer := newTestService(t)
+
+	alice, err := s.users.GetUserByUsername("alice")
+	if err != nil {
+		t.Fatalf("GetUserByUsername: %s", err)
+	}
+
+	err = s.RequestEmailVerification(*alice)
+	if err != nil {
+		t.Fatalf("RequestEmailVerification: %s", err)
+	}
+	token := lastToken(t, mailer, "alice@fake.com")
+
+	changed := *alice
+	changed.Email = "alice@new.com"
+	err = user.NewMemoryRepository(s.repository.(*memoryRepository).store).UpdateUser(*alice, changed)
+	if err != nil {
+		t.Fatalf("UpdateUser: %s", err)
+	}
+
+	_, err = s.VerifyEmail(token)
+	if _, ok := err.(entities.ConflictError); !ok {
+		t.Errorf("expected a conflict error verifying a previous email, got %v", err)
+	}
+}
+
+func TestExpiredToken(t *testing.T) {
+	s, mailer := newTestService(t)
+
+	err := s.RequestPasswordReset("alice@fake.com")
+	if err != nil {
+		t.Fatalf("RequestPasswordReset: %s", err)
+	}
+	token := lastToken(t, mailer, "alice@fake.com")
+
+	store := s.repository.(*memoryRepository).store
+	for tokenHash, accountToken := range store.AccountTokens {
+		accountToken.ExpiresAt = accountToken.CreatedAt
+		store.AccountTokens[tokenHash] = accountToken
+	}
+
+	_, err = s.ResetPassword(token, "654321")
+	if !isInputError(err) {
+		t.Errorf("expected an input error using an expired token, got %v", err)
+	}
+}
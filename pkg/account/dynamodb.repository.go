@@ -0,0 +1,55 @@
+package account
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/dynamo"
+)
+
+type dynamoRepository struct{}
+
+func (d *dynamoRepository) PutToken(token entities.AccountToken) error {
+	item, err := dynamodbattribute.MarshalMap(token)
+	if err != nil {
+		return err
+	}
+
+	putToken := dynamodb.PutItemInput{
+		TableName:           aws.String(dynamo.AccountTokenTableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(TokenHash)"),
+	}
+
+	_, err = dynamo.DynamoDB().PutItem(&putToken)
+
+	return err
+}
+
+func (d *dynamoRepository) ConsumeToken(tokenHash, purpose string) (*entities.AccountToken, error) {
+	// The conditional delete makes sure that concurrent uses of a token can't both succeed
+	deleteToken := dynamodb.DeleteItemInput{
+		TableName:                 aws.String(dynamo.AccountTokenTableName),
+		Key:                       dynamo.StringKey("TokenHash", tokenHash),
+		ConditionExpression:       aws.String("Purpose=:purpose"),
+		ExpressionAttributeValues: dynamo.StringKey(":purpose", purpose),
+		ReturnValues:              aws.String(dynamodb.ReturnValueAllOld),
+	}
+
+	output, err := dynamo.DynamoDB().DeleteItem(&deleteToken)
+	if dynamo.IsConditionalCheckFailed(err) {
+		return nil, ErrTokenNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var token entities.AccountToken
+	err = dynamodbattribute.UnmarshalMap(output.Attributes, &token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
@@ -0,0 +1,41 @@
+package account
+
+import (
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/memory"
+)
+
+type memoryRepository struct {
+	store *memory.Store
+}
+
+// NewMemoryRepository returns a TokenRepository backed by store, mostly useful
+// for tests which need an isolated store
+func NewMemoryRepository(store *memory.Store) TokenRepository {
+	return &memoryRepository{
+		store: store,
+	}
+}
+
+func (m *memoryRepository) PutToken(token entities.AccountToken) error {
+	m.store.Lock()
+	defer m.store.Unlock()
+
+	m.store.AccountTokens[token.TokenHash] = token
+
+	return nil
+}
+
+func (m *memoryRepository) ConsumeToken(tokenHash, purpose string) (*entities.AccountToken, error) {
+	m.store.Lock()
+	defer m.store.Unlock()
+
+	token, ok := m.store.AccountTokens[tokenHash]
+	if !ok || token.Purpose != purpose {
+		return nil, ErrTokenNotFound
+	}
+
+	delete(m.store.AccountTokens, tokenHash)
+
+	return &token, nil
+}
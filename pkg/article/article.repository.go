@@ -4,26 +4,79 @@ import (
 	"errors"
 
 	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/memory"
 )
 
 const (
 	InstanceDynamodb int = iota
+	InstanceMemory
 )
 
+func errArticleNotFound() error {
+	return entities.NewNotFoundError("article", "not found")
+}
+
+func errRevisionNotFound() error {
+	return entities.NewNotFoundError("revision", "not found")
+}
+
+func errNotAuthor() error {
+	return entities.NewForbiddenError("article", "only the author can modify an article")
+}
+
+func errNotRevisionReader() error {
+	return entities.NewForbiddenError("revisions", "only the users who can modify an article can read its revisions")
+}
+
 type ArticleRepository interface {
+	// PutArticle and UpdateArticle store the revision of the saved article when its Revision changes,
+	// DeleteArticle deletes the revisions along with the article
 	PutArticle(article *entities.Article) error
+	GetArticleById(articleId int64) (*entities.Article, error)
+	UpdateArticle(oldArticle, newArticle entities.Article) error
+	DeleteArticle(article entities.Article) error
 	GetAllArticles(offset, limit int) ([]entities.Article, error)
 	GetArticlesByAuthor(author string, offset, limit int) ([]entities.Article, error)
 	GetArticlesByTag(tag string, offset, limit int) ([]entities.Article, error)
 	GetFavoriteArticlesByUsername(username string, offset, limit int) ([]entities.Article, error)
+	// FavoriteArticle and UnfavoriteArticle return whether the favorite actually changed,
+	// so repeated calls never count twice
+	FavoriteArticle(username string, articleId int64) (bool, error)
+	UnfavoriteArticle(username string, articleId int64) (bool, error)
 	IsArticleFavoritedByUser(user *entities.User, articles []entities.Article) ([]bool, error)
 	GetFeed(username string, offset, limit int) ([]entities.Article, error)
+
+	// The Page variants read at most limit articles after an opaque cursor, "" being the first page,
+	// and return the cursor of the next page, "" once the list is exhausted.
+	// Their cost doesn't depend on how deep the page is.
+	GetAllArticlesPage(cursor string, limit int) ([]entities.Article, string, error)
+	GetArticlesByAuthorPage(author, cursor string, limit int) ([]entities.Article, string, error)
+	GetArticlesByTagPage(tag, cursor string, limit int) ([]entities.Article, string, error)
+	GetFavoriteArticlesByUsernamePage(username, cursor string, limit int) ([]entities.Article, string, error)
+	GetFeedPage(username, cursor string, limit int) ([]entities.Article, string, error)
+
+	// Every other read only returns published articles.
+	// GetUnpublishedArticlesByAuthorPage pages through the drafts, scheduled and archived articles of author,
+	// GetScheduledArticles returns at most limit scheduled articles due at until, oldest PublishAt first.
+	GetUnpublishedArticlesByAuthorPage(author, cursor string, limit int) ([]entities.Article, string, error)
+	GetScheduledArticles(until int64, limit int) ([]entities.Article, error)
+
+	// GetArticleRevisionsPage pages through the revisions of an article, newest first
+	GetArticleRevisionsPage(articleId int64, cursor string, limit int) ([]entities.ArticleRevision, string, error)
+	GetArticleRevision(articleId, revision int64) (*entities.ArticleRevision, error)
+
+	// RenameAuthor and RenameFavorites give the articles and favorites of oldUsername to newUsername,
+	// one page of at most limit rows after cursor. They return the cursor of the next page, "" once done.
+	RenameAuthor(oldUsername, newUsername, cursor string, limit int) (string, error)
+	RenameFavorites(oldUsername, newUsername, cursor string, limit int) (string, error)
 }
 
 func NewArticleRepository(instance int) (ArticleRepository, error) {
 	switch instance {
 	case InstanceDynamodb:
 		return &dynamoRepository{}, nil
+	case InstanceMemory:
+		return NewMemoryRepository(memory.DB()), nil
 	default:
 		return nil, errors.New("repository instance not found")
 	}
@@ -3,19 +3,56 @@ package article
 import (
 	"errors"
 	"fmt"
+	"log"
+	"time"
 
 	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/diff"
+	"github.com/ferjmc/cms/internal/memory"
+	"github.com/ferjmc/cms/pkg/auth"
 	"github.com/ferjmc/cms/pkg/follow"
 	"github.com/ferjmc/cms/pkg/user"
 )
 
 type ArticleService interface {
+	// PutArticle saves a new article and its first revision, published unless its Status is draft or scheduled
 	PutArticle(article *entities.Article) error
+	// GetArticleBySlug resolves an article from the hex id suffix of its slug,
+	// the title prefix is ignored so stale slugs keep working after a rename.
+	// Drafts and scheduled articles are not found unless user is their author, user may be nil.
+	GetArticleBySlug(user *entities.User, slug string) (*entities.Article, error)
+	// UpdateArticle replaces the editable fields and the status of oldArticle with the ones of newArticle,
+	// allowed for the author of the article and the editors. An empty Status keeps the current one.
+	// A change of content is saved as a new revision.
+	UpdateArticle(user *entities.User, oldArticle entities.Article, newArticle *entities.Article) error
+	// DeleteArticle removes an article and its tags, allowed for the author of the article and the editors
+	DeleteArticle(user *entities.User, article entities.Article) error
 	GetArticles(offset, limit int, author, tag, favorited string) ([]entities.Article, error)
+	// Favorite and Unfavorite are idempotent, article.FavoritesCount is updated
+	// only when the favorite state actually changed
+	Favorite(user *entities.User, article *entities.Article) error
+	Unfavorite(user *entities.User, article *entities.Article) error
 	GetArticleRelatedProperties(user *entities.User, articles []entities.Article, getFollowing bool) ([]bool, []entities.User, []bool, error)
 	GetFeed(username string, offset, limit int) ([]entities.Article, error)
+	// GetArticlesPage and GetFeedPage paginate with opaque cursors instead of offsets,
+	// they return the cursor of the next page, empty on the last one
+	GetArticlesPage(cursor string, limit int, author, tag, favorited string) ([]entities.Article, string, error)
+	GetFeedPage(username, cursor string, limit int) ([]entities.Article, string, error)
+	// GetUnpublishedArticlesPage pages through the drafts, scheduled and archived articles of user
+	GetUnpublishedArticlesPage(user *entities.User, cursor string, limit int) ([]entities.Article, string, error)
+	// PublishScheduledArticles publishes the scheduled articles due at now and returns how many were published
+	PublishScheduledArticles(now int64) (int, error)
+	// GetRevisionsPage pages through the revisions of article newest first, for the users who may update it
+	GetRevisionsPage(user *entities.User, article entities.Article, cursor string, limit int) ([]entities.ArticleRevision, string, error)
+	// DiffRevisions returns the unified diff of the body of article from revision from to revision to,
+	// revision 0 being an empty article
+	DiffRevisions(user *entities.User, article entities.Article, from, to int64) (string, error)
+	// RestoreRevision saves the content of a revision of article as its newest revision
+	RestoreRevision(user *entities.User, article entities.Article, revision int64) (*entities.Article, error)
 }
 
+const MaxPageLimit = 100
+
 func NewArticleService(r ArticleRepository, u user.UserService, f follow.FollowService) ArticleService {
 	return &articleService{
 		repository: r,
@@ -31,6 +68,9 @@ func New(opts ...func(ArticleService) ArticleService) ArticleService {
 	}
 	// whitout opts retrieves service with dynamo by default
 	if len(opts) <= 0 {
+		if memory.Enabled() {
+			return WithMemory(serv)
+		}
 		return WithDynamoDB(serv)
 	}
 	return serv
@@ -46,6 +86,16 @@ func WithDynamoDB(serv ArticleService) ArticleService {
 	return NewArticleService(repo, user, follow)
 }
 
+func WithMemory(serv ArticleService) ArticleService {
+	user := user.New(user.WithMemory)
+	follow := follow.New(follow.WithMemory)
+	repo, err := NewArticleRepository(InstanceMemory)
+	if err != nil {
+		return serv
+	}
+	return NewArticleService(repo, user, follow)
+}
+
 type articleService struct {
 	repository ArticleRepository
 	users      user.UserService
@@ -58,9 +108,105 @@ func (s *articleService) PutArticle(article *entities.Article) error {
 		return err
 	}
 
+	err = article.ValidateStatus(time.Now().UTC().UnixNano())
+	if err != nil {
+		return err
+	}
+
+	if article.Status == entities.ArticleStatusArchived {
+		return entities.NewInputError("status", "a new article can't be archived")
+	}
+
+	article.UpdatedBy = article.Author
+	article.Revision = 1
+
 	return s.repository.PutArticle(article)
 }
 
+func (s *articleService) GetArticleBySlug(user *entities.User, slug string) (*entities.Article, error) {
+	// A slug which can't be parsed can't name an article
+	articleId, err := entities.SlugToArticleId(slug)
+	if err != nil {
+		return nil, errArticleNotFound()
+	}
+
+	if articleId <= 0 || articleId >= entities.MaxArticleId {
+		return nil, errArticleNotFound()
+	}
+
+	article, err := s.repository.GetArticleById(articleId)
+	if err != nil {
+		return nil, err
+	}
+
+	username := ""
+	if user != nil {
+		username = user.Username
+	}
+	if !article.VisibleTo(username) {
+		return nil, errArticleNotFound()
+	}
+
+	return article, nil
+}
+
+func (s *articleService) UpdateArticle(user *entities.User, oldArticle entities.Article, newArticle *entities.Article) error {
+	if !canUpdate(user, oldArticle) {
+		return errNotAuthor()
+	}
+
+	err := newArticle.Validate()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().UnixNano()
+
+	if newArticle.Status == "" {
+		newArticle.Status = oldArticle.Status
+		if newArticle.PublishAt == 0 {
+			newArticle.PublishAt = oldArticle.PublishAt
+		}
+	}
+
+	err = newArticle.ValidateStatus(now)
+	if err != nil {
+		return err
+	}
+
+	err = newArticle.ValidateStatusChange(oldArticle.Status)
+	if err != nil {
+		return err
+	}
+
+	newArticle.ArticleId = oldArticle.ArticleId
+	newArticle.Author = oldArticle.Author
+	newArticle.CreatedAt = oldArticle.CreatedAt
+	newArticle.FavoritesCount = oldArticle.FavoritesCount
+	newArticle.UpdatedAt = now
+	newArticle.UpdatedBy = user.Username
+	newArticle.Revision = oldArticle.Revision
+	if !newArticle.SameContent(oldArticle) {
+		newArticle.Revision++
+	}
+	newArticle.MakeSlug()
+
+	// Listings are sorted by CreatedAt, a draft takes its place when it is published
+	if !oldArticle.Published() && oldArticle.Status != entities.ArticleStatusArchived && newArticle.Published() {
+		newArticle.CreatedAt = now
+	}
+
+	return s.repository.UpdateArticle(oldArticle, *newArticle)
+}
+
+func (s *articleService) DeleteArticle(user *entities.User, article entities.Article) error {
+	if !isAuthor(user, article) && !auth.Can(user, auth.PermissionDeleteAnyArticle) {
+		return errNotAuthor()
+	}
+
+	return s.repository.DeleteArticle(article)
+}
+
 func (s *articleService) GetArticles(offset, limit int, author, tag, favorited string) ([]entities.Article, error) {
 	if offset < 0 {
 		return nil, entities.NewInputError("offset", "must be non-negative")
@@ -99,6 +245,70 @@ func (s *articleService) GetArticles(offset, limit int, author, tag, favorited s
 	return nil, errors.New("unreachable code")
 }
 
+func (s *articleService) Favorite(user *entities.User, article *entities.Article) error {
+	changed, err := s.repository.FavoriteArticle(user.Username, article.ArticleId)
+	if err != nil {
+		return err
+	}
+
+	if changed {
+		article.FavoritesCount++
+	}
+
+	return nil
+}
+
+func (s *articleService) Unfavorite(user *entities.User, article *entities.Article) error {
+	changed, err := s.repository.UnfavoriteArticle(user.Username, article.ArticleId)
+	if err != nil {
+		return err
+	}
+
+	if changed {
+		article.FavoritesCount--
+	}
+
+	return nil
+}
+
+func (s *articleService) GetArticlesPage(cursor string, limit int, author, tag, favorited string) ([]entities.Article, string, error) {
+	err := validatePageLimit(limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	numFilters := getNumFilters(author, tag, favorited)
+	if numFilters > 1 {
+		return nil, "", entities.NewInputError("author, tag, favorited", "only one of these can be specified")
+	}
+
+	if author != "" {
+		return s.repository.GetArticlesByAuthorPage(author, cursor, limit)
+	}
+
+	if tag != "" {
+		return s.repository.GetArticlesByTagPage(tag, cursor, limit)
+	}
+
+	if favorited != "" {
+		return s.repository.GetFavoriteArticlesByUsernamePage(favorited, cursor, limit)
+	}
+
+	return s.repository.GetAllArticlesPage(cursor, limit)
+}
+
+func validatePageLimit(limit int) error {
+	if limit <= 0 {
+		return entities.NewInputError("limit", "must be positive")
+	}
+
+	if limit > MaxPageLimit {
+		return entities.NewInputError("limit", fmt.Sprintf("must be smaller or equal to %d", MaxPageLimit))
+	}
+
+	return nil
+}
+
 func getNumFilters(author, tag, favorited string) int {
 	numFilters := 0
 	if author != "" {
@@ -144,3 +354,144 @@ func (s *articleService) GetArticleRelatedProperties(user *entities.User, articl
 func (s *articleService) GetFeed(username string, offset, limit int) ([]entities.Article, error) {
 	return s.repository.GetFeed(username, offset, limit)
 }
+
+func (s *articleService) GetFeedPage(username, cursor string, limit int) ([]entities.Article, string, error) {
+	err := validatePageLimit(limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return s.repository.GetFeedPage(username, cursor, limit)
+}
+
+func (s *articleService) GetUnpublishedArticlesPage(user *entities.User, cursor string, limit int) ([]entities.Article, string, error) {
+	err := validatePageLimit(limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return s.repository.GetUnpublishedArticlesByAuthorPage(user.Username, cursor, limit)
+}
+
+// publishBatchSize is the number of scheduled articles read at once by PublishScheduledArticles
+const publishBatchSize = 25
+
+func (s *articleService) PublishScheduledArticles(now int64) (int, error) {
+	published := 0
+
+	for {
+		articles, err := s.repository.GetScheduledArticles(now, publishBatchSize)
+		if err != nil {
+			return published, err
+		}
+
+		publishedBatch := 0
+		for _, oldArticle := range articles {
+			// Scheduled articles are listed at their publication time
+			newArticle := oldArticle
+			newArticle.Status = entities.ArticleStatusPublished
+			newArticle.PublishAt = 0
+			newArticle.CreatedAt = oldArticle.PublishAt
+			newArticle.UpdatedAt = now
+
+			err = s.repository.UpdateArticle(oldArticle, newArticle)
+			if _, ok := err.(entities.ConflictError); ok {
+				// Edited or deleted meanwhile, it is read again by the next run if still due
+				log.Printf("WARN: publication of article %d: %s", oldArticle.ArticleId, err)
+				continue
+			}
+			if err != nil {
+				return published, err
+			}
+			publishedBatch++
+		}
+
+		published += publishedBatch
+		if len(articles) < publishBatchSize || publishedBatch == 0 {
+			return published, nil
+		}
+	}
+}
+
+func (s *articleService) GetRevisionsPage(user *entities.User, article entities.Article, cursor string, limit int) ([]entities.ArticleRevision, string, error) {
+	if !canUpdate(user, article) {
+		return nil, "", errNotRevisionReader()
+	}
+
+	err := validatePageLimit(limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return s.repository.GetArticleRevisionsPage(article.ArticleId, cursor, limit)
+}
+
+func (s *articleService) DiffRevisions(user *entities.User, article entities.Article, from, to int64) (string, error) {
+	if !canUpdate(user, article) {
+		return "", errNotRevisionReader()
+	}
+
+	fromRevision, err := s.getRevision(article.ArticleId, from)
+	if err != nil {
+		return "", err
+	}
+
+	toRevision, err := s.getRevision(article.ArticleId, to)
+	if err != nil {
+		return "", err
+	}
+
+	return diff.Unified(revisionName(from), revisionName(to), fromRevision.Body, toRevision.Body, 3), nil
+}
+
+// getRevision reads a revision of an article, revision 0 being empty
+func (s *articleService) getRevision(articleId, revision int64) (*entities.ArticleRevision, error) {
+	if revision < 0 {
+		return nil, errRevisionNotFound()
+	}
+	if revision == 0 {
+		return &entities.ArticleRevision{ArticleId: articleId}, nil
+	}
+	return s.repository.GetArticleRevision(articleId, revision)
+}
+
+func revisionName(revision int64) string {
+	return fmt.Sprintf("revision %d", revision)
+}
+
+func (s *articleService) RestoreRevision(user *entities.User, article entities.Article, revision int64) (*entities.Article, error) {
+	if !canUpdate(user, article) {
+		return nil, errNotAuthor()
+	}
+
+	if revision <= 0 {
+		return nil, errRevisionNotFound()
+	}
+
+	restored, err := s.repository.GetArticleRevision(article.ArticleId, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	newArticle := article
+	newArticle.Title = restored.Title
+	newArticle.Description = restored.Description
+	newArticle.Body = restored.Body
+	newArticle.TagList = restored.TagList
+
+	err = s.UpdateArticle(user, article, &newArticle)
+	if err != nil {
+		return nil, err
+	}
+
+	return &newArticle, nil
+}
+
+// canUpdate tells if user may modify article
+func canUpdate(user *entities.User, article entities.Article) bool {
+	return isAuthor(user, article) || auth.Can(user, auth.PermissionUpdateAnyArticle)
+}
+
+func isAuthor(user *entities.User, article entities.Article) bool {
+	return user != nil && article.Author == user.Username
+}
@@ -0,0 +1,498 @@
+// Package articletest checks that an article.ArticleRepository implementation behaves
+// like the DynamoDB one, so every backend can be plugged into the same suite.
+package articletest
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/pkg/article"
+	"github.com/ferjmc/cms/pkg/follow"
+)
+
+// Run executes the conformance suite. newRepositories must return an article
+// repository and the follow repository sharing its storage, which the feed reads.
+// Authors and tags are unique per run, so the suite can share storage with other data.
+func Run(t *testing.T, newRepositories func() (article.ArticleRepository, follow.FollowRepository)) {
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+	name := func(name string) string {
+		return name + "-" + suffix
+	}
+
+	// Every article gets a distinct, increasing CreatedAt
+	clock := time.Now().UTC().UnixNano()
+	newArticle := func(author string, tags ...string) *entities.Article {
+		clock += int64(time.Millisecond)
+		return &entities.Article{
+			Title:       "title " + strconv.FormatInt(clock, 10),
+			Description: "description",
+			Body:        "body",
+			TagList:     tags,
+			CreatedAt:   clock,
+			UpdatedAt:   clock,
+			Author:      author,
+		}
+	}
+
+	putArticles := func(t *testing.T, repo article.ArticleRepository, n int, author string, tags ...string) []entities.Article {
+		t.Helper()
+		articles := make([]entities.Article, 0, n)
+		for i := 0; i < n; i++ {
+			a := newArticle(author, tags...)
+			if err := repo.PutArticle(a); err != nil {
+				t.Fatalf("PutArticle: %s", err)
+			}
+			articles = append(articles, *a)
+		}
+		return articles
+	}
+
+	t.Run("PutArticle assigns an id and a slug", func(t *testing.T) {
+		repo, _ := newRepositories()
+		a := newArticle(name("alice"))
+		if err := repo.PutArticle(a); err != nil {
+			t.Fatalf("PutArticle: %s", err)
+		}
+
+		if a.ArticleId <= 0 || a.ArticleId >= entities.MaxArticleId {
+			t.Errorf("article id %d out of range", a.ArticleId)
+		}
+
+		articleId, err := entities.SlugToArticleId(a.Slug)
+		if err != nil || articleId != a.ArticleId {
+			t.Errorf("slug %s doesn't resolve to %d", a.Slug, a.ArticleId)
+		}
+
+		found, err := repo.GetArticleById(a.ArticleId)
+		if err != nil {
+			t.Fatalf("GetArticleById: %s", err)
+		}
+		if found.Title != a.Title || found.Author != a.Author {
+			t.Errorf("stored %+v, found %+v", *a, *found)
+		}
+	})
+
+	t.Run("GetArticleById reports unknown articles", func(t *testing.T) {
+		repo, _ := newRepositories()
+		a := putArticles(t, repo, 1, name("bob"))[0]
+		if err := repo.DeleteArticle(a); err != nil {
+			t.Fatalf("DeleteArticle: %s", err)
+		}
+
+		if _, err := repo.GetArticleById(a.ArticleId); !isNotFound(err) {
+			t.Errorf("expected a not found error, got %v", err)
+		}
+	})
+
+	t.Run("GetArticlesByAuthor is newest first with offset and limit", func(t *testing.T) {
+		repo, _ := newRepositories()
+		articles := putArticles(t, repo, 5, name("carol"))
+
+		all, err := repo.GetArticlesByAuthor(name("carol"), 0, 10)
+		if err != nil {
+			t.Fatalf("GetArticlesByAuthor: %s", err)
+		}
+		assertArticles(t, all, articles[4], articles[3], articles[2], articles[1], articles[0])
+
+		page, err := repo.GetArticlesByAuthor(name("carol"), 1, 2)
+		if err != nil {
+			t.Fatalf("GetArticlesByAuthor: %s", err)
+		}
+		assertArticles(t, page, articles[3], articles[2])
+
+		past, err := repo.GetArticlesByAuthor(name("carol"), 5, 2)
+		if err != nil {
+			t.Fatalf("GetArticlesByAuthor: %s", err)
+		}
+		assertArticles(t, past)
+	})
+
+	t.Run("Cursor pages chain without gaps or repeats", func(t *testing.T) {
+		repo, follows := newRepositories()
+		tag := name("paged")
+		fan := name("pager")
+		if err := follows.Follow(entities.Follow{Follower: fan, Publisher: name("kim")}); err != nil {
+			t.Fatalf("Follow: %s", err)
+		}
+
+		articles := putArticles(t, repo, 5, name("kim"), tag)
+		for i := range articles {
+			// FavoritedAt comes from the repository clock, keep favorites apart
+			time.Sleep(time.Millisecond)
+			if _, err := repo.FavoriteArticle(fan, articles[i].ArticleId); err != nil {
+				t.Fatalf("FavoriteArticle: %s", err)
+			}
+		}
+		expected := []entities.Article{articles[4], articles[3], articles[2], articles[1], articles[0]}
+
+		lists := map[string]func(cursor string) ([]entities.Article, string, error){
+			"author": func(cursor string) ([]entities.Article, string, error) {
+				return repo.GetArticlesByAuthorPage(name("kim"), cursor, 2)
+			},
+			"tag": func(cursor string) ([]entities.Article, string, error) {
+				return repo.GetArticlesByTagPage(tag, cursor, 2)
+			},
+			"favorited": func(cursor string) ([]entities.Article, string, error) {
+				return repo.GetFavoriteArticlesByUsernamePage(fan, cursor, 2)
+			},
+			"feed": func(cursor string) ([]entities.Article, string, error) {
+				return repo.GetFeedPage(fan, cursor, 2)
+			},
+		}
+
+		for list, getPage := range lists {
+			var all []entities.Article
+			cursor := ""
+			for pages := 0; ; pages++ {
+				if pages > len(expected) {
+					t.Fatalf("%s: too many pages", list)
+				}
+
+				page, next, err := getPage(cursor)
+				if err != nil {
+					t.Fatalf("%s: %s", list, err)
+				}
+				if len(page) > 2 {
+					t.Fatalf("%s: page of %d articles exceeds the limit", list, len(page))
+				}
+
+				all = append(all, page...)
+				if next == "" {
+					break
+				}
+				cursor = next
+			}
+
+			if len(all) != len(expected) {
+				t.Fatalf("%s: expected articles %v, got %v", list, articleIds(expected), articleIds(all))
+			}
+			for i := range expected {
+				if all[i].ArticleId != expected[i].ArticleId {
+					t.Fatalf("%s: expected articles %v, got %v", list, articleIds(expected), articleIds(all))
+				}
+			}
+		}
+
+		if _, _, err := repo.GetAllArticlesPage("not a cursor", 2); err == nil {
+			t.Error("expected an error for an invalid cursor")
+		}
+	})
+
+	t.Run("GetAllArticles is newest first", func(t *testing.T) {
+		repo, _ := newRepositories()
+		articles := putArticles(t, repo, 3, name("dave"))
+
+		all, err := repo.GetAllArticles(0, 3)
+		if err != nil {
+			t.Fatalf("GetAllArticles: %s", err)
+		}
+		assertArticles(t, all, articles[2], articles[1], articles[0])
+
+		page, err := repo.GetAllArticles(1, 1)
+		if err != nil {
+			t.Fatalf("GetAllArticles: %s", err)
+		}
+		assertArticles(t, page, articles[1])
+	})
+
+	t.Run("GetArticlesByTag is newest first and follows tag edits", func(t *testing.T) {
+		repo, _ := newRepositories()
+		tag := name("go")
+		articles := putArticles(t, repo, 3, name("erin"), tag)
+
+		page, err := repo.GetArticlesByTag(tag, 1, 5)
+		if err != nil {
+			t.Fatalf("GetArticlesByTag: %s", err)
+		}
+		assertArticles(t, page, articles[1], articles[0])
+
+		// Move the newest article to another tag
+		updated := articles[2]
+		updated.TagList = []string{name("rust")}
+		updated.UpdatedAt++
+		if err := repo.UpdateArticle(articles[2], updated); err != nil {
+			t.Fatalf("UpdateArticle: %s", err)
+		}
+
+		tagged, err := repo.GetArticlesByTag(tag, 0, 5)
+		if err != nil {
+			t.Fatalf("GetArticlesByTag: %s", err)
+		}
+		assertArticles(t, tagged, articles[1], articles[0])
+
+		retagged, err := repo.GetArticlesByTag(name("rust"), 0, 5)
+		if err != nil {
+			t.Fatalf("GetArticlesByTag: %s", err)
+		}
+		assertArticles(t, retagged, updated)
+	})
+
+	t.Run("UpdateArticle rejects a stale article", func(t *testing.T) {
+		repo, _ := newRepositories()
+		a := putArticles(t, repo, 1, name("frank"))[0]
+
+		first := a
+		first.Title = "first edit"
+		first.UpdatedAt++
+		if err := repo.UpdateArticle(a, first); err != nil {
+			t.Fatalf("UpdateArticle: %s", err)
+		}
+
+		second := a
+		second.Title = "second edit"
+		second.UpdatedAt += 2
+		if err := repo.UpdateArticle(a, second); err == nil {
+			t.Error("expected an error when updating from a stale article")
+		}
+	})
+
+	t.Run("Favorites are idempotent and counted once", func(t *testing.T) {
+		repo, _ := newRepositories()
+		articles := putArticles(t, repo, 2, name("grace"))
+		fan := entities.User{Username: name("fan")}
+
+		for i := 0; i < 2; i++ {
+			changed, err := repo.FavoriteArticle(fan.Username, articles[0].ArticleId)
+			if err != nil {
+				t.Fatalf("FavoriteArticle: %s", err)
+			}
+			if changed != (i == 0) {
+				t.Errorf("favorite #%d: expected changed=%v", i+1, i == 0)
+			}
+		}
+
+		found, err := repo.GetArticleById(articles[0].ArticleId)
+		if err != nil {
+			t.Fatalf("GetArticleById: %s", err)
+		}
+		if found.FavoritesCount != 1 {
+			t.Errorf("expected FavoritesCount 1, got %d", found.FavoritesCount)
+		}
+
+		isFavorited, err := repo.IsArticleFavoritedByUser(&fan, articles)
+		if err != nil {
+			t.Fatalf("IsArticleFavoritedByUser: %s", err)
+		}
+		if len(isFavorited) != 2 || !isFavorited[0] || isFavorited[1] {
+			t.Errorf("expected [true false], got %v", isFavorited)
+		}
+
+		favorites, err := repo.GetFavoriteArticlesByUsername(fan.Username, 0, 10)
+		if err != nil {
+			t.Fatalf("GetFavoriteArticlesByUsername: %s", err)
+		}
+		if len(favorites) != 1 || favorites[0].ArticleId != articles[0].ArticleId {
+			t.Errorf("expected the favorited article only, got %v", articleIds(favorites))
+		}
+
+		for i := 0; i < 2; i++ {
+			changed, err := repo.UnfavoriteArticle(fan.Username, articles[0].ArticleId)
+			if err != nil {
+				t.Fatalf("UnfavoriteArticle: %s", err)
+			}
+			if changed != (i == 0) {
+				t.Errorf("unfavorite #%d: expected changed=%v", i+1, i == 0)
+			}
+		}
+
+		found, err = repo.GetArticleById(articles[0].ArticleId)
+		if err != nil {
+			t.Fatalf("GetArticleById: %s", err)
+		}
+		if found.FavoritesCount != 0 {
+			t.Errorf("expected FavoritesCount 0, got %d", found.FavoritesCount)
+		}
+	})
+
+	t.Run("Only published articles are listed, tagged and fed", func(t *testing.T) {
+		repo, follows := newRepositories()
+		author, reader, tag := name("kim"), name("kim-reader"), name("drafts")
+		if err := follows.Follow(entities.Follow{Follower: reader, Publisher: author}); err != nil {
+			t.Fatalf("Follow: %s", err)
+		}
+
+		published := putArticles(t, repo, 1, author, tag)[0]
+
+		draft := newArticle(author, tag)
+		draft.Status = entities.ArticleStatusDraft
+		if err := repo.PutArticle(draft); err != nil {
+			t.Fatalf("PutArticle: %s", err)
+		}
+
+		scheduled := newArticle(author, tag)
+		scheduled.Status = entities.ArticleStatusScheduled
+		scheduled.PublishAt = clock
+		if err := repo.PutArticle(scheduled); err != nil {
+			t.Fatalf("PutArticle: %s", err)
+		}
+
+		assertListed := func(t *testing.T, expected ...entities.Article) {
+			t.Helper()
+			byAuthor, err := repo.GetArticlesByAuthor(author, 0, 10)
+			if err != nil {
+				t.Fatalf("GetArticlesByAuthor: %s", err)
+			}
+			assertArticles(t, byAuthor, expected...)
+
+			byTag, err := repo.GetArticlesByTag(tag, 0, 10)
+			if err != nil {
+				t.Fatalf("GetArticlesByTag: %s", err)
+			}
+			assertArticles(t, byTag, expected...)
+
+			feed, _, err := repo.GetFeedPage(reader, "", 10)
+			if err != nil {
+				t.Fatalf("GetFeedPage: %s", err)
+			}
+			assertArticles(t, feed, expected...)
+		}
+		assertListed(t, published)
+
+		unpublished, _, err := repo.GetUnpublishedArticlesByAuthorPage(author, "", 10)
+		if err != nil {
+			t.Fatalf("GetUnpublishedArticlesByAuthorPage: %s", err)
+		}
+		assertArticles(t, unpublished, *scheduled, *draft)
+
+		due, err := repo.GetScheduledArticles(scheduled.PublishAt, 100)
+		if err != nil {
+			t.Fatalf("GetScheduledArticles: %s", err)
+		}
+		if !containsArticle(due, scheduled.ArticleId) || containsArticle(due, draft.ArticleId) {
+			t.Errorf("expected the scheduled article to be due, got %v", articleIds(due))
+		}
+		early, err := repo.GetScheduledArticles(scheduled.PublishAt-1, 100)
+		if err != nil {
+			t.Fatalf("GetScheduledArticles: %s", err)
+		}
+		if containsArticle(early, scheduled.ArticleId) {
+			t.Errorf("the scheduled article must not be due before PublishAt")
+		}
+
+		// Publishing the draft lists it at its publication time
+		clock += int64(time.Millisecond)
+		publishedDraft := *draft
+		publishedDraft.Status = entities.ArticleStatusPublished
+		publishedDraft.CreatedAt = clock
+		publishedDraft.UpdatedAt = clock
+		if err := repo.UpdateArticle(*draft, publishedDraft); err != nil {
+			t.Fatalf("UpdateArticle: %s", err)
+		}
+		assertListed(t, publishedDraft, published)
+
+		// Archiving takes it out of the listings again
+		archived := published
+		archived.Status = entities.ArticleStatusArchived
+		archived.UpdatedAt++
+		if err := repo.UpdateArticle(published, archived); err != nil {
+			t.Fatalf("UpdateArticle: %s", err)
+		}
+		assertListed(t, publishedDraft)
+	})
+
+	t.Run("Revisions are stored with every save", func(t *testing.T) {
+		repo, _ := newRepositories()
+		a := newArticle(name("lou"))
+		a.Revision, a.UpdatedBy = 1, a.Author
+		if err := repo.PutArticle(a); err != nil {
+			t.Fatalf("PutArticle: %s", err)
+		}
+
+		edited := *a
+		edited.Body = "edited body"
+		edited.UpdatedAt++
+		edited.UpdatedBy = name("editor")
+		edited.Revision = 2
+		if err := repo.UpdateArticle(*a, edited); err != nil {
+			t.Fatalf("UpdateArticle: %s", err)
+		}
+
+		revisions, next, err := repo.GetArticleRevisionsPage(a.ArticleId, "", 1)
+		if err != nil {
+			t.Fatalf("GetArticleRevisionsPage: %s", err)
+		}
+		if len(revisions) != 1 || revisions[0].Revision != 2 || revisions[0].Editor != edited.UpdatedBy || next == "" {
+			t.Fatalf("expected the newest revision and a cursor, got %+v %q", revisions, next)
+		}
+		revisions, next, err = repo.GetArticleRevisionsPage(a.ArticleId, next, 1)
+		if err != nil {
+			t.Fatalf("GetArticleRevisionsPage: %s", err)
+		}
+		if len(revisions) != 1 || revisions[0].Revision != 1 || revisions[0].Body != a.Body || next != "" {
+			t.Fatalf("expected the first revision and no cursor, got %+v %q", revisions, next)
+		}
+
+		if err := repo.DeleteArticle(edited); err != nil {
+			t.Fatalf("DeleteArticle: %s", err)
+		}
+		if _, err := repo.GetArticleRevision(a.ArticleId, 1); !isNotFound(err) {
+			t.Errorf("expected the revisions to be deleted with the article, got %v", err)
+		}
+	})
+
+	t.Run("GetFeed merges followed authors newest first", func(t *testing.T) {
+		repo, follows := newRepositories()
+		reader := name("reader")
+		for _, publisher := range []string{name("heidi"), name("ivan")} {
+			if err := follows.Follow(entities.Follow{Follower: reader, Publisher: publisher}); err != nil {
+				t.Fatalf("Follow: %s", err)
+			}
+		}
+
+		// Interleave the authors, and add one nobody follows
+		var expected []entities.Article
+		for i := 0; i < 3; i++ {
+			expected = append(expected, putArticles(t, repo, 1, name("heidi"))...)
+			putArticles(t, repo, 1, name("judy"))
+			expected = append(expected, putArticles(t, repo, 1, name("ivan"))...)
+		}
+
+		feed, err := repo.GetFeed(reader, 0, 10)
+		if err != nil {
+			t.Fatalf("GetFeed: %s", err)
+		}
+		assertArticles(t, feed, expected[5], expected[4], expected[3], expected[2], expected[1], expected[0])
+
+		page, err := repo.GetFeed(reader, 2, 3)
+		if err != nil {
+			t.Fatalf("GetFeed: %s", err)
+		}
+		assertArticles(t, page, expected[3], expected[2], expected[1])
+	})
+}
+
+func assertArticles(t *testing.T, got []entities.Article, expected ...entities.Article) {
+	t.Helper()
+	if len(got) != len(expected) {
+		t.Fatalf("expected articles %v, got %v", articleIds(expected), articleIds(got))
+	}
+	for i := range expected {
+		if got[i].ArticleId != expected[i].ArticleId {
+			t.Fatalf("expected articles %v, got %v", articleIds(expected), articleIds(got))
+		}
+	}
+}
+
+func articleIds(articles []entities.Article) []int64 {
+	ids := make([]int64, 0, len(articles))
+	for _, a := range articles {
+		ids = append(ids, a.ArticleId)
+	}
+	return ids
+}
+
+func containsArticle(articles []entities.Article, articleId int64) bool {
+	for _, a := range articles {
+		if a.ArticleId == articleId {
+			return true
+		}
+	}
+	return false
+}
+
+func isNotFound(err error) bool {
+	_, ok := err.(entities.NotFoundError)
+	return ok
+}
@@ -0,0 +1,285 @@
+package article
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/cursor"
+	"github.com/ferjmc/cms/internal/dynamo"
+	"github.com/ferjmc/cms/internal/timeline"
+)
+
+// DynamoDB doesn't support batch queries, the feed queries every followed author
+// https://stackoverflow.com/questions/24953783/dynamodb-batch-execute-queryrequests
+const maxConcurrentFeedQueries = 8
+const feedFirstPageSize = 10
+const feedMaxPageSize = 100
+
+// feedPageQuery reads at most pageSize articles of a stream, like the articles of a followed author,
+// newest first, starting after startKey. With a boundary, only articles placed after it are returned.
+// The returned key is empty once the stream has no more articles.
+type feedPageQuery func(ctx context.Context, startKey dynamo.AWSObject, boundary *cursor.Position, pageSize int) ([]entities.Article, dynamo.AWSObject, error)
+
+// feedStream holds the articles of a stream read so far
+type feedStream struct {
+	query     feedPageQuery
+	articles  []entities.Article
+	startKey  dynamo.AWSObject
+	exhausted bool
+	// last is the position of the last article taken from the stream,
+	// every article still to be read is placed after it
+	last    cursor.Position
+	hasLast bool
+}
+
+func (s *feedStream) head() (cursor.Position, bool) {
+	if len(s.articles) == 0 {
+		return cursor.Position{}, false
+	}
+	return cursor.Position{Key: s.articles[0].CreatedAt, Id: s.articles[0].ArticleId}, true
+}
+
+// feedMerger merges many streams of articles newest first. Streams are read
+// lazily, a page is only queried when its articles may be part of the result,
+// and the merge stops as soon as enough articles are final.
+type feedMerger struct {
+	boundary *cursor.Position
+}
+
+// merge skips the first skip articles of the feed and returns the next limit ones
+func (f *feedMerger) merge(ctx context.Context, queries []feedPageQuery, skip, limit int) ([]entities.Article, error) {
+	streams := make([]*feedStream, 0, len(queries))
+	for _, query := range queries {
+		streams = append(streams, &feedStream{query: query})
+	}
+
+	needed := skip + limit
+	merged := make([]entities.Article, 0, limit)
+	pageSize := minInt(needed, feedFirstPageSize)
+
+	for taken := 0; taken < needed; {
+		// The newest article already read
+		var best *feedStream
+		var bestHead cursor.Position
+		for _, s := range streams {
+			if head, ok := s.head(); ok && (best == nil || head.After(bestHead.Key, bestHead.Id)) {
+				best, bestHead = s, head
+			}
+		}
+
+		// It is final unless a stream with nothing buffered may still have a newer one
+		refill := make([]*feedStream, 0)
+		for _, s := range streams {
+			if len(s.articles) > 0 || s.exhausted {
+				continue
+			}
+			if best == nil || !s.hasLast || s.last.After(bestHead.Key, bestHead.Id) {
+				refill = append(refill, s)
+			}
+		}
+
+		if len(refill) > 0 {
+			err := f.fetch(ctx, refill, pageSize)
+			if err != nil {
+				return nil, err
+			}
+			pageSize = minInt(needed-taken, feedMaxPageSize)
+			continue
+		}
+
+		if best == nil {
+			break
+		}
+
+		article := best.articles[0]
+		best.articles = best.articles[1:]
+		best.last, best.hasLast = bestHead, true
+
+		if taken >= skip {
+			merged = append(merged, article)
+		}
+		taken++
+	}
+
+	return merged, nil
+}
+
+// fetch reads the next page of every stream with a bounded number of concurrent
+// queries, the first error cancels the queries still running
+func (f *feedMerger) fetch(ctx context.Context, streams []*feedStream, pageSize int) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan *feedStream)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+
+	for i := 0; i < minInt(maxConcurrentFeedQueries, len(streams)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for s := range jobs {
+				articles, nextKey, err := s.query(ctx, s.startKey, f.boundary, pageSize)
+				if err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+					continue
+				}
+
+				s.articles = append(s.articles, articles...)
+				s.startKey = nextKey
+				s.exhausted = len(nextKey) == 0
+			}
+		}()
+	}
+
+dispatch:
+	for _, s := range streams {
+		select {
+		case jobs <- s:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// feedQueries returns the streams making the feed of username. Without timeline, every followed
+// author is read. With timeline, the timeline of username is read along with the followed popular
+// authors, whose articles which were not fanned out are missing from it.
+func feedQueries(ctx context.Context, username string) ([]feedPageQuery, error) {
+	publishers, err := getPublishers(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if !timeline.Enabled() {
+		return authorQueries(publishers, false), nil
+	}
+
+	popular, err := timeline.PopularPublishers(ctx, publishers)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(authorQueries(popular, true), timelineQuery(username)), nil
+}
+
+func authorQueries(authors []string, onlyNotFannedOut bool) []feedPageQuery {
+	queries := make([]feedPageQuery, 0, len(authors))
+	for _, author := range authors {
+		author := author
+		queries = append(queries, func(ctx context.Context, startKey dynamo.AWSObject, boundary *cursor.Position, pageSize int) ([]entities.Article, dynamo.AWSObject, error) {
+			return queryFeedPage(ctx, author, onlyNotFannedOut, startKey, boundary, pageSize)
+		})
+	}
+	return queries
+}
+
+func timelineQuery(follower string) feedPageQuery {
+	return func(ctx context.Context, startKey dynamo.AWSObject, boundary *cursor.Position, pageSize int) ([]entities.Article, dynamo.AWSObject, error) {
+		entries, nextKey, err := timeline.Query(ctx, follower, startKey, boundary, pageSize)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		articleIds := make([]int64, 0, len(entries))
+		for _, entry := range entries {
+			articleIds = append(articleIds, entry.ArticleId)
+		}
+
+		// Deleted articles may still have entries, they are skipped
+		articles, err := (&dynamoRepository{}).GetArticlesByArticleIds(articleIds, len(articleIds))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return articles, nextKey, nil
+	}
+}
+
+func queryFeedPage(ctx context.Context, author string, onlyNotFannedOut bool, startKey dynamo.AWSObject, boundary *cursor.Position, pageSize int) ([]entities.Article, dynamo.AWSObject, error) {
+	queryArticles := authorArticlesQuery(author)
+	queryArticles.Limit = aws.Int64(int64(pageSize))
+	if onlyNotFannedOut {
+		queryArticles.FilterExpression = aws.String(publishedFilter + " AND (attribute_not_exists(FannedOut) OR FannedOut=:false)")
+		queryArticles.ExpressionAttributeValues[":false"] = &dynamodb.AttributeValue{BOOL: aws.Bool(false)}
+	}
+	if len(startKey) > 0 {
+		queryArticles.ExclusiveStartKey = startKey
+	}
+	if boundary != nil {
+		queryArticles.KeyConditionExpression = aws.String("Author=:author AND CreatedAt<=:createdAt")
+		queryArticles.ExpressionAttributeValues[":createdAt"] = dynamo.Int64Value(boundary.Key)
+	}
+
+	output, err := dynamo.DynamoDB().QueryWithContext(ctx, &queryArticles)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	articles, err := unmarshalArticles(output.Items)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if boundary != nil {
+		// Articles sharing the boundary CreatedAt may have been returned already
+		articles = articlesAfter(articles, true, *boundary, len(articles))
+	}
+
+	return articles, output.LastEvaluatedKey, nil
+}
+
+// getPublishers pages through every follow of username, however many there are
+func getPublishers(ctx context.Context, username string) ([]string, error) {
+	queryPublishers := dynamodb.QueryInput{
+		TableName:                 aws.String(dynamo.FollowTableName),
+		KeyConditionExpression:    aws.String("Follower=:username"),
+		ExpressionAttributeValues: dynamo.StringKey(":username", username),
+		ProjectionExpression:      aws.String("Publisher"),
+	}
+
+	publishers := make([]string, 0)
+	var unmarshalErr error
+
+	err := dynamo.DynamoDB().QueryPagesWithContext(ctx, &queryPublishers, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		follows := make([]entities.Follow, 0, len(page.Items))
+		unmarshalErr = dynamodbattribute.UnmarshalListOfMaps(page.Items, &follows)
+		if unmarshalErr != nil {
+			return false
+		}
+
+		for _, follow := range follows {
+			publishers = append(publishers, follow.Publisher)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if unmarshalErr != nil {
+		return nil, unmarshalErr
+	}
+
+	return publishers, nil
+}
+
+func minInt(x, y int) int {
+	if x < y {
+		return x
+	}
+	return y
+}
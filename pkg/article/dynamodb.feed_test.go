@@ -0,0 +1,190 @@
+package article
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/cursor"
+	"github.com/ferjmc/cms/internal/dynamo"
+)
+
+// fakeFeed serves newest first pages of in-memory articles per author
+type fakeFeed struct {
+	articles map[string][]entities.Article
+	queries  int32
+	fail     string
+}
+
+func (f *fakeFeed) query(ctx context.Context, author string, startKey dynamo.AWSObject, boundary *cursor.Position, pageSize int) ([]entities.Article, dynamo.AWSObject, error) {
+	atomic.AddInt32(&f.queries, 1)
+	if author == f.fail {
+		return nil, nil, errors.New("query failed")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	start := 0
+	if startKey != nil {
+		start, _ = strconv.Atoi(*startKey["i"].N)
+	}
+
+	all := f.articles[author]
+	end := minInt(start+pageSize, len(all))
+	page := make([]entities.Article, 0, end-start)
+	for _, article := range all[start:end] {
+		if boundary == nil || boundary.After(article.CreatedAt, article.ArticleId) {
+			page = append(page, article)
+		}
+	}
+
+	if end == len(all) {
+		return page, nil, nil
+	}
+	return page, dynamo.IntKey("i", end), nil
+}
+
+func (f *fakeFeed) streams(authors []string) []feedPageQuery {
+	queries := make([]feedPageQuery, 0, len(authors))
+	for _, author := range authors {
+		author := author
+		queries = append(queries, func(ctx context.Context, startKey dynamo.AWSObject, boundary *cursor.Position, pageSize int) ([]entities.Article, dynamo.AWSObject, error) {
+			return f.query(ctx, author, startKey, boundary, pageSize)
+		})
+	}
+	return queries
+}
+
+func newFakeFeed(numAuthors, numArticles int) (*fakeFeed, []string, []entities.Article) {
+	random := rand.New(rand.NewSource(1))
+	feed := &fakeFeed{articles: make(map[string][]entities.Article)}
+	authors := make([]string, 0, numAuthors)
+	for i := 0; i < numAuthors; i++ {
+		authors = append(authors, "author"+strconv.Itoa(i))
+	}
+
+	all := make([]entities.Article, 0, numArticles)
+	for i := 0; i < numArticles; i++ {
+		article := entities.Article{
+			ArticleId: int64(i + 1),
+			// Few distinct timestamps, so ties are broken by ArticleId
+			CreatedAt: int64(random.Intn(numArticles / 2)),
+			Author:    authors[random.Intn(numAuthors)],
+		}
+		feed.articles[article.Author] = append(feed.articles[article.Author], article)
+		all = append(all, article)
+	}
+
+	newestFirst := func(articles []entities.Article) {
+		sort.Slice(articles, func(i, j int) bool {
+			if articles[i].CreatedAt != articles[j].CreatedAt {
+				return articles[i].CreatedAt > articles[j].CreatedAt
+			}
+			return articles[i].ArticleId > articles[j].ArticleId
+		})
+	}
+	for _, articles := range feed.articles {
+		newestFirst(articles)
+	}
+	newestFirst(all)
+
+	return feed, authors, all
+}
+
+func TestFeedMergerMatchesSortedArticles(t *testing.T) {
+	feed, authors, all := newFakeFeed(30, 400)
+	merger := feedMerger{}
+
+	for _, window := range [][2]int{{0, 20}, {7, 13}, {150, 100}, {390, 20}, {400, 5}} {
+		skip, limit := window[0], window[1]
+		merged, err := merger.merge(context.Background(), feed.streams(authors), skip, limit)
+		if err != nil {
+			t.Fatalf("merge(%d, %d): %s", skip, limit, err)
+		}
+
+		start, end := minInt(skip, len(all)), minInt(skip+limit, len(all))
+		expected := all[start:end]
+		if len(merged) != len(expected) {
+			t.Fatalf("merge(%d, %d): expected %d articles, got %d", skip, limit, len(expected), len(merged))
+		}
+		for i := range expected {
+			if merged[i].ArticleId != expected[i].ArticleId {
+				t.Fatalf("merge(%d, %d): article %d is %d instead of %d", skip, limit, i, merged[i].ArticleId, expected[i].ArticleId)
+			}
+		}
+	}
+}
+
+func TestFeedMergerResumesAfterBoundary(t *testing.T) {
+	feed, authors, all := newFakeFeed(10, 100)
+
+	var pages []entities.Article
+	var boundary *cursor.Position
+	for len(pages) < len(all) {
+		merger := feedMerger{boundary: boundary}
+		page, err := merger.merge(context.Background(), feed.streams(authors), 0, 7)
+		if err != nil {
+			t.Fatalf("merge: %s", err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		pages = append(pages, page...)
+		last := page[len(page)-1]
+		boundary = &cursor.Position{Key: last.CreatedAt, Id: last.ArticleId}
+	}
+
+	if len(pages) != len(all) {
+		t.Fatalf("expected %d articles, got %d", len(all), len(pages))
+	}
+	for i := range all {
+		if pages[i].ArticleId != all[i].ArticleId {
+			t.Fatalf("article %d is %d instead of %d", i, pages[i].ArticleId, all[i].ArticleId)
+		}
+	}
+}
+
+func TestFeedMergerStopsEarly(t *testing.T) {
+	// One prolific author and many quiet ones, the first page needs few queries
+	feed := &fakeFeed{articles: make(map[string][]entities.Article)}
+	authors := []string{"prolific"}
+	for i := 0; i < 1000; i++ {
+		feed.articles["prolific"] = append(feed.articles["prolific"], entities.Article{ArticleId: int64(i + 1), CreatedAt: int64(10000 - i)})
+	}
+	for i := 0; i < 20; i++ {
+		author := "quiet" + strconv.Itoa(i)
+		authors = append(authors, author)
+		feed.articles[author] = []entities.Article{{ArticleId: int64(2000 + i), CreatedAt: int64(i)}}
+	}
+
+	merger := feedMerger{}
+	merged, err := merger.merge(context.Background(), feed.streams(authors), 0, 20)
+	if err != nil {
+		t.Fatalf("merge: %s", err)
+	}
+	if len(merged) != 20 || merged[19].ArticleId != 20 {
+		t.Fatalf("expected the 20 newest articles of the prolific author, got %d articles", len(merged))
+	}
+
+	// One first page per author, and one more page of the prolific author
+	if feed.queries != int32(len(authors)+1) {
+		t.Errorf("expected %d queries, got %d", len(authors)+1, feed.queries)
+	}
+}
+
+func TestFeedMergerReturnsQueryErrors(t *testing.T) {
+	feed, authors, _ := newFakeFeed(30, 100)
+	feed.fail = authors[3]
+
+	merger := feedMerger{}
+	_, err := merger.merge(context.Background(), feed.streams(authors), 0, 20)
+	if err == nil || err.Error() != "query failed" {
+		t.Fatalf("expected the query error, got %v", err)
+	}
+}
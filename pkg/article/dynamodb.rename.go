@@ -0,0 +1,146 @@
+package article
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/dynamo"
+)
+
+// The rename steps rewrite one page of rows at a time and may run again on the same page
+// after a failure, every write is conditioned on the row still naming the old username.
+
+func (d *dynamoRepository) RenameAuthor(oldUsername, newUsername, cursor string, limit int) (string, error) {
+	// Every article of the author, whatever its status
+	queryArticles := authorArticlesQuery(oldUsername)
+	queryArticles.FilterExpression = nil
+	queryArticles.ExpressionAttributeNames = nil
+	queryArticles.ExpressionAttributeValues = dynamo.StringKey(":author", oldUsername)
+
+	items, next, err := dynamo.QueryPage(&queryArticles, cursor, limit)
+	if err != nil {
+		return "", err
+	}
+
+	articles, err := unmarshalArticles(items)
+	if err != nil {
+		return "", err
+	}
+
+	for _, article := range articles {
+		updateExpression := "SET Author=:new"
+		if article.UpdatedBy == oldUsername {
+			updateExpression += ", UpdatedBy=:new"
+		}
+
+		_, err = dynamo.DynamoDB().UpdateItem(&dynamodb.UpdateItemInput{
+			TableName:           aws.String(dynamo.ArticleTableName),
+			Key:                 dynamo.Int64Key("ArticleId", article.ArticleId),
+			UpdateExpression:    aws.String(updateExpression),
+			ConditionExpression: aws.String("Author=:old"),
+			ExpressionAttributeValues: dynamo.AWSObject{
+				":old": dynamo.StringValue(oldUsername),
+				":new": dynamo.StringValue(newUsername),
+			},
+		})
+		if err != nil && !dynamo.IsConditionalCheckFailed(err) {
+			return "", err
+		}
+	}
+
+	return next, nil
+}
+
+func (d *dynamoRepository) RenameFavorites(oldUsername, newUsername, cursor string, limit int) (string, error) {
+	queryFavorites := dynamodb.QueryInput{
+		TableName:                 aws.String(dynamo.FavoriteArticleTableName),
+		KeyConditionExpression:    aws.String("Username=:username"),
+		ExpressionAttributeValues: dynamo.StringKey(":username", oldUsername),
+	}
+
+	items, next, err := dynamo.QueryPage(&queryFavorites, cursor, limit)
+	if err != nil {
+		return "", err
+	}
+
+	favorites := make([]entities.FavoriteArticle, len(items))
+	err = dynamodbattribute.UnmarshalListOfMaps(items, &favorites)
+	if err != nil {
+		return "", err
+	}
+
+	for _, favorite := range favorites {
+		err = moveFavorite(favorite, newUsername)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return next, nil
+}
+
+// moveFavorite gives favorite to newUsername, unless it already favorited the article
+func moveFavorite(favorite entities.FavoriteArticle, newUsername string) error {
+	oldKey, err := dynamodbattribute.MarshalMap(favorite.FavoriteArticleKey)
+	if err != nil {
+		return err
+	}
+
+	moved := favorite
+	moved.Username = newUsername
+	item, err := dynamodbattribute.MarshalMap(moved)
+	if err != nil {
+		return err
+	}
+
+	deleteOld := &dynamodb.TransactWriteItem{
+		Delete: &dynamodb.Delete{
+			TableName:           aws.String(dynamo.FavoriteArticleTableName),
+			Key:                 oldKey,
+			ConditionExpression: aws.String("attribute_exists(Username)"),
+		},
+	}
+
+	_, err = dynamo.DynamoDB().TransactWriteItems(&dynamodb.TransactWriteItemsInput{
+		TransactItems: []*dynamodb.TransactWriteItem{
+			deleteOld,
+			{
+				Put: &dynamodb.Put{
+					TableName:           aws.String(dynamo.FavoriteArticleTableName),
+					Item:                item,
+					ConditionExpression: aws.String("attribute_not_exists(Username)"),
+				},
+			},
+		},
+	})
+	canceled, ok := dynamo.AsTransactionCanceled(err)
+	if !ok || !canceled.AnyConditionFailed() {
+		return err
+	}
+	if canceled.ConditionFailed(0) {
+		// Already moved
+		return nil
+	}
+
+	// Favorited again under the new username, the old favorite is deleted and uncounted
+	_, err = dynamo.DynamoDB().TransactWriteItems(&dynamodb.TransactWriteItemsInput{
+		TransactItems: []*dynamodb.TransactWriteItem{
+			deleteOld,
+			{
+				Update: &dynamodb.Update{
+					TableName:                 aws.String(dynamo.ArticleTableName),
+					Key:                       dynamo.Int64Key("ArticleId", favorite.ArticleId),
+					UpdateExpression:          aws.String("ADD FavoritesCount :minusOne"),
+					ConditionExpression:       aws.String("attribute_exists(ArticleId)"),
+					ExpressionAttributeValues: dynamo.IntKey(":minusOne", -1),
+				},
+			},
+		},
+	})
+	if dynamo.IsConditionalCheckFailed(err) {
+		return nil
+	}
+
+	return err
+}
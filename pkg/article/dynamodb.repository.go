@@ -1,11 +1,18 @@
 package article
 
 import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/cursor"
 	"github.com/ferjmc/cms/internal/dynamo"
+	"github.com/ferjmc/cms/internal/timeline"
 	"github.com/ferjmc/cms/pkg/rand"
 )
 
@@ -14,11 +21,28 @@ type dynamoRepository struct{}
 func (d *dynamoRepository) PutArticle(article *entities.Article) error {
 	const maxAttempt = 5
 
+	// Only published articles are fanned out, the others are when they get published
+	fanOut := false
+	if article.Published() {
+		var err error
+		fanOut, err = timeline.ShouldFanOut(article.Author)
+		if err != nil {
+			return err
+		}
+	}
+	article.FannedOut = fanOut
+
 	// Try to find a unique article id
 	for attempt := 0; ; attempt++ {
 		err := putArticleWithRandomId(article)
 
 		if err == nil {
+			if fanOut {
+				// The article is saved, timelines missing it only lose it from the feed
+				if err := timeline.FanOut(*article); err != nil {
+					log.Printf("ERROR: fan out of article %d: %s", article.ArticleId, err)
+				}
+			}
 			return nil
 		}
 
@@ -34,16 +58,31 @@ func (d *dynamoRepository) PutArticle(article *entities.Article) error {
 	}
 }
 
+// marshalArticle leaves Dummy out of the articles which are not published,
+// so the sparse index CreatedAt only lists published articles
+func marshalArticle(article *entities.Article) (dynamo.AWSObject, error) {
+	item, err := dynamodbattribute.MarshalMap(article)
+	if err != nil {
+		return nil, err
+	}
+
+	if !article.Published() {
+		delete(item, "Dummy")
+	}
+
+	return item, nil
+}
+
 func putArticleWithRandomId(article *entities.Article) error {
 	article.ArticleId = 1 + rand.ArticleIdRand.Get().Int63n(entities.MaxArticleId-1) // range: [1, MaxArticleId)
 	article.MakeSlug()
 
-	articleItem, err := dynamodbattribute.MarshalMap(article)
+	articleItem, err := marshalArticle(article)
 	if err != nil {
 		return err
 	}
 
-	transactItems := make([]*dynamodb.TransactWriteItem, 0, 1+2*len(article.TagList))
+	transactItems := make([]*dynamodb.TransactWriteItem, 0, 3+2*len(article.TagList))
 
 	// Put a new article
 	transactItems = append(transactItems, &dynamodb.TransactWriteItem{
@@ -54,28 +93,60 @@ func putArticleWithRandomId(article *entities.Article) error {
 		},
 	})
 
-	for _, tag := range article.TagList {
-		articleTag := entities.ArticleTag{
-			Tag:       tag,
-			ArticleId: article.ArticleId,
-			CreatedAt: article.CreatedAt,
-		}
-
-		item, err := dynamodbattribute.MarshalMap(articleTag)
+	if article.Revision > 0 {
+		revisionItem, err := putRevisionItem(*article)
 		if err != nil {
 			return err
 		}
+		transactItems = append(transactItems, revisionItem)
+	}
+
+	// Tags only count published articles
+	if article.Published() {
+		for _, tag := range article.TagList {
+			items, err := linkTagItems(tag, article)
+			if err != nil {
+				return err
+			}
+
+			transactItems = append(transactItems, items...)
+		}
+
+		// Feeds read the articles which are not fanned out from the article table
+		if !article.FannedOut && timeline.Enabled() {
+			transactItems = append(transactItems, timeline.MarkPopularItem(article.Author))
+		}
+	}
+
+	_, err = dynamo.DynamoDB().TransactWriteItems(&dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
+
+	return err
+}
+
+func linkTagItems(tag string, article *entities.Article) ([]*dynamodb.TransactWriteItem, error) {
+	articleTag := entities.ArticleTag{
+		Tag:       tag,
+		ArticleId: article.ArticleId,
+		CreatedAt: article.CreatedAt,
+	}
+
+	item, err := dynamodbattribute.MarshalMap(articleTag)
+	if err != nil {
+		return nil, err
+	}
 
+	return []*dynamodb.TransactWriteItem{
 		// Link article with tag
-		transactItems = append(transactItems, &dynamodb.TransactWriteItem{
+		{
 			Put: &dynamodb.Put{
 				TableName: aws.String(dynamo.ArticleTagTableName),
 				Item:      item,
 			},
-		})
-
-		// Update article count for each tag
-		transactItems = append(transactItems, &dynamodb.TransactWriteItem{
+		},
+		// Update article count for the tag
+		{
 			Update: &dynamodb.Update{
 				TableName:        aws.String(dynamo.TagTableName),
 				Key:              dynamo.StringKey("Tag", tag),
@@ -85,33 +156,299 @@ func putArticleWithRandomId(article *entities.Article) error {
 					":zero": dynamo.IntValue(0),
 				},
 			},
-		})
+		},
+	}, nil
+}
+
+func unlinkTagItems(tag string, articleId int64) []*dynamodb.TransactWriteItem {
+	return []*dynamodb.TransactWriteItem{
+		// Unlink article from tag
+		{
+			Delete: &dynamodb.Delete{
+				TableName: aws.String(dynamo.ArticleTagTableName),
+				Key: dynamo.AWSObject{
+					"Tag":       dynamo.StringValue(tag),
+					"ArticleId": dynamo.Int64Value(articleId),
+				},
+			},
+		},
+		// Update article count for the tag
+		{
+			Update: &dynamodb.Update{
+				TableName:                 aws.String(dynamo.TagTableName),
+				Key:                       dynamo.StringKey("Tag", tag),
+				UpdateExpression:          aws.String("ADD ArticleCount :minusOne"),
+				ExpressionAttributeValues: dynamo.IntKey(":minusOne", -1),
+			},
+		},
+	}
+}
+
+func (d *dynamoRepository) UpdateArticle(oldArticle, newArticle entities.Article) error {
+	publishing := !oldArticle.Published() && newArticle.Published()
+	unpublishing := oldArticle.Published() && !newArticle.Published()
+
+	// Tags are linked to published articles only, the links follow the status
+	var addedTags, removedTags []string
+	switch {
+	case publishing:
+		addedTags = newArticle.TagList
+	case unpublishing:
+		removedTags = oldArticle.TagList
+	case newArticle.Published():
+		addedTags, removedTags = entities.DiffTags(oldArticle.TagList, newArticle.TagList)
+	}
+
+	newArticle.FannedOut = oldArticle.FannedOut
+	if publishing {
+		fanOut, err := timeline.ShouldFanOut(newArticle.Author)
+		if err != nil {
+			return err
+		}
+		newArticle.FannedOut = fanOut
+	}
+	if unpublishing {
+		newArticle.FannedOut = false
+	}
+
+	transactItems := make([]*dynamodb.TransactWriteItem, 0, 3+2*(len(addedTags)+len(removedTags)))
+
+	tagList, err := dynamodbattribute.Marshal(newArticle.TagList)
+	if err != nil {
+		return err
+	}
+
+	// Dummy puts the article in the index CreatedAt while it is published,
+	// PublishAt in the index Status while it is scheduled
+	updateExpression := "SET Slug=:slug, Title=:title, Description=:description, Body=:body, TagList=:tagList, UpdatedAt=:updatedAt, " +
+		"CreatedAt=:createdAt, #status=:status, FannedOut=:fannedOut, UpdatedBy=:updatedBy, Revision=:revision"
+	values := dynamo.AWSObject{
+		":slug":         dynamo.StringValue(newArticle.Slug),
+		":title":        dynamo.StringValue(newArticle.Title),
+		":description":  dynamo.StringValue(newArticle.Description),
+		":body":         dynamo.StringValue(newArticle.Body),
+		":tagList":      tagList,
+		":updatedAt":    dynamo.Int64Value(newArticle.UpdatedAt),
+		":createdAt":    dynamo.Int64Value(newArticle.CreatedAt),
+		":status":       dynamo.StringValue(newArticle.Status),
+		":fannedOut":    {BOOL: aws.Bool(newArticle.FannedOut)},
+		":updatedBy":    dynamo.StringValue(newArticle.UpdatedBy),
+		":revision":     dynamo.Int64Value(newArticle.Revision),
+		":oldUpdatedAt": dynamo.Int64Value(oldArticle.UpdatedAt),
+	}
+	removed := make([]string, 0, 2)
+	if newArticle.Published() {
+		updateExpression += ", Dummy=:zero"
+		values[":zero"] = dynamo.IntValue(0)
+	} else {
+		removed = append(removed, "Dummy")
+	}
+	if newArticle.Status == entities.ArticleStatusScheduled {
+		updateExpression += ", PublishAt=:publishAt"
+		values[":publishAt"] = dynamo.Int64Value(newArticle.PublishAt)
+	} else {
+		removed = append(removed, "PublishAt")
+	}
+	if len(removed) > 0 {
+		updateExpression += " REMOVE " + strings.Join(removed, ", ")
+	}
+
+	// Update the editable fields only, FavoritesCount may be changing concurrently.
+	// UpdatedAt guards the tag diff against a concurrent edit.
+	transactItems = append(transactItems, &dynamodb.TransactWriteItem{
+		Update: &dynamodb.Update{
+			TableName:                 aws.String(dynamo.ArticleTableName),
+			Key:                       dynamo.Int64Key("ArticleId", oldArticle.ArticleId),
+			UpdateExpression:          aws.String(updateExpression),
+			ConditionExpression:       aws.String("UpdatedAt=:oldUpdatedAt"),
+			ExpressionAttributeNames:  map[string]*string{"#status": aws.String("Status")},
+			ExpressionAttributeValues: values,
+		},
+	})
+
+	if newArticle.Revision != oldArticle.Revision {
+		revisionItem, err := putRevisionItem(newArticle)
+		if err != nil {
+			return err
+		}
+		transactItems = append(transactItems, revisionItem)
+	}
+
+	for _, tag := range addedTags {
+		items, err := linkTagItems(tag, &newArticle)
+		if err != nil {
+			return err
+		}
+
+		transactItems = append(transactItems, items...)
+	}
+
+	for _, tag := range removedTags {
+		transactItems = append(transactItems, unlinkTagItems(tag, oldArticle.ArticleId)...)
+	}
+
+	if publishing && !newArticle.FannedOut && timeline.Enabled() {
+		transactItems = append(transactItems, timeline.MarkPopularItem(newArticle.Author))
 	}
 
 	_, err = dynamo.DynamoDB().TransactWriteItems(&dynamodb.TransactWriteItemsInput{
 		TransactItems: transactItems,
 	})
+	if dynamo.IsConditionalCheckFailed(err) {
+		return entities.NewConflictError("article", "has been modified or deleted, please retry")
+	}
+	if err != nil {
+		return err
+	}
 
-	return err
+	if publishing && newArticle.FannedOut {
+		if err := timeline.FanOut(newArticle); err != nil {
+			log.Printf("ERROR: fan out of article %d: %s", newArticle.ArticleId, err)
+		}
+	}
+	if unpublishing && oldArticle.FannedOut {
+		if err := timeline.Remove(oldArticle); err != nil {
+			log.Printf("ERROR: removal of article %d from timelines: %s", oldArticle.ArticleId, err)
+		}
+	}
+
+	return nil
 }
 
-func (d *dynamoRepository) GetAllArticles(offset, limit int) ([]entities.Article, error) {
-	queryArticles := dynamodb.QueryInput{
+func (d *dynamoRepository) DeleteArticle(article entities.Article) error {
+	transactItems := make([]*dynamodb.TransactWriteItem, 0, 1+2*len(article.TagList))
+
+	transactItems = append(transactItems, &dynamodb.TransactWriteItem{
+		Delete: &dynamodb.Delete{
+			TableName:                 aws.String(dynamo.ArticleTableName),
+			Key:                       dynamo.Int64Key("ArticleId", article.ArticleId),
+			ConditionExpression:       aws.String("UpdatedAt=:updatedAt"),
+			ExpressionAttributeValues: dynamo.Int64Key(":updatedAt", article.UpdatedAt),
+		},
+	})
+
+	// Only published articles are linked to their tags
+	if article.Published() {
+		for _, tag := range article.TagList {
+			transactItems = append(transactItems, unlinkTagItems(tag, article.ArticleId)...)
+		}
+	}
+
+	_, err := dynamo.DynamoDB().TransactWriteItems(&dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
+	if dynamo.IsConditionalCheckFailed(err) {
+		return entities.NewConflictError("article", "has been modified or deleted, please retry")
+	}
+	if err != nil {
+		return err
+	}
+
+	// Revisions of a deleted article are never read again, leftovers only waste space
+	if err := deleteRevisions(article.ArticleId); err != nil {
+		log.Printf("ERROR: removal of the revisions of article %d: %s", article.ArticleId, err)
+	}
+
+	if article.FannedOut {
+		// Feeds skip deleted articles, leftover entries only waste space
+		if err := timeline.Remove(article); err != nil {
+			log.Printf("ERROR: removal of article %d from timelines: %s", article.ArticleId, err)
+		}
+	}
+
+	return nil
+}
+
+func (d *dynamoRepository) GetArticleById(articleId int64) (*entities.Article, error) {
+	var article entities.Article
+	found, err := dynamo.GetItemByKey(dynamo.ArticleTableName, dynamo.Int64Key("ArticleId", articleId), &article)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, errArticleNotFound()
+	}
+
+	return &article, nil
+}
+
+func allArticlesQuery() dynamodb.QueryInput {
+	return dynamodb.QueryInput{
 		TableName:                 aws.String(dynamo.ArticleTableName),
 		IndexName:                 aws.String("CreatedAt"),
 		KeyConditionExpression:    aws.String("Dummy=:zero"),
 		ExpressionAttributeValues: dynamo.IntKey(":zero", 0),
-		Limit:                     aws.Int64(int64(offset + limit)),
 		ScanIndexForward:          aws.Bool(false),
 	}
+}
 
-	items, err := dynamo.QueryItems(&queryArticles, offset, limit)
-	if err != nil {
-		return nil, err
+// publishedFilter keeps the published articles, the articles written before statuses have none
+const publishedFilter = "(attribute_not_exists(#status) OR #status=:published)"
+
+// authorArticlesQuery reads the published articles of author, the index Author holds them all
+func authorArticlesQuery(author string) dynamodb.QueryInput {
+	return dynamodb.QueryInput{
+		TableName:                aws.String(dynamo.ArticleTableName),
+		IndexName:                aws.String("Author"),
+		KeyConditionExpression:   aws.String("Author=:author"),
+		FilterExpression:         aws.String(publishedFilter),
+		ExpressionAttributeNames: map[string]*string{"#status": aws.String("Status")},
+		ExpressionAttributeValues: dynamo.AWSObject{
+			":author":    dynamo.StringValue(author),
+			":published": dynamo.StringValue(entities.ArticleStatusPublished),
+		},
+		ScanIndexForward: aws.Bool(false),
+	}
+}
+
+// unpublishedArticlesQuery reads the drafts, scheduled and archived articles of author
+func unpublishedArticlesQuery(author string) dynamodb.QueryInput {
+	queryArticles := authorArticlesQuery(author)
+	queryArticles.FilterExpression = aws.String("attribute_exists(#status) AND #status<>:published")
+	return queryArticles
+}
+
+// scheduledArticlesQuery reads the scheduled articles due at until, oldest first,
+// from the index Status keyed by Status and PublishAt
+func scheduledArticlesQuery(until int64) dynamodb.QueryInput {
+	return dynamodb.QueryInput{
+		TableName:                aws.String(dynamo.ArticleTableName),
+		IndexName:                aws.String("Status"),
+		KeyConditionExpression:   aws.String("#status=:scheduled AND PublishAt<=:until"),
+		ExpressionAttributeNames: map[string]*string{"#status": aws.String("Status")},
+		ExpressionAttributeValues: dynamo.AWSObject{
+			":scheduled": dynamo.StringValue(entities.ArticleStatusScheduled),
+			":until":     dynamo.Int64Value(until),
+		},
+	}
+}
+
+func tagArticleIdsQuery(tag string) dynamodb.QueryInput {
+	return dynamodb.QueryInput{
+		TableName:                 aws.String(dynamo.ArticleTagTableName),
+		IndexName:                 aws.String("CreatedAt"),
+		KeyConditionExpression:    aws.String("Tag=:tag"),
+		ExpressionAttributeValues: dynamo.StringKey(":tag", tag),
+		ScanIndexForward:          aws.Bool(false),
+		ProjectionExpression:      aws.String("ArticleId"),
+	}
+}
+
+func favoriteArticleIdsQuery(username string) dynamodb.QueryInput {
+	return dynamodb.QueryInput{
+		TableName:                 aws.String(dynamo.FavoriteArticleTableName),
+		IndexName:                 aws.String("FavoritedAt"),
+		KeyConditionExpression:    aws.String("Username=:username"),
+		ExpressionAttributeValues: dynamo.StringKey(":username", username),
+		ScanIndexForward:          aws.Bool(false),
+		ProjectionExpression:      aws.String("ArticleId"),
 	}
+}
 
+func unmarshalArticles(items []dynamo.AWSObject) ([]entities.Article, error) {
 	articles := make([]entities.Article, len(items))
-	err = dynamodbattribute.UnmarshalListOfMaps(items, &articles)
+	err := dynamodbattribute.UnmarshalListOfMaps(items, &articles)
 	if err != nil {
 		return nil, err
 	}
@@ -119,41 +456,131 @@ func (d *dynamoRepository) GetAllArticles(offset, limit int) ([]entities.Article
 	return articles, nil
 }
 
-func (d *dynamoRepository) GetArticlesByAuthor(author string, offset, limit int) ([]entities.Article, error) {
-	queryArticles := dynamodb.QueryInput{
-		TableName:                 aws.String(dynamo.ArticleTableName),
-		IndexName:                 aws.String("Author"),
-		KeyConditionExpression:    aws.String("Author=:author"),
-		ExpressionAttributeValues: dynamo.StringKey(":author", author),
-		Limit:                     aws.Int64(int64(offset + limit)),
-		ScanIndexForward:          aws.Bool(false),
+func unmarshalArticleIds(items []dynamo.AWSObject) ([]int64, error) {
+	// Both ArticleTag and FavoriteArticle items hold an ArticleId
+	articleTags := make([]entities.ArticleTag, len(items))
+	err := dynamodbattribute.UnmarshalListOfMaps(items, &articleTags)
+	if err != nil {
+		return nil, err
+	}
+
+	articleIds := make([]int64, 0, len(items))
+	for _, articleTag := range articleTags {
+		articleIds = append(articleIds, articleTag.ArticleId)
 	}
 
+	return articleIds, nil
+}
+
+func (d *dynamoRepository) GetAllArticles(offset, limit int) ([]entities.Article, error) {
+	queryArticles := allArticlesQuery()
+	queryArticles.Limit = aws.Int64(int64(offset + limit))
+
 	items, err := dynamo.QueryItems(&queryArticles, offset, limit)
 	if err != nil {
 		return nil, err
 	}
 
-	articles := make([]entities.Article, len(items))
-	err = dynamodbattribute.UnmarshalListOfMaps(items, &articles)
+	return unmarshalArticles(items)
+}
+
+func (d *dynamoRepository) GetAllArticlesPage(cursor string, limit int) ([]entities.Article, string, error) {
+	queryArticles := allArticlesQuery()
+
+	items, next, err := dynamo.QueryPage(&queryArticles, cursor, limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	articles, err := unmarshalArticles(items)
+	return articles, next, err
+}
+
+func (d *dynamoRepository) GetArticlesByAuthor(author string, offset, limit int) ([]entities.Article, error) {
+	queryArticles := authorArticlesQuery(author)
+	queryArticles.Limit = aws.Int64(int64(offset + limit))
+
+	items, err := dynamo.QueryItems(&queryArticles, offset, limit)
 	if err != nil {
 		return nil, err
 	}
 
-	return articles, nil
+	return unmarshalArticles(items)
 }
 
-func GetArticleIdsByTag(tag string, offset, limit int) ([]int64, error) {
-	queryArticleIds := dynamodb.QueryInput{
-		TableName:                 aws.String(dynamo.ArticleTagTableName),
-		IndexName:                 aws.String("CreatedAt"),
-		KeyConditionExpression:    aws.String("Tag=:tag"),
-		ExpressionAttributeValues: dynamo.StringKey(":tag", tag),
-		Limit:                     aws.Int64(int64(offset + limit)),
-		ScanIndexForward:          aws.Bool(false),
-		ProjectionExpression:      aws.String("ArticleId"),
+func (d *dynamoRepository) GetArticlesByAuthorPage(author, cursor string, limit int) ([]entities.Article, string, error) {
+	queryArticles := authorArticlesQuery(author)
+
+	items, next, err := dynamo.QueryPage(&queryArticles, cursor, limit)
+	if err != nil {
+		return nil, "", err
 	}
 
+	articles, err := unmarshalArticles(items)
+	return articles, next, err
+}
+
+func (d *dynamoRepository) GetUnpublishedArticlesByAuthorPage(author, cursor string, limit int) ([]entities.Article, string, error) {
+	queryArticles := unpublishedArticlesQuery(author)
+
+	items, next, err := dynamo.QueryPage(&queryArticles, cursor, limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	articles, err := unmarshalArticles(items)
+	return articles, next, err
+}
+
+func (d *dynamoRepository) GetScheduledArticles(until int64, limit int) ([]entities.Article, error) {
+	queryArticles := scheduledArticlesQuery(until)
+
+	items, _, err := dynamo.QueryPage(&queryArticles, "", limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return unmarshalArticles(items)
+}
+
+func (d *dynamoRepository) GetArticlesByTagPage(tag, cursor string, limit int) ([]entities.Article, string, error) {
+	queryArticleIds := tagArticleIdsQuery(tag)
+
+	items, next, err := dynamo.QueryPage(&queryArticleIds, cursor, limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	articleIds, err := unmarshalArticleIds(items)
+	if err != nil {
+		return nil, "", err
+	}
+
+	articles, err := d.GetArticlesByArticleIds(articleIds, limit)
+	return articles, next, err
+}
+
+func (d *dynamoRepository) GetFavoriteArticlesByUsernamePage(username, cursor string, limit int) ([]entities.Article, string, error) {
+	queryArticleIds := favoriteArticleIdsQuery(username)
+
+	items, next, err := dynamo.QueryPage(&queryArticleIds, cursor, limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	articleIds, err := unmarshalArticleIds(items)
+	if err != nil {
+		return nil, "", err
+	}
+
+	articles, err := d.GetArticlesByArticleIds(articleIds, limit)
+	return articles, next, err
+}
+
+func GetArticleIdsByTag(tag string, offset, limit int) ([]int64, error) {
+	queryArticleIds := tagArticleIdsQuery(tag)
+	queryArticleIds.Limit = aws.Int64(int64(offset + limit))
+
 	items, err := dynamo.QueryItems(&queryArticleIds, offset, limit)
 	if err != nil {
 		return nil, err
@@ -184,15 +611,8 @@ func (d *dynamoRepository) GetArticlesByTag(tag string, offset, limit int) ([]en
 }
 
 func GetFavoriteArticleIdsByUsername(username string, offset, limit int) ([]int64, error) {
-	queryArticleIds := dynamodb.QueryInput{
-		TableName:                 aws.String(dynamo.FavoriteArticleTableName),
-		IndexName:                 aws.String("FavoritedAt"),
-		KeyConditionExpression:    aws.String("Username=:username"),
-		ExpressionAttributeValues: dynamo.StringKey(":username", username),
-		Limit:                     aws.Int64(int64(offset + limit)),
-		ScanIndexForward:          aws.Bool(false),
-		ProjectionExpression:      aws.String("ArticleId"),
-	}
+	queryArticleIds := favoriteArticleIdsQuery(username)
+	queryArticleIds.Limit = aws.Int64(int64(offset + limit))
 
 	items, err := dynamo.QueryItems(&queryArticleIds, offset, limit)
 	if err != nil {
@@ -247,6 +667,7 @@ func (d *dynamoRepository) GetArticlesByArticleIds(articleIds []int64, limit int
 	}
 
 	articles := make([]entities.Article, len(articleIds))
+	found := make([]bool, len(articleIds))
 	articleIdToIndex := dynamo.ReverseIndexInt64(articleIds)
 
 	for _, response := range responses {
@@ -260,11 +681,132 @@ func (d *dynamoRepository) GetArticlesByArticleIds(articleIds []int64, limit int
 
 				index := articleIdToIndex[article.ArticleId]
 				articles[index] = article
+				found[index] = true
 			}
 		}
 	}
 
-	return articles, nil
+	// Favorites and timelines may still point to deleted or unpublished articles, skip them
+	existing := articles[:0]
+	for i, article := range articles {
+		if found[i] && article.Published() {
+			existing = append(existing, article)
+		}
+	}
+
+	return existing, nil
+}
+
+func (d *dynamoRepository) FavoriteArticle(username string, articleId int64) (bool, error) {
+	favoriteArticle := entities.FavoriteArticle{
+		FavoriteArticleKey: entities.FavoriteArticleKey{
+			Username:  username,
+			ArticleId: articleId,
+		},
+		FavoritedAt: time.Now().UTC().UnixNano(),
+	}
+
+	item, err := dynamodbattribute.MarshalMap(favoriteArticle)
+	if err != nil {
+		return false, err
+	}
+
+	// Insert the favorite and count it, only if it didn't exist yet
+	transaction := dynamodb.TransactWriteItemsInput{
+		TransactItems: []*dynamodb.TransactWriteItem{
+			{
+				Put: &dynamodb.Put{
+					TableName:           aws.String(dynamo.FavoriteArticleTableName),
+					Item:                item,
+					ConditionExpression: aws.String("attribute_not_exists(Username)"),
+				},
+			},
+			{
+				Update: &dynamodb.Update{
+					TableName:                 aws.String(dynamo.ArticleTableName),
+					Key:                       dynamo.Int64Key("ArticleId", articleId),
+					UpdateExpression:          aws.String("ADD FavoritesCount :one"),
+					ConditionExpression:       aws.String("attribute_exists(ArticleId)"),
+					ExpressionAttributeValues: dynamo.IntKey(":one", 1),
+				},
+			},
+		},
+	}
+
+	_, err = dynamo.DynamoDB().TransactWriteItems(&transaction)
+	if dynamo.IsConditionalCheckFailed(err) {
+		return false, checkFavoriteConflict(favoriteArticle.FavoriteArticleKey, true)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (d *dynamoRepository) UnfavoriteArticle(username string, articleId int64) (bool, error) {
+	key := entities.FavoriteArticleKey{
+		Username:  username,
+		ArticleId: articleId,
+	}
+
+	keyItem, err := dynamodbattribute.MarshalMap(key)
+	if err != nil {
+		return false, err
+	}
+
+	// Remove the favorite and uncount it, only if it existed
+	transaction := dynamodb.TransactWriteItemsInput{
+		TransactItems: []*dynamodb.TransactWriteItem{
+			{
+				Delete: &dynamodb.Delete{
+					TableName:           aws.String(dynamo.FavoriteArticleTableName),
+					Key:                 keyItem,
+					ConditionExpression: aws.String("attribute_exists(Username)"),
+				},
+			},
+			{
+				Update: &dynamodb.Update{
+					TableName:                 aws.String(dynamo.ArticleTableName),
+					Key:                       dynamo.Int64Key("ArticleId", articleId),
+					UpdateExpression:          aws.String("ADD FavoritesCount :minusOne"),
+					ConditionExpression:       aws.String("attribute_exists(ArticleId)"),
+					ExpressionAttributeValues: dynamo.IntKey(":minusOne", -1),
+				},
+			},
+		},
+	}
+
+	_, err = dynamo.DynamoDB().TransactWriteItems(&transaction)
+	if dynamo.IsConditionalCheckFailed(err) {
+		return false, checkFavoriteConflict(key, false)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// checkFavoriteConflict finds out which condition cancelled a favorite transaction.
+// The favorite already being in the wanted state is not an error, a missing article is.
+func checkFavoriteConflict(key entities.FavoriteArticleKey, wantFavorited bool) error {
+	keyItem, err := dynamodbattribute.MarshalMap(key)
+	if err != nil {
+		return err
+	}
+
+	var favoriteArticle entities.FavoriteArticle
+	favorited, err := dynamo.GetItemByKey(dynamo.FavoriteArticleTableName, keyItem, &favoriteArticle)
+	if err != nil {
+		return err
+	}
+
+	if favorited == wantFavorited {
+		return nil
+	}
+
+	return errArticleNotFound()
 }
 
 func (d *dynamoRepository) IsArticleFavoritedByUser(user *entities.User, articles []entities.Article) ([]bool, error) {
@@ -323,40 +865,69 @@ func reverseIndexArticleIds(articles []entities.Article) map[int64]int {
 	return indices
 }
 
-func (d *dynamoRepository) GetFeed(username string, offset, limit int) ([]entities.Article, error) {
-	queryPublishers := dynamodb.QueryInput{
-		TableName:                 aws.String(dynamo.FollowTableName),
-		KeyConditionExpression:    aws.String("Follower=:username"),
-		ExpressionAttributeValues: dynamo.StringKey(":username", username),
-		ProjectionExpression:      aws.String("Publisher"),
+func (d *dynamoRepository) GetFeedPage(username, cursorToken string, limit int) ([]entities.Article, string, error) {
+	var boundary cursor.Position
+	hasCursor, err := cursor.Decode(cursorToken, &boundary)
+	if err != nil {
+		return nil, "", err
 	}
 
-	const queryInitialCapacity = 16
-	items, err := dynamo.QueryItems(&queryPublishers, 0, queryInitialCapacity)
+	ctx := context.Background()
+	queries, err := feedQueries(ctx, username)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	follows := make([]entities.Follow, 0, len(items))
-	err = dynamodbattribute.UnmarshalListOfMaps(items, &follows)
-	if err != nil {
-		return nil, err
+	// Every stream resumes right after the last article of the previous page
+	merger := feedMerger{}
+	if hasCursor {
+		merger.boundary = &boundary
 	}
 
-	// TODO: DynamoDB doesn't support batch queries
-	// https://stackoverflow.com/questions/24953783/dynamodb-batch-execute-queryrequests
-	// Concurrent queries can probably improve the performance of the following operations.
+	merged, err := merger.merge(ctx, queries, 0, limit)
+	if err != nil {
+		return nil, "", err
+	}
 
-	articlesByAuthor := make(entities.ArticlePriorityQueue, 0, len(follows))
+	next, err := nextFeedCursor(merged, limit)
+	return merged, next, err
+}
 
-	for _, follow := range follows {
-		articles, err := d.GetArticlesByAuthor(follow.Publisher, 0, limit)
-		if err != nil {
-			return nil, err
+// articlesAfter keeps at most limit of the newest first articles placed after boundary
+func articlesAfter(articles []entities.Article, hasBoundary bool, boundary cursor.Position, limit int) []entities.Article {
+	after := articles[:0]
+	for _, article := range articles {
+		if len(after) >= limit {
+			break
 		}
+		if !hasBoundary || boundary.After(article.CreatedAt, article.ArticleId) {
+			after = append(after, article)
+		}
+	}
+	return after
+}
+
+// nextFeedCursor is the position of the last article of a full page, so every
+// stream resumes after it on the next page
+func nextFeedCursor(page []entities.Article, limit int) (string, error) {
+	if len(page) < limit || len(page) == 0 {
+		return "", nil
+	}
 
-		articlesByAuthor = append(articlesByAuthor, articles)
+	last := page[len(page)-1]
+	return cursor.Encode(cursor.Position{
+		Key: last.CreatedAt,
+		Id:  last.ArticleId,
+	})
+}
+
+func (d *dynamoRepository) GetFeed(username string, offset, limit int) ([]entities.Article, error) {
+	ctx := context.Background()
+	queries, err := feedQueries(ctx, username)
+	if err != nil {
+		return nil, err
 	}
 
-	return entities.MergeArticles(articlesByAuthor, offset, limit), nil
+	merger := feedMerger{}
+	return merger.merge(ctx, queries, offset, limit)
 }
@@ -0,0 +1,94 @@
+package article
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/dynamo"
+)
+
+// Revisions are stored in their own table, keyed by ArticleId (hash) and Revision (range).
+// They are written in the transaction saving the article, and never updated.
+
+// putRevisionItem stores the revision made by the latest save of article
+func putRevisionItem(article entities.Article) (*dynamodb.TransactWriteItem, error) {
+	item, err := dynamodbattribute.MarshalMap(entities.NewArticleRevision(article))
+	if err != nil {
+		return nil, err
+	}
+
+	return &dynamodb.TransactWriteItem{
+		Put: &dynamodb.Put{
+			TableName:           aws.String(dynamo.ArticleRevisionTableName),
+			Item:                item,
+			ConditionExpression: aws.String("attribute_not_exists(ArticleId)"),
+		},
+	}, nil
+}
+
+func revisionsQuery(articleId int64) dynamodb.QueryInput {
+	return dynamodb.QueryInput{
+		TableName:                 aws.String(dynamo.ArticleRevisionTableName),
+		KeyConditionExpression:    aws.String("ArticleId=:articleId"),
+		ExpressionAttributeValues: dynamo.Int64Key(":articleId", articleId),
+		ScanIndexForward:          aws.Bool(false),
+	}
+}
+
+func (d *dynamoRepository) GetArticleRevisionsPage(articleId int64, cursor string, limit int) ([]entities.ArticleRevision, string, error) {
+	queryRevisions := revisionsQuery(articleId)
+
+	items, next, err := dynamo.QueryPage(&queryRevisions, cursor, limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	revisions := make([]entities.ArticleRevision, len(items))
+	err = dynamodbattribute.UnmarshalListOfMaps(items, &revisions)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return revisions, next, nil
+}
+
+func (d *dynamoRepository) GetArticleRevision(articleId, revision int64) (*entities.ArticleRevision, error) {
+	key := dynamo.AWSObject{
+		"ArticleId": dynamo.Int64Value(articleId),
+		"Revision":  dynamo.Int64Value(revision),
+	}
+
+	var articleRevision entities.ArticleRevision
+	found, err := dynamo.GetItemByKey(dynamo.ArticleRevisionTableName, key, &articleRevision)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, errRevisionNotFound()
+	}
+
+	return &articleRevision, nil
+}
+
+// deleteRevisions removes every revision of a deleted article
+func deleteRevisions(articleId int64) error {
+	queryRevisions := revisionsQuery(articleId)
+	queryRevisions.ProjectionExpression = aws.String("ArticleId, Revision")
+
+	requests := make([]*dynamodb.WriteRequest, 0)
+	err := dynamo.DynamoDB().QueryPages(&queryRevisions, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		for _, key := range page.Items {
+			requests = append(requests, &dynamodb.WriteRequest{
+				DeleteRequest: &dynamodb.DeleteRequest{Key: key},
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	return dynamo.BatchWriteItems(dynamo.ArticleRevisionTableName, requests)
+}
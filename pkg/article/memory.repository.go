@@ -0,0 +1,617 @@
+package article
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/cursor"
+	"github.com/ferjmc/cms/internal/memory"
+	"github.com/ferjmc/cms/pkg/rand"
+)
+
+type memoryRepository struct {
+	store *memory.Store
+}
+
+// NewMemoryRepository returns an ArticleRepository backed by store, mostly useful
+// for tests which need an isolated store
+func NewMemoryRepository(store *memory.Store) ArticleRepository {
+	return &memoryRepository{
+		store: store,
+	}
+}
+
+func (m *memoryRepository) PutArticle(article *entities.Article) error {
+	m.store.Lock()
+	defer m.store.Unlock()
+
+	// Try to find a unique article id
+	for {
+		article.ArticleId = 1 + rand.ArticleIdRand.Get().Int63n(entities.MaxArticleId-1) // range: [1, MaxArticleId)
+		if _, ok := m.store.Articles[article.ArticleId]; !ok {
+			break
+		}
+	}
+
+	article.MakeSlug()
+	article.Dummy = 0
+	m.store.Articles[article.ArticleId] = copyArticle(*article)
+	if article.Revision > 0 {
+		m.putRevision(*article)
+	}
+
+	// Tags only count published articles
+	if article.Published() {
+		for _, tag := range article.TagList {
+			m.linkTag(tag, *article)
+		}
+	}
+
+	return nil
+}
+
+func (m *memoryRepository) linkTag(tag string, article entities.Article) {
+	articleTags, ok := m.store.ArticleTags[tag]
+	if !ok {
+		articleTags = make(map[int64]entities.ArticleTag)
+		m.store.ArticleTags[tag] = articleTags
+	}
+
+	articleTags[article.ArticleId] = entities.ArticleTag{
+		Tag:       tag,
+		ArticleId: article.ArticleId,
+		CreatedAt: article.CreatedAt,
+	}
+
+	tagCount := m.store.Tags[tag]
+	tagCount.Tag = tag
+	tagCount.ArticleCount++
+	m.store.Tags[tag] = tagCount
+}
+
+func (m *memoryRepository) unlinkTag(tag string, articleId int64) {
+	delete(m.store.ArticleTags[tag], articleId)
+
+	// Like the DynamoDB counter, the tag stays with a zero count
+	tagCount := m.store.Tags[tag]
+	tagCount.Tag = tag
+	tagCount.ArticleCount--
+	m.store.Tags[tag] = tagCount
+}
+
+func (m *memoryRepository) putRevision(article entities.Article) {
+	revisions, ok := m.store.ArticleRevisions[article.ArticleId]
+	if !ok {
+		revisions = make(map[int64]entities.ArticleRevision)
+		m.store.ArticleRevisions[article.ArticleId] = revisions
+	}
+
+	revision := entities.NewArticleRevision(article)
+	revision.TagList = memory.CopyStrings(revision.TagList)
+	revisions[revision.Revision] = revision
+}
+
+func (m *memoryRepository) GetArticleRevisionsPage(articleId int64, cursorToken string, limit int) ([]entities.ArticleRevision, string, error) {
+	var boundary cursor.Position
+	hasCursor, err := cursor.Decode(cursorToken, &boundary)
+	if err != nil {
+		return nil, "", err
+	}
+
+	m.store.RLock()
+	defer m.store.RUnlock()
+
+	// Positions are made of the revision numbers, newest first like the table
+	revisions := m.store.ArticleRevisions[articleId]
+	positions := make([]cursor.Position, 0, len(revisions))
+	for revision := range revisions {
+		if hasCursor && !boundary.After(revision, articleId) {
+			continue
+		}
+		positions = append(positions, cursor.Position{Key: revision, Id: articleId})
+	}
+
+	sortPositions(positions)
+
+	_, end := memory.Page(len(positions), 0, limit)
+	page := make([]entities.ArticleRevision, 0, end)
+	for _, position := range positions[:end] {
+		revision := revisions[position.Key]
+		revision.TagList = memory.CopyStrings(revision.TagList)
+		page = append(page, revision)
+	}
+
+	next, err := nextCursor(positions, end)
+	return page, next, err
+}
+
+func (m *memoryRepository) GetArticleRevision(articleId, revision int64) (*entities.ArticleRevision, error) {
+	m.store.RLock()
+	defer m.store.RUnlock()
+
+	articleRevision, ok := m.store.ArticleRevisions[articleId][revision]
+	if !ok {
+		return nil, errRevisionNotFound()
+	}
+
+	articleRevision.TagList = memory.CopyStrings(articleRevision.TagList)
+	return &articleRevision, nil
+}
+
+func (m *memoryRepository) GetArticleById(articleId int64) (*entities.Article, error) {
+	m.store.RLock()
+	defer m.store.RUnlock()
+
+	article, ok := m.store.Articles[articleId]
+	if !ok {
+		return nil, errArticleNotFound()
+	}
+
+	article = copyArticle(article)
+	return &article, nil
+}
+
+func (m *memoryRepository) UpdateArticle(oldArticle, newArticle entities.Article) error {
+	m.store.Lock()
+	defer m.store.Unlock()
+
+	current, ok := m.store.Articles[oldArticle.ArticleId]
+	if !ok || current.UpdatedAt != oldArticle.UpdatedAt {
+		return entities.NewConflictError("article", "has been modified or deleted, please retry")
+	}
+
+	// Only the editable fields change, FavoritesCount is kept from the stored article
+	current.Slug = newArticle.Slug
+	current.Title = newArticle.Title
+	current.Description = newArticle.Description
+	current.Body = newArticle.Body
+	current.TagList = memory.CopyStrings(newArticle.TagList)
+	current.UpdatedAt = newArticle.UpdatedAt
+	current.CreatedAt = newArticle.CreatedAt
+	current.Status = newArticle.Status
+	current.PublishAt = newArticle.PublishAt
+	current.UpdatedBy = newArticle.UpdatedBy
+	current.Revision = newArticle.Revision
+	m.store.Articles[current.ArticleId] = current
+
+	if newArticle.Revision != oldArticle.Revision {
+		m.putRevision(current)
+	}
+
+	// Tags are linked to published articles only, the links follow the status
+	var addedTags, removedTags []string
+	switch {
+	case !oldArticle.Published() && newArticle.Published():
+		addedTags = newArticle.TagList
+	case oldArticle.Published() && !newArticle.Published():
+		removedTags = oldArticle.TagList
+	case newArticle.Published():
+		addedTags, removedTags = entities.DiffTags(oldArticle.TagList, newArticle.TagList)
+	}
+	for _, tag := range addedTags {
+		m.linkTag(tag, current)
+	}
+	for _, tag := range removedTags {
+		m.unlinkTag(tag, current.ArticleId)
+	}
+
+	return nil
+}
+
+func (m *memoryRepository) DeleteArticle(article entities.Article) error {
+	m.store.Lock()
+	defer m.store.Unlock()
+
+	current, ok := m.store.Articles[article.ArticleId]
+	if !ok || current.UpdatedAt != article.UpdatedAt {
+		return entities.NewConflictError("article", "has been modified or deleted, please retry")
+	}
+
+	delete(m.store.Articles, article.ArticleId)
+	delete(m.store.ArticleRevisions, article.ArticleId)
+	if current.Published() {
+		for _, tag := range current.TagList {
+			m.unlinkTag(tag, article.ArticleId)
+		}
+	}
+
+	return nil
+}
+
+func (m *memoryRepository) GetAllArticles(offset, limit int) ([]entities.Article, error) {
+	m.store.RLock()
+	defer m.store.RUnlock()
+
+	return m.pageArticles(m.filterArticles(func(article entities.Article) bool {
+		return article.Published()
+	}), offset, limit), nil
+}
+
+func (m *memoryRepository) GetArticlesByAuthor(author string, offset, limit int) ([]entities.Article, error) {
+	m.store.RLock()
+	defer m.store.RUnlock()
+
+	return m.pageArticles(m.filterArticles(func(article entities.Article) bool {
+		return article.Author == author && article.Published()
+	}), offset, limit), nil
+}
+
+func (m *memoryRepository) GetArticlesByTag(tag string, offset, limit int) ([]entities.Article, error) {
+	m.store.RLock()
+	defer m.store.RUnlock()
+
+	articles := make([]entities.Article, 0, len(m.store.ArticleTags[tag]))
+	for articleId := range m.store.ArticleTags[tag] {
+		if article, ok := m.store.Articles[articleId]; ok {
+			articles = append(articles, article)
+		}
+	}
+
+	return m.pageArticles(articles, offset, limit), nil
+}
+
+func (m *memoryRepository) GetFavoriteArticlesByUsername(username string, offset, limit int) ([]entities.Article, error) {
+	m.store.RLock()
+	defer m.store.RUnlock()
+
+	favoriteArticles := make([]entities.FavoriteArticle, 0)
+	for key, favoriteArticle := range m.store.FavoriteArticles {
+		if key.Username == username {
+			favoriteArticles = append(favoriteArticles, favoriteArticle)
+		}
+	}
+
+	// Latest favorited first, like the FavoritedAt index
+	sort.Slice(favoriteArticles, func(i, j int) bool {
+		if favoriteArticles[i].FavoritedAt != favoriteArticles[j].FavoritedAt {
+			return favoriteArticles[i].FavoritedAt > favoriteArticles[j].FavoritedAt
+		}
+		return favoriteArticles[i].ArticleId > favoriteArticles[j].ArticleId
+	})
+
+	start, end := memory.Page(len(favoriteArticles), offset, limit)
+	articles := make([]entities.Article, 0, end-start)
+
+	// Favorites may still point to deleted or unpublished articles, skip them
+	for _, favoriteArticle := range favoriteArticles[start:end] {
+		if article, ok := m.store.Articles[favoriteArticle.ArticleId]; ok && article.Published() {
+			articles = append(articles, copyArticle(article))
+		}
+	}
+
+	return articles, nil
+}
+
+func (m *memoryRepository) FavoriteArticle(username string, articleId int64) (bool, error) {
+	m.store.Lock()
+	defer m.store.Unlock()
+
+	key := entities.FavoriteArticleKey{
+		Username:  username,
+		ArticleId: articleId,
+	}
+
+	article, ok := m.store.Articles[articleId]
+	if !ok {
+		if _, favorited := m.store.FavoriteArticles[key]; favorited {
+			return false, nil
+		}
+		return false, errArticleNotFound()
+	}
+
+	if _, favorited := m.store.FavoriteArticles[key]; favorited {
+		return false, nil
+	}
+
+	m.store.FavoriteArticles[key] = entities.FavoriteArticle{
+		FavoriteArticleKey: key,
+		FavoritedAt:        time.Now().UTC().UnixNano(),
+	}
+	article.FavoritesCount++
+	m.store.Articles[articleId] = article
+
+	return true, nil
+}
+
+func (m *memoryRepository) UnfavoriteArticle(username string, articleId int64) (bool, error) {
+	m.store.Lock()
+	defer m.store.Unlock()
+
+	key := entities.FavoriteArticleKey{
+		Username:  username,
+		ArticleId: articleId,
+	}
+
+	if _, favorited := m.store.FavoriteArticles[key]; !favorited {
+		return false, nil
+	}
+
+	article, ok := m.store.Articles[articleId]
+	if !ok {
+		return false, errArticleNotFound()
+	}
+
+	delete(m.store.FavoriteArticles, key)
+	article.FavoritesCount--
+	m.store.Articles[articleId] = article
+
+	return true, nil
+}
+
+func (m *memoryRepository) IsArticleFavoritedByUser(user *entities.User, articles []entities.Article) ([]bool, error) {
+	isFavorited := make([]bool, len(articles))
+	if user == nil {
+		return isFavorited, nil
+	}
+
+	m.store.RLock()
+	defer m.store.RUnlock()
+
+	for i, article := range articles {
+		_, isFavorited[i] = m.store.FavoriteArticles[entities.FavoriteArticleKey{
+			Username:  user.Username,
+			ArticleId: article.ArticleId,
+		}]
+	}
+
+	return isFavorited, nil
+}
+
+func (m *memoryRepository) GetFeed(username string, offset, limit int) ([]entities.Article, error) {
+	m.store.RLock()
+	defer m.store.RUnlock()
+
+	articlesByAuthor := make(entities.ArticlePriorityQueue, 0)
+
+	for follow := range m.store.Follows {
+		if follow.Follower != username {
+			continue
+		}
+
+		publisher := follow.Publisher
+		articles := m.pageArticles(m.filterArticles(func(article entities.Article) bool {
+			return article.Author == publisher && article.Published()
+		}), 0, offset+limit)
+
+		articlesByAuthor = append(articlesByAuthor, articles)
+	}
+
+	return entities.MergeArticles(articlesByAuthor, offset, limit), nil
+}
+
+func (m *memoryRepository) GetAllArticlesPage(cursor string, limit int) ([]entities.Article, string, error) {
+	m.store.RLock()
+	defer m.store.RUnlock()
+
+	return m.pageArticlesAfter(m.filterArticles(func(article entities.Article) bool {
+		return article.Published()
+	}), cursor, limit)
+}
+
+func (m *memoryRepository) GetArticlesByAuthorPage(author, cursor string, limit int) ([]entities.Article, string, error) {
+	m.store.RLock()
+	defer m.store.RUnlock()
+
+	return m.pageArticlesAfter(m.filterArticles(func(article entities.Article) bool {
+		return article.Author == author && article.Published()
+	}), cursor, limit)
+}
+
+func (m *memoryRepository) GetArticlesByTagPage(tag, cursor string, limit int) ([]entities.Article, string, error) {
+	m.store.RLock()
+	defer m.store.RUnlock()
+
+	articles := make([]entities.Article, 0, len(m.store.ArticleTags[tag]))
+	for articleId := range m.store.ArticleTags[tag] {
+		if article, ok := m.store.Articles[articleId]; ok {
+			articles = append(articles, article)
+		}
+	}
+
+	return m.pageArticlesAfter(articles, cursor, limit)
+}
+
+func (m *memoryRepository) GetFavoriteArticlesByUsernamePage(username, cursorToken string, limit int) ([]entities.Article, string, error) {
+	var boundary cursor.Position
+	hasCursor, err := cursor.Decode(cursorToken, &boundary)
+	if err != nil {
+		return nil, "", err
+	}
+
+	m.store.RLock()
+	defer m.store.RUnlock()
+
+	// Positions are made of FavoritedAt and ArticleId, like the FavoritedAt index
+	positions := make([]cursor.Position, 0)
+	for key, favoriteArticle := range m.store.FavoriteArticles {
+		if key.Username != username {
+			continue
+		}
+		if hasCursor && !boundary.After(favoriteArticle.FavoritedAt, key.ArticleId) {
+			continue
+		}
+		positions = append(positions, cursor.Position{Key: favoriteArticle.FavoritedAt, Id: key.ArticleId})
+	}
+
+	sortPositions(positions)
+
+	_, end := memory.Page(len(positions), 0, limit)
+	articles := make([]entities.Article, 0, end)
+	for _, position := range positions[:end] {
+		// Favorites may still point to deleted or unpublished articles, skip them
+		if article, ok := m.store.Articles[position.Id]; ok && article.Published() {
+			articles = append(articles, copyArticle(article))
+		}
+	}
+
+	next, err := nextCursor(positions, end)
+	return articles, next, err
+}
+
+func (m *memoryRepository) GetUnpublishedArticlesByAuthorPage(author, cursor string, limit int) ([]entities.Article, string, error) {
+	m.store.RLock()
+	defer m.store.RUnlock()
+
+	return m.pageArticlesAfter(m.filterArticles(func(article entities.Article) bool {
+		return article.Author == author && !article.Published()
+	}), cursor, limit)
+}
+
+func (m *memoryRepository) GetScheduledArticles(until int64, limit int) ([]entities.Article, error) {
+	m.store.RLock()
+	defer m.store.RUnlock()
+
+	articles := m.filterArticles(func(article entities.Article) bool {
+		return article.Status == entities.ArticleStatusScheduled && article.PublishAt <= until
+	})
+
+	// Oldest PublishAt first, like the index Status
+	sort.Slice(articles, func(i, j int) bool {
+		if articles[i].PublishAt != articles[j].PublishAt {
+			return articles[i].PublishAt < articles[j].PublishAt
+		}
+		return articles[i].ArticleId < articles[j].ArticleId
+	})
+
+	_, end := memory.Page(len(articles), 0, limit)
+	scheduled := make([]entities.Article, 0, end)
+	for _, article := range articles[:end] {
+		scheduled = append(scheduled, copyArticle(article))
+	}
+
+	return scheduled, nil
+}
+
+func (m *memoryRepository) GetFeedPage(username, cursor string, limit int) ([]entities.Article, string, error) {
+	m.store.RLock()
+	defer m.store.RUnlock()
+
+	return m.pageArticlesAfter(m.filterArticles(func(article entities.Article) bool {
+		return article.Published() && m.store.Follows[entities.Follow{Follower: username, Publisher: article.Author}]
+	}), cursor, limit)
+}
+
+// pageArticlesAfter sorts articles newest first and copies at most limit of them placed after the cursor
+func (m *memoryRepository) pageArticlesAfter(articles []entities.Article, cursorToken string, limit int) ([]entities.Article, string, error) {
+	var boundary cursor.Position
+	hasCursor, err := cursor.Decode(cursorToken, &boundary)
+	if err != nil {
+		return nil, "", err
+	}
+
+	positions := make([]cursor.Position, 0, len(articles))
+	byId := make(map[int64]entities.Article, len(articles))
+	for _, article := range articles {
+		if hasCursor && !boundary.After(article.CreatedAt, article.ArticleId) {
+			continue
+		}
+		positions = append(positions, cursor.Position{Key: article.CreatedAt, Id: article.ArticleId})
+		byId[article.ArticleId] = article
+	}
+
+	sortPositions(positions)
+
+	_, end := memory.Page(len(positions), 0, limit)
+	page := make([]entities.Article, 0, end)
+	for _, position := range positions[:end] {
+		page = append(page, copyArticle(byId[position.Id]))
+	}
+
+	next, err := nextCursor(positions, end)
+	return page, next, err
+}
+
+func sortPositions(positions []cursor.Position) {
+	sort.Slice(positions, func(i, j int) bool {
+		return positions[i].After(positions[j].Key, positions[j].Id)
+	})
+}
+
+// nextCursor points after the last returned position, if any position remains
+func nextCursor(positions []cursor.Position, end int) (string, error) {
+	if end >= len(positions) || end == 0 {
+		return "", nil
+	}
+
+	return cursor.Encode(positions[end-1])
+}
+
+func (m *memoryRepository) filterArticles(keep func(entities.Article) bool) []entities.Article {
+	articles := make([]entities.Article, 0)
+	for _, article := range m.store.Articles {
+		if keep(article) {
+			articles = append(articles, article)
+		}
+	}
+	return articles
+}
+
+// pageArticles sorts articles newest first, like the CreatedAt indexes, and copies the requested window
+func (m *memoryRepository) pageArticles(articles []entities.Article, offset, limit int) []entities.Article {
+	sort.Slice(articles, func(i, j int) bool {
+		if articles[i].CreatedAt != articles[j].CreatedAt {
+			return articles[i].CreatedAt > articles[j].CreatedAt
+		}
+		return articles[i].ArticleId > articles[j].ArticleId
+	})
+
+	start, end := memory.Page(len(articles), offset, limit)
+	page := make([]entities.Article, 0, end-start)
+	for _, article := range articles[start:end] {
+		page = append(page, copyArticle(article))
+	}
+
+	return page
+}
+
+func copyArticle(article entities.Article) entities.Article {
+	article.TagList = memory.CopyStrings(article.TagList)
+	return article
+}
+
+// The memory rename steps rewrite every row at once, there is no next page
+func (m *memoryRepository) RenameAuthor(oldUsername, newUsername, cursor string, limit int) (string, error) {
+	m.store.Lock()
+	defer m.store.Unlock()
+
+	for articleId, article := range m.store.Articles {
+		if article.Author != oldUsername {
+			continue
+		}
+
+		article.Author = newUsername
+		if article.UpdatedBy == oldUsername {
+			article.UpdatedBy = newUsername
+		}
+		m.store.Articles[articleId] = article
+	}
+
+	return "", nil
+}
+
+func (m *memoryRepository) RenameFavorites(oldUsername, newUsername, cursor string, limit int) (string, error) {
+	m.store.Lock()
+	defer m.store.Unlock()
+
+	for key, favorite := range m.store.FavoriteArticles {
+		if key.Username != oldUsername {
+			continue
+		}
+		delete(m.store.FavoriteArticles, key)
+
+		favorite.Username = newUsername
+		if _, favorited := m.store.FavoriteArticles[favorite.FavoriteArticleKey]; !favorited {
+			m.store.FavoriteArticles[favorite.FavoriteArticleKey] = favorite
+			continue
+		}
+
+		// Favorited again under the new username
+		if article, ok := m.store.Articles[key.ArticleId]; ok {
+			article.FavoritesCount--
+			m.store.Articles[key.ArticleId] = article
+		}
+	}
+
+	return "", nil
+}
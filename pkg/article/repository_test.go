@@ -0,0 +1,58 @@
+package article_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ferjmc/cms/internal/memory"
+	"github.com/ferjmc/cms/pkg/article"
+	"github.com/ferjmc/cms/pkg/article/articletest"
+	"github.com/ferjmc/cms/pkg/follow"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	articletest.Run(t, func() (article.ArticleRepository, follow.FollowRepository) {
+		store := memory.NewStore()
+		return article.NewMemoryRepository(store), follow.NewMemoryRepository(store)
+	})
+}
+
+func TestDynamoDBRepository(t *testing.T) {
+	if os.Getenv("DYNAMODB_ENDPOINT") == "" {
+		t.Skip("DYNAMODB_ENDPOINT is not set")
+	}
+
+	articletest.Run(t, func() (article.ArticleRepository, follow.FollowRepository) {
+		articles, err := article.NewArticleRepository(article.InstanceDynamodb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		follows, err := follow.NewFollowRepository(follow.InstanceDynamodb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return articles, follows
+	})
+}
+
+func TestDynamoDBRepositoryWithTimeline(t *testing.T) {
+	if os.Getenv("DYNAMODB_ENDPOINT") == "" {
+		t.Skip("DYNAMODB_ENDPOINT is not set")
+	}
+
+	// Authors with more than one follower are read from the article table, the others from timelines
+	os.Setenv("TIMELINE_THRESHOLD", "1")
+	defer os.Unsetenv("TIMELINE_THRESHOLD")
+
+	articletest.Run(t, func() (article.ArticleRepository, follow.FollowRepository) {
+		articles, err := article.NewArticleRepository(article.InstanceDynamodb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		follows, err := follow.NewFollowRepository(follow.InstanceDynamodb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return articles, follows
+	})
+}
@@ -0,0 +1,97 @@
+package article
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/memory"
+)
+
+func TestRevisions(t *testing.T) {
+	serv := NewArticleService(NewMemoryRepository(memory.NewStore()), nil, nil)
+
+	author := &entities.User{Username: "alice"}
+	editor := &entities.User{Username: "carol", Roles: []string{entities.RoleEditor}}
+	reader := &entities.User{Username: "bob"}
+
+	now := time.Now().UTC().UnixNano()
+	original := &entities.Article{
+		Title:       "title",
+		Description: "description",
+		Body:        "one\ntwo\nthree\n",
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Author:      author.Username,
+	}
+	if err := serv.PutArticle(original); err != nil {
+		t.Fatalf("PutArticle: %s", err)
+	}
+
+	edited := *original
+	edited.Body = "one\n2\nthree\n"
+	if err := serv.UpdateArticle(editor, *original, &edited); err != nil {
+		t.Fatalf("UpdateArticle: %s", err)
+	}
+
+	t.Run("It must only number content changes", func(t *testing.T) {
+		if original.Revision != 1 || edited.Revision != 2 || edited.UpdatedBy != editor.Username {
+			t.Fatalf("expected revisions 1 and 2 by carol, got %d and %d by %s", original.Revision, edited.Revision, edited.UpdatedBy)
+		}
+
+		archived := edited
+		archived.Status = entities.ArticleStatusArchived
+		if err := serv.UpdateArticle(author, edited, &archived); err != nil {
+			t.Fatalf("UpdateArticle: %s", err)
+		}
+		if archived.Revision != 2 {
+			t.Errorf("a status change must not make a revision, got %d", archived.Revision)
+		}
+		edited = archived
+	})
+
+	t.Run("It must diff the bodies of two revisions", func(t *testing.T) {
+		unified, err := serv.DiffRevisions(author, edited, 1, 2)
+		if err != nil {
+			t.Fatalf("DiffRevisions: %s", err)
+		}
+		if !strings.Contains(unified, "-two\n+2\n") {
+			t.Errorf("unexpected diff\n%s", unified)
+		}
+
+		if _, err := serv.DiffRevisions(author, edited, 2, 3); !isNotFoundError(err) {
+			t.Errorf("expected an unknown revision to be not found, got %v", err)
+		}
+		if _, err := serv.DiffRevisions(reader, edited, 1, 2); !isForbiddenError(err) {
+			t.Errorf("only the users who can modify an article may read its revisions, got %v", err)
+		}
+	})
+
+	t.Run("It must restore a revision as a new one", func(t *testing.T) {
+		if _, err := serv.RestoreRevision(reader, edited, 1); !isForbiddenError(err) {
+			t.Errorf("only the users who can modify an article may restore a revision, got %v", err)
+		}
+
+		restored, err := serv.RestoreRevision(author, edited, 1)
+		if err != nil {
+			t.Fatalf("RestoreRevision: %s", err)
+		}
+		if restored.Revision != 3 || restored.Body != original.Body || restored.UpdatedBy != author.Username {
+			t.Errorf("expected revision 3 with the first body, got %+v", *restored)
+		}
+
+		revisions, _, err := serv.GetRevisionsPage(editor, *restored, "", 10)
+		if err != nil {
+			t.Fatalf("GetRevisionsPage: %s", err)
+		}
+		if len(revisions) != 3 || revisions[0].Revision != 3 || revisions[0].Body != original.Body {
+			t.Errorf("expected 3 revisions newest first, got %+v", revisions)
+		}
+	})
+}
+
+func isForbiddenError(err error) bool {
+	_, ok := err.(entities.ForbiddenError)
+	return ok
+}
@@ -0,0 +1,138 @@
+package article
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/memory"
+)
+
+func TestArticleStatus(t *testing.T) {
+	store := memory.NewStore()
+	serv := NewArticleService(NewMemoryRepository(store), nil, nil)
+
+	author := &entities.User{Username: "alice"}
+	reader := &entities.User{Username: "bob"}
+	newArticle := func(status string, publishAt int64) *entities.Article {
+		now := time.Now().UTC().UnixNano()
+		return &entities.Article{
+			Title:       "title",
+			Description: "description",
+			Body:        "body",
+			TagList:     []string{"status"},
+			CreatedAt:   now,
+			UpdatedAt:   now,
+			Author:      author.Username,
+			Status:      status,
+			PublishAt:   publishAt,
+		}
+	}
+
+	t.Run("It must hide drafts from everyone but their author", func(t *testing.T) {
+		draft := newArticle(entities.ArticleStatusDraft, 0)
+		if err := serv.PutArticle(draft); err != nil {
+			t.Fatalf("PutArticle: %s", err)
+		}
+
+		if _, err := serv.GetArticleBySlug(author, draft.Slug); err != nil {
+			t.Errorf("the author must read the draft: %s", err)
+		}
+		for _, user := range []*entities.User{reader, nil} {
+			if _, err := serv.GetArticleBySlug(user, draft.Slug); !isNotFoundError(err) {
+				t.Errorf("expected not found for %v, got %v", user, err)
+			}
+		}
+	})
+
+	t.Run("It must validate statuses", func(t *testing.T) {
+		for _, a := range []*entities.Article{
+			newArticle("pending", 0),
+			newArticle(entities.ArticleStatusArchived, 0),
+			newArticle(entities.ArticleStatusScheduled, time.Now().Add(-time.Hour).UnixNano()),
+		} {
+			if _, ok := serv.PutArticle(a).(entities.InputError); !ok {
+				t.Errorf("expected an input error for status %s", a.Status)
+			}
+		}
+
+		published := newArticle("", 0)
+		if err := serv.PutArticle(published); err != nil {
+			t.Fatalf("PutArticle: %s", err)
+		}
+		if published.Status != entities.ArticleStatusPublished {
+			t.Errorf("expected a published article, got %q", published.Status)
+		}
+
+		backToDraft := *published
+		backToDraft.Status = entities.ArticleStatusDraft
+		if _, ok := serv.UpdateArticle(author, *published, &backToDraft).(entities.InputError); !ok {
+			t.Error("a published article must not go back to draft")
+		}
+	})
+
+	t.Run("It must publish the scheduled articles which are due", func(t *testing.T) {
+		publishAt := time.Now().Add(time.Hour).UTC().UnixNano()
+		scheduled := newArticle(entities.ArticleStatusScheduled, publishAt)
+		if err := serv.PutArticle(scheduled); err != nil {
+			t.Fatalf("PutArticle: %s", err)
+		}
+
+		published, err := serv.PublishScheduledArticles(publishAt - 1)
+		if err != nil || published != 0 {
+			t.Fatalf("expected nothing to publish before PublishAt, got %d (%v)", published, err)
+		}
+		if store.Tags["status"].ArticleCount != 1 {
+			t.Errorf("expected the tag to count the published article only, got %d", store.Tags["status"].ArticleCount)
+		}
+
+		published, err = serv.PublishScheduledArticles(publishAt)
+		if err != nil || published != 1 {
+			t.Fatalf("expected one published article, got %d (%v)", published, err)
+		}
+
+		found, err := serv.GetArticleBySlug(reader, scheduled.Slug)
+		if err != nil {
+			t.Fatalf("GetArticleBySlug: %s", err)
+		}
+		if !found.Published() || found.CreatedAt != publishAt {
+			t.Errorf("expected an article published at %d, got %+v", publishAt, *found)
+		}
+		if store.Tags["status"].ArticleCount != 2 {
+			t.Errorf("expected the tag to count 2 articles, got %d", store.Tags["status"].ArticleCount)
+		}
+	})
+}
+
+func isNotFoundError(err error) bool {
+	_, ok := err.(entities.NotFoundError)
+	return ok
+}
+
+func TestMarshalArticleKeepsTheStatusIndexSparse(t *testing.T) {
+	tests := []struct {
+		status    string
+		publishAt int64
+		dummy     bool
+		scheduled bool
+	}{
+		{entities.ArticleStatusDraft, 0, false, false},
+		{entities.ArticleStatusScheduled, 1600000000000000000, false, true},
+		{entities.ArticleStatusPublished, 0, true, false},
+		{entities.ArticleStatusArchived, 0, false, false},
+	}
+
+	for _, tt := range tests {
+		item, err := marshalArticle(&entities.Article{ArticleId: 1, Status: tt.status, PublishAt: tt.publishAt})
+		if err != nil {
+			t.Fatalf("marshalArticle: %s", err)
+		}
+
+		if _, ok := item["Dummy"]; ok != tt.dummy {
+			t.Errorf("%s: Dummy stored %v, want %v", tt.status, ok, tt.dummy)
+		}
+		if _, ok := item["PublishAt"]; ok != tt.scheduled {
+			t.Errorf("%s: PublishAt stored %v, want %v", tt.status, ok, tt.scheduled)
+		}
+	}
+}
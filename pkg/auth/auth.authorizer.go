@@ -0,0 +1,51 @@
+package auth
+
+import "github.com/ferjmc/cms/entities"
+
+// Permissions over the content of other users, everyone may act on its own content
+const (
+	PermissionUpdateAnyArticle = "article:update-any"
+	PermissionDeleteAnyArticle = "article:delete-any"
+	PermissionDeleteAnyComment = "comment:delete-any"
+	PermissionManageRoles      = "user:manage-roles"
+)
+
+// rolePermissions are the permissions granted by each role, editors moderate the
+// content and admins also manage the roles
+var rolePermissions = map[string][]string{
+	entities.RoleAdmin: {
+		PermissionUpdateAnyArticle,
+		PermissionDeleteAnyArticle,
+		PermissionDeleteAnyComment,
+		PermissionManageRoles,
+	},
+	entities.RoleEditor: {
+		PermissionUpdateAnyArticle,
+		PermissionDeleteAnyArticle,
+		PermissionDeleteAnyComment,
+	},
+}
+
+// Can tells if one of the roles of user grants permission, nobody can without a user
+func Can(user *entities.User, permission string) bool {
+	if user == nil {
+		return false
+	}
+
+	for _, role := range user.Roles {
+		for _, granted := range rolePermissions[role] {
+			if granted == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Authorize fails with a forbidden error unless user has permission
+func Authorize(user *entities.User, permission string) error {
+	if !Can(user, permission) {
+		return entities.NewForbiddenError("user", "is not allowed to "+permission)
+	}
+	return nil
+}
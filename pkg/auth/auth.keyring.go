@@ -0,0 +1,269 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/ferjmc/cms/entities"
+)
+
+// Keys are read again from the store after keyCacheDuration, or when a token has an unknown
+// kid, at most once per keyReloadInterval so invalid tokens can't flood the store
+const keyCacheDuration = 5 * time.Minute
+const keyReloadInterval = 10 * time.Second
+
+var errNoActiveKey = errors.New("no active signing key")
+
+// parsedKey is a SigningKey ready to sign and verify tokens
+type parsedKey struct {
+	entities.SigningKey
+	method          jwt.SigningMethod
+	signingKey      interface{}
+	verificationKey interface{}
+}
+
+func parseKey(key entities.SigningKey) (parsedKey, error) {
+	parsed := parsedKey{SigningKey: key}
+
+	switch key.Algorithm {
+	case AlgorithmHS256:
+		if len(key.Material) == 0 {
+			return parsed, fmt.Errorf("signing key %s: empty secret", key.Kid)
+		}
+		parsed.method = jwt.SigningMethodHS256
+		parsed.signingKey = key.Material
+		parsed.verificationKey = key.Material
+		return parsed, nil
+	case AlgorithmRS256, AlgorithmEdDSA:
+	default:
+		return parsed, fmt.Errorf("signing key %s: unsupported algorithm %q", key.Kid, key.Algorithm)
+	}
+
+	privateKey, err := x509.ParsePKCS8PrivateKey(key.Material)
+	if err != nil {
+		return parsed, fmt.Errorf("signing key %s: %w", key.Kid, err)
+	}
+
+	switch privateKey := privateKey.(type) {
+	case *rsa.PrivateKey:
+		if key.Algorithm == AlgorithmRS256 {
+			parsed.method = jwt.SigningMethodRS256
+			parsed.signingKey = privateKey
+			parsed.verificationKey = &privateKey.PublicKey
+			return parsed, nil
+		}
+	case ed25519.PrivateKey:
+		if key.Algorithm == AlgorithmEdDSA {
+			parsed.method = SigningMethodEdDSA
+			parsed.signingKey = privateKey
+			parsed.verificationKey = privateKey.Public().(ed25519.PublicKey)
+			return parsed, nil
+		}
+	}
+
+	return parsed, fmt.Errorf("signing key %s: material doesn't match algorithm %s", key.Kid, key.Algorithm)
+}
+
+// keyring caches the keys of a store
+type keyring struct {
+	store         KeyStore
+	storeErr      error
+	cacheDuration time.Duration
+
+	mu       sync.Mutex
+	keys     map[string]parsedKey
+	active   *parsedKey
+	loadedAt time.Time
+}
+
+func newKeyring(store KeyStore) *keyring {
+	return &keyring{
+		store:         store,
+		cacheDuration: keyCacheDuration,
+	}
+}
+
+var defaultKeyringOnce sync.Once
+var defaultKeys *keyring
+
+// defaultKeyring is shared by the process, so a lambda reads its keys once per keyCacheDuration
+func defaultKeyring() *keyring {
+	defaultKeyringOnce.Do(func() {
+		store, err := defaultKeyStore()
+		defaultKeys = newKeyring(store)
+		defaultKeys.storeErr = err
+	})
+	return defaultKeys
+}
+
+// load reads the keys again if the cache is expired, or if force and they weren't read recently
+func (k *keyring) load(force bool) error {
+	if k.storeErr != nil {
+		return k.storeErr
+	}
+
+	age := time.Since(k.loadedAt)
+	if k.keys != nil && age < k.cacheDuration && (!force || age < keyReloadInterval) {
+		return nil
+	}
+
+	keys, err := k.store.Keys()
+	if err != nil {
+		return err
+	}
+
+	parsedKeys := make(map[string]parsedKey, len(keys))
+	var active *parsedKey
+	for _, key := range keys {
+		parsed, err := parseKey(key)
+		if err != nil {
+			return err
+		}
+		parsedKeys[key.Kid] = parsed
+
+		if key.Status == entities.KeyStatusActive && (active == nil || key.CreatedAt > active.CreatedAt) {
+			active = &parsed
+		}
+	}
+
+	k.keys, k.active, k.loadedAt = parsedKeys, active, time.Now()
+	return nil
+}
+
+// signingKey returns the newest active key, a writable store without one gets a new key
+func (k *keyring) signingKey() (parsedKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	err := k.load(false)
+	if err != nil {
+		return parsedKey{}, err
+	}
+
+	if k.active == nil {
+		_, err = RotateKey(k.store, defaultKeyAlgorithm())
+		if err == ErrReadOnlyKeyStore {
+			return parsedKey{}, errNoActiveKey
+		}
+		if err != nil {
+			return parsedKey{}, err
+		}
+
+		k.loadedAt = time.Time{}
+		err = k.load(true)
+		if err != nil {
+			return parsedKey{}, err
+		}
+		if k.active == nil {
+			return parsedKey{}, errNoActiveKey
+		}
+	}
+
+	return *k.active, nil
+}
+
+// verificationKey returns the key verifying token, selected by its kid header. The algorithm
+// of the token must be the one of the key, so a public key is never used as a HMAC secret.
+func (k *keyring) verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, ok := token.Header["kid"].(string)
+	if !ok || kid == "" {
+		return nil, entities.NewUnauthorizedError("Authorization", "kid missing")
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	err := k.load(false)
+	if err != nil {
+		return nil, err
+	}
+
+	key, found := k.keys[kid]
+	if !found {
+		// The key may have been added since the keys were read
+		err = k.load(true)
+		if err != nil {
+			return nil, err
+		}
+		key, found = k.keys[kid]
+	}
+	if !found {
+		return nil, entities.NewUnauthorizedError("Authorization", "unknown key")
+	}
+
+	if token.Method.Alg() != key.Algorithm {
+		return nil, entities.NewUnauthorizedError("Authorization", fmt.Sprintf("unexpected signing method: %v", token.Header["alg"]))
+	}
+
+	return key.verificationKey, nil
+}
+
+// JWK is a public key in the JSON Web Key format (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// jwks returns the public keys verifying tokens, HS256 secrets are never published
+func (k *keyring) jwks() (JWKS, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	jwks := JWKS{
+		Keys: make([]JWK, 0, len(k.keys)),
+	}
+
+	err := k.load(false)
+	if err != nil {
+		return jwks, err
+	}
+
+	for _, key := range k.keys {
+		switch publicKey := key.verificationKey.(type) {
+		case *rsa.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				Kty: "RSA",
+				Kid: key.Kid,
+				Use: "sig",
+				Alg: key.Algorithm,
+				N:   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				Kty: "OKP",
+				Kid: key.Kid,
+				Use: "sig",
+				Alg: key.Algorithm,
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(publicKey),
+			})
+		}
+	}
+
+	// Newest keys first, for a stable document
+	sort.Slice(jwks.Keys, func(i, j int) bool {
+		return k.keys[jwks.Keys[i].Kid].CreatedAt > k.keys[jwks.Keys[j].Kid].CreatedAt
+	})
+
+	return jwks, nil
+}
@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/memory"
+)
+
+const (
+	KeyStoreDynamodb int = iota
+	KeyStoreMemory
+	KeyStoreEnv
+	KeyStoreFile
+)
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+var ErrReadOnlyKeyStore = errors.New("key store is read only")
+var ErrKeyNotFound = errors.New("signing key not found")
+
+// KeyStore holds the keys signing the JWT tokens
+type KeyStore interface {
+	// Keys returns every key which isn't retired
+	Keys() ([]entities.SigningKey, error)
+	PutKey(key entities.SigningKey) error
+	SetKeyStatus(kid, status string) error
+}
+
+// NewKeyStore returns the key store of instance. The env and file stores read
+// a JSON list of keys from JWT_KEYS and from the file JWT_KEYS_FILE, they are
+// managed outside of the application and are read only.
+func NewKeyStore(instance int) (KeyStore, error) {
+	switch instance {
+	case KeyStoreDynamodb:
+		return &dynamoKeyStore{}, nil
+	case KeyStoreMemory:
+		return NewMemoryKeyStore(memory.DB()), nil
+	case KeyStoreEnv:
+		return &staticKeyStore{source: "JWT_KEYS", read: func() ([]byte, error) {
+			return []byte(os.Getenv("JWT_KEYS")), nil
+		}}, nil
+	case KeyStoreFile:
+		return &staticKeyStore{source: os.Getenv("JWT_KEYS_FILE"), read: func() ([]byte, error) {
+			return os.ReadFile(os.Getenv("JWT_KEYS_FILE"))
+		}}, nil
+	default:
+		return nil, errors.New("key store instance not found")
+	}
+}
+
+// defaultKeyStore is selected by JWT_KEY_STORE: dynamodb, memory, env or file.
+// Without it, keys are kept in memory along with the memory repositories, otherwise in DynamoDB.
+func defaultKeyStore() (KeyStore, error) {
+	switch os.Getenv("JWT_KEY_STORE") {
+	case "dynamodb":
+		return NewKeyStore(KeyStoreDynamodb)
+	case "memory":
+		return NewKeyStore(KeyStoreMemory)
+	case "env":
+		return NewKeyStore(KeyStoreEnv)
+	case "file":
+		return NewKeyStore(KeyStoreFile)
+	case "":
+		if memory.Enabled() {
+			return NewKeyStore(KeyStoreMemory)
+		}
+		return NewKeyStore(KeyStoreDynamodb)
+	default:
+		return nil, fmt.Errorf("unknown JWT_KEY_STORE %q", os.Getenv("JWT_KEY_STORE"))
+	}
+}
+
+// defaultKeyAlgorithm is the algorithm of generated keys, JWT_ALGORITHM or RS256 by default
+func defaultKeyAlgorithm() string {
+	if algorithm := os.Getenv("JWT_ALGORITHM"); algorithm != "" {
+		return algorithm
+	}
+	return AlgorithmRS256
+}
+
+// GenerateKey returns a new active key of algorithm
+func GenerateKey(algorithm string) (entities.SigningKey, error) {
+	var material []byte
+	var err error
+
+	switch algorithm {
+	case AlgorithmHS256:
+		material = make([]byte, 32)
+		_, err = rand.Read(material)
+	case AlgorithmRS256:
+		var privateKey *rsa.PrivateKey
+		privateKey, err = rsa.GenerateKey(rand.Reader, 2048)
+		if err == nil {
+			material, err = x509.MarshalPKCS8PrivateKey(privateKey)
+		}
+	case AlgorithmEdDSA:
+		var privateKey ed25519.PrivateKey
+		_, privateKey, err = ed25519.GenerateKey(rand.Reader)
+		if err == nil {
+			material, err = x509.MarshalPKCS8PrivateKey(privateKey)
+		}
+	default:
+		return entities.SigningKey{}, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+	if err != nil {
+		return entities.SigningKey{}, err
+	}
+
+	kid := make([]byte, 12)
+	_, err = rand.Read(kid)
+	if err != nil {
+		return entities.SigningKey{}, err
+	}
+
+	return entities.SigningKey{
+		Kid:       base64.RawURLEncoding.EncodeToString(kid),
+		Algorithm: algorithm,
+		Material:  material,
+		Status:    entities.KeyStatusActive,
+		CreatedAt: time.Now().UTC().UnixNano(),
+	}, nil
+}
+
+// RotateKey adds a new active key of algorithm, the previous active keys only verify tokens
+// from then on, until they are retired once the tokens they signed have expired
+func RotateKey(store KeyStore, algorithm string) (entities.SigningKey, error) {
+	keys, err := store.Keys()
+	if err != nil {
+		return entities.SigningKey{}, err
+	}
+
+	key, err := GenerateKey(algorithm)
+	if err != nil {
+		return entities.SigningKey{}, err
+	}
+
+	err = store.PutKey(key)
+	if err != nil {
+		return entities.SigningKey{}, err
+	}
+
+	for _, oldKey := range keys {
+		if oldKey.Status == entities.KeyStatusActive {
+			err = store.SetKeyStatus(oldKey.Kid, entities.KeyStatusVerify)
+			if err != nil {
+				return entities.SigningKey{}, err
+			}
+		}
+	}
+
+	return key, nil
+}
+
+// RetireKey stops verifying the tokens signed by kid
+func RetireKey(store KeyStore, kid string) error {
+	return store.SetKeyStatus(kid, entities.KeyStatusRetired)
+}
@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/memory"
+)
+
+// newTestAuth never caches keys, so changes of the store are seen at once
+func newTestAuth(store KeyStore) *auth {
+	keys := newKeyring(store)
+	keys.cacheDuration = 0
+	return &auth{keys: keys}
+}
+
+func tokenKid(t *testing.T, token string) string {
+	parsed, _, err := new(jwt.Parser).ParseUnverified(token, jwt.MapClaims{})
+	if err != nil {
+		t.Fatalf("ParseUnverified: %s", err)
+	}
+	kid, _ := parsed.Header["kid"].(string)
+	return kid
+}
+
+func TestTokensOfEveryAlgorithm(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmHS256, AlgorithmRS256, AlgorithmEdDSA} {
+		t.Run(algorithm, func(t *testing.T) {
+			store := NewMemoryKeyStore(memory.NewStore())
+			key, err := RotateKey(store, algorithm)
+			if err != nil {
+				t.Fatalf("RotateKey: %s", err)
+			}
+			a := newTestAuth(store)
+
+			token, err := a.GenerateToken("alice", "session")
+			if err != nil {
+				t.Fatalf("GenerateToken: %s", err)
+			}
+			if kid := tokenKid(t, token); kid != key.Kid {
+				t.Errorf("expected kid %s, got %s", key.Kid, kid)
+			}
+
+			claims, err := a.VerifyToken(token)
+			if err != nil || claims.Username != "alice" || claims.SessionId != "session" || claims.IssuedAt == 0 {
+				t.Errorf("VerifyToken = %+v, %v", claims, err)
+			}
+
+			_, err = a.VerifyToken(token[:len(token)-4] + "AAAA")
+			if _, ok := err.(entities.UnauthorizedError); !ok {
+				t.Errorf("a tampered token must be rejected with an unauthorized error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestGenerateTokenCreatesFirstKey(t *testing.T) {
+	store := NewMemoryKeyStore(memory.NewStore())
+	a := newTestAuth(store)
+
+	token, err := a.GenerateToken("alice", "session")
+	if err != nil {
+		t.Fatalf("GenerateToken: %s", err)
+	}
+
+	keys, _ := store.Keys()
+	if len(keys) != 1 || keys[0].Kid != tokenKid(t, token) {
+		t.Errorf("expected the token to be signed by a new key, keys: %+v", keys)
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	store := NewMemoryKeyStore(memory.NewStore())
+	oldKey, err := RotateKey(store, AlgorithmEdDSA)
+	if err != nil {
+		t.Fatalf("RotateKey: %s", err)
+	}
+	a := newTestAuth(store)
+
+	oldToken, err := a.GenerateToken("alice", "session")
+	if err != nil {
+		t.Fatalf("GenerateToken: %s", err)
+	}
+
+	newKey, err := RotateKey(store, AlgorithmRS256)
+	if err != nil {
+		t.Fatalf("RotateKey: %s", err)
+	}
+
+	newToken, err := a.GenerateToken("alice", "session")
+	if err != nil {
+		t.Fatalf("GenerateToken: %s", err)
+	}
+	if kid := tokenKid(t, newToken); kid != newKey.Kid {
+		t.Errorf("expected tokens signed by the new key %s, got %s", newKey.Kid, kid)
+	}
+
+	if _, err := a.VerifyToken(oldToken); err != nil {
+		t.Errorf("a token of the previous key must verify until it is retired: %s", err)
+	}
+
+	err = RetireKey(store, oldKey.Kid)
+	if err != nil {
+		t.Fatalf("RetireKey: %s", err)
+	}
+
+	if _, err := a.VerifyToken(oldToken); err == nil {
+		t.Error("a token of a retired key must be rejected")
+	}
+	if _, err := a.VerifyToken(newToken); err != nil {
+		t.Errorf("VerifyToken: %s", err)
+	}
+}
+
+func TestVerifyTokenRejectsAlgorithmMismatch(t *testing.T) {
+	store := NewMemoryKeyStore(memory.NewStore())
+	key, err := RotateKey(store, AlgorithmRS256)
+	if err != nil {
+		t.Fatalf("RotateKey: %s", err)
+	}
+	a := newTestAuth(store)
+
+	jwks, err := a.JWKS()
+	if err != nil {
+		t.Fatalf("JWKS: %s", err)
+	}
+
+	// A HS256 token using the public key as secret, with the kid of the RSA key
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "mallory"})
+	token.Header["kid"] = key.Kid
+	forged, err := token.SignedString([]byte(jwks.Keys[0].N))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = a.VerifyToken(forged)
+	if _, ok := err.(entities.UnauthorizedError); !ok {
+		t.Errorf("expected an unauthorized error, got %v", err)
+	}
+
+	// Unsigned tokens and tokens without kid
+	for _, tokenString := range []string{
+		"eyJhbGciOiJub25lIiwidHlwIjoiSldUIn0.eyJzdWIiOiJtYWxsb3J5In0.",
+		"not a token",
+	} {
+		_, err = a.VerifyToken(tokenString)
+		if _, ok := err.(entities.UnauthorizedError); !ok {
+			t.Errorf("VerifyToken(%q): expected an unauthorized error, got %v", tokenString, err)
+		}
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	store := NewMemoryKeyStore(memory.NewStore())
+	for _, algorithm := range []string{AlgorithmHS256, AlgorithmRS256, AlgorithmEdDSA} {
+		if _, err := RotateKey(store, algorithm); err != nil {
+			t.Fatalf("RotateKey: %s", err)
+		}
+	}
+
+	jwks, err := newTestAuth(store).JWKS()
+	if err != nil {
+		t.Fatalf("JWKS: %s", err)
+	}
+
+	document, _ := json.Marshal(jwks)
+	if len(jwks.Keys) != 2 || jwks.Keys[0].Kty != "OKP" || jwks.Keys[1].Kty != "RSA" {
+		t.Fatalf("expected the Ed25519 and RSA public keys, newest first, got %s", document)
+	}
+	if jwks.Keys[1].E != "AQAB" || jwks.Keys[0].Crv != "Ed25519" {
+		t.Errorf("unexpected keys %s", document)
+	}
+	if strings.Contains(string(document), "HS256") {
+		t.Errorf("HS256 secrets must not be published, got %s", document)
+	}
+}
+
+func TestStaticKeyStore(t *testing.T) {
+	key, err := GenerateKey(AlgorithmEdDSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	retired, err := GenerateKey(AlgorithmHS256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	retired.Status = entities.KeyStatusRetired
+
+	data, _ := json.Marshal([]entities.SigningKey{key, retired})
+	store := &staticKeyStore{source: "test", read: func() ([]byte, error) {
+		return data, nil
+	}}
+
+	keys, err := store.Keys()
+	if err != nil || len(keys) != 1 || keys[0].Kid != key.Kid {
+		t.Fatalf("Keys = %+v, %v", keys, err)
+	}
+
+	a := newTestAuth(store)
+	token, err := a.GenerateToken("alice", "session")
+	if err != nil {
+		t.Fatalf("GenerateToken: %s", err)
+	}
+	if _, err := a.VerifyToken(token); err != nil {
+		t.Errorf("VerifyToken: %s", err)
+	}
+
+	if _, err := RotateKey(store, AlgorithmEdDSA); err != ErrReadOnlyKeyStore {
+		t.Errorf("expected ErrReadOnlyKeyStore, got %v", err)
+	}
+}
@@ -0,0 +1,241 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	PasswordScrypt   = "scrypt"
+	PasswordBcrypt   = "bcrypt"
+	PasswordArgon2id = "argon2id"
+)
+
+// Parameters of new hashes, hashes made with other parameters are outdated
+const (
+	passwordSaltLength = 16
+	passwordHashLength = 32
+
+	scryptLogN = 15
+	scryptR    = 8
+	scryptP    = 1
+
+	bcryptCost = bcrypt.DefaultCost
+
+	// https://cheatsheetseries.owasp.org/cheatsheets/Password_Storage_Cheat_Sheet.html#argon2id
+	argon2Memory  = 19 * 1024
+	argon2Time    = 2
+	argon2Threads = 1
+)
+
+var ErrInvalidPasswordHash = errors.New("invalid password hash")
+
+// PasswordHasher hashes passwords into self describing values holding the algorithm,
+// its parameters and a random salt per password:
+//
+//	$scrypt$ln=15,r=8,p=1$<salt>$<hash>
+//	$argon2id$v=19$m=19456,t=2,p=1$<salt>$<hash>
+//	$2a$10$<salt and hash>
+type PasswordHasher interface {
+	Hash(password string) ([]byte, error)
+	// Verify tells if password matches hash, and if hash is outdated and should be
+	// replaced by a new one made with the current algorithm and parameters
+	Verify(password string, hash []byte) (bool, bool, error)
+}
+
+// NewPasswordHasher returns a PasswordHasher making new hashes with algorithm,
+// it verifies hashes made with any of the supported algorithms
+func NewPasswordHasher(algorithm string) (PasswordHasher, error) {
+	switch algorithm {
+	case PasswordScrypt, PasswordBcrypt, PasswordArgon2id:
+		return &passwordHasher{algorithm: algorithm}, nil
+	default:
+		return nil, fmt.Errorf("unknown password hash algorithm %q", algorithm)
+	}
+}
+
+// defaultPasswordHasher uses PASSWORD_HASH_ALGORITHM, argon2id by default
+func defaultPasswordHasher() (PasswordHasher, error) {
+	algorithm := os.Getenv("PASSWORD_HASH_ALGORITHM")
+	if algorithm == "" {
+		algorithm = PasswordArgon2id
+	}
+	return NewPasswordHasher(algorithm)
+}
+
+type passwordHasher struct {
+	algorithm string
+}
+
+func (h *passwordHasher) Hash(password string) ([]byte, error) {
+	if h.algorithm == PasswordBcrypt {
+		return bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
+	}
+
+	salt := make([]byte, passwordSaltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	var params string
+	var key []byte
+
+	switch h.algorithm {
+	case PasswordScrypt:
+		params = fmt.Sprintf("ln=%d,r=%d,p=%d", scryptLogN, scryptR, scryptP)
+		key, err = scrypt.Key([]byte(password), salt, 1<<scryptLogN, scryptR, scryptP, passwordHashLength)
+		if err != nil {
+			return nil, err
+		}
+	case PasswordArgon2id:
+		params = fmt.Sprintf("v=%d$m=%d,t=%d,p=%d", argon2.Version, argon2Memory, argon2Time, argon2Threads)
+		key = argon2.IDKey([]byte(password), salt, argon2Time, argon2Memory, argon2Threads, passwordHashLength)
+	}
+
+	return []byte(fmt.Sprintf("$%s$%s$%s$%s", h.algorithm, params,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key))), nil
+}
+
+func (h *passwordHasher) Verify(password string, hash []byte) (bool, bool, error) {
+	if isLegacyPasswordHash(hash) {
+		key, err := legacyScrypt(password)
+		if err != nil {
+			return false, false, err
+		}
+		return subtle.ConstantTimeCompare(key, hash) == 1, true, nil
+	}
+
+	if bytes.HasPrefix(hash, []byte("$2")) {
+		return h.verifyBcrypt(password, hash)
+	}
+
+	// $<algorithm>$[v=<version>$]<params>$<salt>$<hash>
+	fields := strings.Split(string(hash), "$")
+	if len(fields) < 5 || fields[0] != "" {
+		return false, false, ErrInvalidPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(fields[len(fields)-2])
+	if err != nil {
+		return false, false, ErrInvalidPasswordHash
+	}
+	expected, err := base64.RawStdEncoding.DecodeString(fields[len(fields)-1])
+	if err != nil || len(expected) == 0 {
+		return false, false, ErrInvalidPasswordHash
+	}
+
+	var key []byte
+	var outdated bool
+
+	switch fields[1] {
+	case PasswordScrypt:
+		params, err := parsePasswordParams(fields[2], "ln", "r", "p")
+		if err != nil || len(fields) != 5 || params["ln"] > 30 {
+			return false, false, ErrInvalidPasswordHash
+		}
+
+		key, err = scrypt.Key([]byte(password), salt, 1<<params["ln"], params["r"], params["p"], len(expected))
+		if err != nil {
+			return false, false, err
+		}
+		outdated = params["ln"] != scryptLogN || params["r"] != scryptR || params["p"] != scryptP
+	case PasswordArgon2id:
+		if len(fields) != 6 || fields[2] != fmt.Sprintf("v=%d", argon2.Version) {
+			return false, false, ErrInvalidPasswordHash
+		}
+		params, err := parsePasswordParams(fields[3], "m", "t", "p")
+		if err != nil || params["p"] > 255 {
+			return false, false, ErrInvalidPasswordHash
+		}
+
+		key = argon2.IDKey([]byte(password), salt, uint32(params["t"]), uint32(params["m"]), uint8(params["p"]), uint32(len(expected)))
+		outdated = params["m"] != argon2Memory || params["t"] != argon2Time || params["p"] != argon2Threads
+	default:
+		return false, false, ErrInvalidPasswordHash
+	}
+
+	if subtle.ConstantTimeCompare(key, expected) != 1 {
+		return false, false, nil
+	}
+
+	return true, outdated || fields[1] != h.algorithm || len(salt) != passwordSaltLength || len(expected) != passwordHashLength, nil
+}
+
+func (h *passwordHasher) verifyBcrypt(password string, hash []byte) (bool, bool, error) {
+	err := bcrypt.CompareHashAndPassword(hash, []byte(password))
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, ErrInvalidPasswordHash
+	}
+
+	cost, err := bcrypt.Cost(hash)
+	if err != nil {
+		return false, false, ErrInvalidPasswordHash
+	}
+
+	return true, h.algorithm != PasswordBcrypt || cost != bcryptCost, nil
+}
+
+// parsePasswordParams parses "name=value,..." holding exactly the positive integers names
+func parsePasswordParams(s string, names ...string) (map[string]int, error) {
+	params := make(map[string]int, len(names))
+	for _, param := range strings.Split(s, ",") {
+		parts := strings.SplitN(param, "=", 2)
+		if len(parts) != 2 {
+			return nil, ErrInvalidPasswordHash
+		}
+		value, err := strconv.Atoi(parts[1])
+		if err != nil || value <= 0 {
+			return nil, ErrInvalidPasswordHash
+		}
+		params[parts[0]] = value
+	}
+
+	if len(params) != len(names) {
+		return nil, ErrInvalidPasswordHash
+	}
+	for _, name := range names {
+		if _, ok := params[name]; !ok {
+			return nil, ErrInvalidPasswordHash
+		}
+	}
+
+	return params, nil
+}
+
+// Legacy hashes are raw scrypt keys, all made with the same salt
+const legacyPasswordKeyLength = 64
+
+var legacyPasswordSalt = []byte("KU2YVXA7BSNExJIvemcdz61eL86IJDCC")
+
+func isLegacyPasswordHash(hash []byte) bool {
+	if len(hash) != legacyPasswordKeyLength {
+		return false
+	}
+	for _, prefix := range []string{"$scrypt$", "$argon2id$", "$2a$", "$2b$", "$2y$"} {
+		if bytes.HasPrefix(hash, []byte(prefix)) {
+			return false
+		}
+	}
+	return true
+}
+
+func legacyScrypt(password string) ([]byte, error) {
+	// https://godoc.org/golang.org/x/crypto/scrypt
+	return scrypt.Key([]byte(password), legacyPasswordSalt, 32768, 8, 1, legacyPasswordKeyLength)
+}
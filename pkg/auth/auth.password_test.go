@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPasswordHasher(t *testing.T) {
+	for _, algorithm := range []string{PasswordScrypt, PasswordBcrypt, PasswordArgon2id} {
+		t.Run(algorithm, func(t *testing.T) {
+			hasher, err := NewPasswordHasher(algorithm)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			hash, err := hasher.Hash("secret")
+			if err != nil {
+				t.Fatalf("Hash: %s", err)
+			}
+
+			other, err := hasher.Hash("secret")
+			if err != nil {
+				t.Fatalf("Hash: %s", err)
+			}
+			if bytes.Equal(hash, other) {
+				t.Errorf("the same password must produce different hashes, got %s twice", hash)
+			}
+
+			match, outdated, err := hasher.Verify("secret", hash)
+			if err != nil || !match || outdated {
+				t.Errorf("Verify(right password) = %v, %v, %v", match, outdated, err)
+			}
+
+			match, _, err = hasher.Verify("wrong", hash)
+			if err != nil || match {
+				t.Errorf("Verify(wrong password) = %v, %v", match, err)
+			}
+		})
+	}
+}
+
+func TestPasswordHasherOutdated(t *testing.T) {
+	scryptHasher, _ := NewPasswordHasher(PasswordScrypt)
+	argon2Hasher, _ := NewPasswordHasher(PasswordArgon2id)
+
+	hash, err := scryptHasher.Hash("secret")
+	if err != nil {
+		t.Fatalf("Hash: %s", err)
+	}
+
+	match, outdated, err := argon2Hasher.Verify("secret", hash)
+	if err != nil || !match || !outdated {
+		t.Errorf("a hash of another algorithm must match and be outdated, got %v, %v, %v", match, outdated, err)
+	}
+
+	legacy, err := legacyScrypt("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	match, outdated, err = argon2Hasher.Verify("secret", legacy)
+	if err != nil || !match || !outdated {
+		t.Errorf("a legacy hash must match and be outdated, got %v, %v, %v", match, outdated, err)
+	}
+
+	match, _, err = argon2Hasher.Verify("wrong", legacy)
+	if err != nil || match {
+		t.Errorf("a legacy hash must not match a wrong password, got %v, %v", match, err)
+	}
+}
+
+func TestPasswordHasherInvalidHash(t *testing.T) {
+	hasher, _ := NewPasswordHasher(PasswordArgon2id)
+
+	for _, hash := range []string{
+		"",
+		"plain",
+		"$md5$abc$def",
+		"$scrypt$ln=15,r=8$c2FsdA$aGFzaA",
+		"$argon2id$v=19$m=19456,t=2,p=1$!!!$aGFzaA",
+	} {
+		_, _, err := hasher.Verify("secret", []byte(hash))
+		if err != ErrInvalidPasswordHash {
+			t.Errorf("Verify(%q) error = %v, expected ErrInvalidPasswordHash", hash, err)
+		}
+	}
+}
@@ -1,100 +1,179 @@
 package auth
 
 import (
-	"fmt"
 	"strings"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/ferjmc/cms/entities"
-	"golang.org/x/crypto/scrypt"
 )
 
 type Auth interface {
-	Scrypt(password string) ([]byte, error)
-	GenerateToken(username string) (string, error)
-	VerifyAuthorization(auth string) (string, string, error)
-	VerifyToken(tokenString string) (string, error)
+	// HashPassword hashes password with a random salt, see PasswordHasher
+	HashPassword(password string) ([]byte, error)
+	// VerifyPassword tells if password matches hash, and if hash should be replaced by a new one
+	VerifyPassword(password string, hash []byte) (bool, bool, error)
+	// GenerateToken returns a short lived access token of username, issued for the session sessionId
+	GenerateToken(username, sessionId string) (string, error)
+	VerifyAuthorization(auth string) (Claims, string, error)
+	// VerifyOptionalAuthorization is VerifyAuthorization for the endpoints open to anonymous readers,
+	// it returns nil claims without an Authorization header. An invalid header is still rejected.
+	VerifyOptionalAuthorization(auth string) (*Claims, string, error)
+	VerifyToken(tokenString string) (Claims, error)
+	// JWKS returns the public keys verifying the tokens, for other services
+	JWKS() (JWKS, error)
 }
 
+// New returns an Auth using the keys of the store selected by JWT_KEY_STORE
 func New() Auth {
-	return &auth{}
+	return &auth{
+		keys: defaultKeyring(),
+	}
+}
+
+// NewWithKeyStore returns an Auth using the keys of store
+func NewWithKeyStore(store KeyStore) Auth {
+	return &auth{
+		keys: newKeyring(store),
+	}
 }
 
-const TokenExpirationDays = 60
-const PasswordKeyLength = 64
+// Access tokens can't be revoked one by one, they are short lived and renewed with a refresh token
+const AccessTokenExpiration = 15 * time.Minute
 
-var passwordSalt = []byte("KU2YVXA7BSNExJIvemcdz61eL86IJDCC")
-var jwtSecret = []byte("C92cw5od80NCWIvu4NZ8AKp5NyTbnBmG") // TODO: Generate random secrets and store in DynamoDB
+// Claims of a verified access token
+type Claims struct {
+	Username  string
+	SessionId string
+	// IssuedAt in nanoseconds, tokens issued before the revocation of the tokens of a user are rejected
+	IssuedAt int64
+}
 
-type auth struct{}
+type auth struct {
+	keys *keyring
+}
 
-func (a *auth) Scrypt(password string) ([]byte, error) {
-	// https://godoc.org/golang.org/x/crypto/scrypt
-	passwordHash, err := scrypt.Key([]byte(password), passwordSalt, 32768, 8, 1, PasswordKeyLength)
+func (a *auth) HashPassword(password string) ([]byte, error) {
+	hasher, err := defaultPasswordHasher()
 	if err != nil {
 		return nil, err
 	}
+	return hasher.Hash(password)
+}
 
-	return passwordHash, nil
+func (a *auth) VerifyPassword(password string, hash []byte) (bool, bool, error) {
+	hasher, err := defaultPasswordHasher()
+	if err != nil {
+		return false, false, err
+	}
+	return hasher.Verify(password, hash)
 }
 
-func (a *auth) GenerateToken(username string) (string, error) {
+func (a *auth) GenerateToken(username, sessionId string) (string, error) {
 	now := time.Now().UTC()
-	exp := now.AddDate(0, 0, TokenExpirationDays).Unix()
+	exp := now.Add(AccessTokenExpiration).Unix()
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	key, err := a.keys.signingKey()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(key.method, jwt.MapClaims{
 		"sub": username,
+		"sid": sessionId,
+		// NumericDate may have a fraction, revocations are precise to the microsecond
+		"iat": float64(now.UnixNano()/int64(time.Microsecond)) / 1e6,
 		"exp": exp,
 	})
+	token.Header["kid"] = key.Kid
 
-	return token.SignedString(jwtSecret)
+	return token.SignedString(key.signingKey)
 }
 
-func (a *auth) VerifyAuthorization(auth string) (string, string, error) {
+func (a *auth) VerifyAuthorization(auth string) (Claims, string, error) {
 	parts := strings.SplitN(auth, " ", 2)
 	if len(parts) != 2 || parts[0] != "Token" {
-		return "", "", entities.NewInputError("Authorization", "invalid format")
+		return Claims{}, "", entities.NewUnauthorizedError("Authorization", "invalid format")
 	}
 
 	token := parts[1]
-	username, err := a.VerifyToken(token)
-	return username, token, err
+	claims, err := a.VerifyToken(token)
+	return claims, token, err
 }
 
-func (a *auth) VerifyToken(tokenString string) (string, error) {
-	token, err := jwt.Parse(tokenString, validateToken)
+func (a *auth) VerifyOptionalAuthorization(auth string) (*Claims, string, error) {
+	if auth == "" {
+		return nil, "", nil
+	}
 
+	claims, token, err := a.VerifyAuthorization(auth)
 	if err != nil {
-		return "", err
+		return nil, "", err
+	}
+	return &claims, token, nil
+}
+
+func (a *auth) VerifyToken(tokenString string) (Claims, error) {
+	// Errors reading the keys are not the fault of the token
+	var keysErr error
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		key, err := a.keys.verificationKey(token)
+		if _, ok := err.(entities.UnauthorizedError); err != nil && !ok {
+			keysErr = err
+		}
+		return key, err
+	})
+
+	if keysErr != nil {
+		return Claims{}, keysErr
+	}
+
+	if validationErr, ok := err.(*jwt.ValidationError); ok {
+		if validationErr.Errors&jwt.ValidationErrorExpired != 0 {
+			return Claims{}, entities.NewUnauthorizedError("Authorization", "token expired")
+		}
+		if unauthorizedErr, ok := validationErr.Inner.(entities.UnauthorizedError); ok {
+			return Claims{}, unauthorizedErr
+		}
+		return Claims{}, entities.NewUnauthorizedError("Authorization", "invalid token")
+	}
+
+	if err != nil {
+		return Claims{}, err
 	}
 
 	if token == nil || !token.Valid {
-		return "", entities.NewInputError("Authorization", "invalid token")
+		return Claims{}, entities.NewUnauthorizedError("Authorization", "invalid token")
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return "", entities.NewInputError("Authorization", "invalid claims")
+		return Claims{}, entities.NewUnauthorizedError("Authorization", "invalid claims")
 	}
 
 	if !claims.VerifyExpiresAt(time.Now().UTC().Unix(), true) {
-		return "", entities.NewInputError("Authorization", "token expired")
+		return Claims{}, entities.NewUnauthorizedError("Authorization", "token expired")
 	}
 
 	username, ok := claims["sub"].(string)
 	if !ok {
-		return "", entities.NewInputError("Authorization", "sub missing")
+		return Claims{}, entities.NewUnauthorizedError("Authorization", "sub missing")
 	}
 
-	return username, nil
-}
-
-func validateToken(token *jwt.Token) (interface{}, error) {
-	_, ok := token.Method.(*jwt.SigningMethodHMAC)
+	issuedAt, ok := claims["iat"].(float64)
 	if !ok {
-		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		return Claims{}, entities.NewUnauthorizedError("Authorization", "iat missing")
 	}
 
-	return jwtSecret, nil
+	sessionId, _ := claims["sid"].(string)
+
+	return Claims{
+		Username:  username,
+		SessionId: sessionId,
+		IssuedAt:  int64(issuedAt * float64(time.Second)),
+	}, nil
+}
+
+func (a *auth) JWKS() (JWKS, error) {
+	return a.keys.jwks()
 }
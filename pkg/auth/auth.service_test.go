@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/memory"
+)
+
+func TestVerifyOptionalAuthorization(t *testing.T) {
+	a := newTestAuth(NewMemoryKeyStore(memory.NewStore()))
+
+	token, err := a.GenerateToken("alice", "session")
+	if err != nil {
+		t.Fatalf("GenerateToken: %s", err)
+	}
+
+	t.Run("It must accept a missing header as anonymous", func(t *testing.T) {
+		claims, _, err := a.VerifyOptionalAuthorization("")
+		if err != nil || claims != nil {
+			t.Errorf("expected no claims and no error, got %+v (%v)", claims, err)
+		}
+	})
+
+	t.Run("It must verify a token", func(t *testing.T) {
+		claims, verified, err := a.VerifyOptionalAuthorization("Token " + token)
+		if err != nil || claims == nil || claims.Username != "alice" || verified != token {
+			t.Errorf("expected the claims of alice, got %+v (%v)", claims, err)
+		}
+	})
+
+	t.Run("It must reject an invalid header", func(t *testing.T) {
+		for _, header := range []string{"Token", "Bearer " + token, "Token " + token + "x"} {
+			_, _, err := a.VerifyOptionalAuthorization(header)
+			if _, ok := err.(entities.UnauthorizedError); !ok {
+				t.Errorf("%q: expected an unauthorized error, got %v", header, err)
+			}
+		}
+	})
+}
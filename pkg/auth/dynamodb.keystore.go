@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/dynamo"
+)
+
+type dynamoKeyStore struct{}
+
+func (d *dynamoKeyStore) Keys() ([]entities.SigningKey, error) {
+	// The table only holds a few keys
+	scanKeys := dynamodb.ScanInput{
+		TableName:                 aws.String(dynamo.SigningKeyTableName),
+		FilterExpression:          aws.String("#status<>:retired"),
+		ExpressionAttributeNames:  map[string]*string{"#status": aws.String("Status")},
+		ExpressionAttributeValues: dynamo.StringKey(":retired", entities.KeyStatusRetired),
+	}
+
+	keys := make([]entities.SigningKey, 0)
+	var unmarshalErr error
+
+	err := dynamo.DynamoDB().ScanPages(&scanKeys, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		pageKeys := make([]entities.SigningKey, 0, len(page.Items))
+		unmarshalErr = dynamodbattribute.UnmarshalListOfMaps(page.Items, &pageKeys)
+		if unmarshalErr != nil {
+			return false
+		}
+
+		keys = append(keys, pageKeys...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, unmarshalErr
+}
+
+func (d *dynamoKeyStore) PutKey(key entities.SigningKey) error {
+	item, err := dynamodbattribute.MarshalMap(key)
+	if err != nil {
+		return err
+	}
+
+	putKey := dynamodb.PutItemInput{
+		TableName:           aws.String(dynamo.SigningKeyTableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(Kid)"),
+	}
+
+	_, err = dynamo.DynamoDB().PutItem(&putKey)
+
+	return err
+}
+
+func (d *dynamoKeyStore) SetKeyStatus(kid, status string) error {
+	updateKey := dynamodb.UpdateItemInput{
+		TableName:                 aws.String(dynamo.SigningKeyTableName),
+		Key:                       dynamo.StringKey("Kid", kid),
+		UpdateExpression:          aws.String("SET #status=:status"),
+		ConditionExpression:       aws.String("attribute_exists(Kid)"),
+		ExpressionAttributeNames:  map[string]*string{"#status": aws.String("Status")},
+		ExpressionAttributeValues: dynamo.StringKey(":status", status),
+	}
+
+	_, err := dynamo.DynamoDB().UpdateItem(&updateKey)
+	if dynamo.IsConditionalCheckFailed(err) {
+		return ErrKeyNotFound
+	}
+
+	return err
+}
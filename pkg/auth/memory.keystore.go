@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/memory"
+)
+
+type memoryKeyStore struct {
+	store *memory.Store
+}
+
+// NewMemoryKeyStore returns a KeyStore backed by store, mostly useful
+// for tests which need an isolated store
+func NewMemoryKeyStore(store *memory.Store) KeyStore {
+	return &memoryKeyStore{
+		store: store,
+	}
+}
+
+func (m *memoryKeyStore) Keys() ([]entities.SigningKey, error) {
+	m.store.RLock()
+	defer m.store.RUnlock()
+
+	keys := make([]entities.SigningKey, 0, len(m.store.SigningKeys))
+	for _, key := range m.store.SigningKeys {
+		if key.Status != entities.KeyStatusRetired {
+			key.Material = memory.CopyBytes(key.Material)
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
+
+func (m *memoryKeyStore) PutKey(key entities.SigningKey) error {
+	m.store.Lock()
+	defer m.store.Unlock()
+
+	key.Material = memory.CopyBytes(key.Material)
+	m.store.SigningKeys[key.Kid] = key
+
+	return nil
+}
+
+func (m *memoryKeyStore) SetKeyStatus(kid, status string) error {
+	m.store.Lock()
+	defer m.store.Unlock()
+
+	key, ok := m.store.SigningKeys[kid]
+	if !ok {
+		return ErrKeyNotFound
+	}
+
+	key.Status = status
+	m.store.SigningKeys[kid] = key
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ferjmc/cms/entities"
+)
+
+// staticKeyStore reads a JSON list of keys, like
+// [{"kid": "2021-09", "algorithm": "EdDSA", "material": "<base64 PKCS #8 DER>", "status": "active"}]
+type staticKeyStore struct {
+	source string
+	read   func() ([]byte, error)
+}
+
+func (s *staticKeyStore) Keys() ([]entities.SigningKey, error) {
+	data, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+
+	var all []entities.SigningKey
+	err = json.Unmarshal(data, &all)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing keys in %s: %w", s.source, err)
+	}
+
+	keys := make([]entities.SigningKey, 0, len(all))
+	for _, key := range all {
+		if key.Status != entities.KeyStatusRetired {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
+
+func (s *staticKeyStore) PutKey(key entities.SigningKey) error {
+	return ErrReadOnlyKeyStore
+}
+
+func (s *staticKeyStore) SetKeyStatus(kid, status string) error {
+	return ErrReadOnlyKeyStore
+}
@@ -0,0 +1,42 @@
+package comment
+
+import (
+	"errors"
+
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/memory"
+)
+
+const (
+	InstanceDynamodb int = iota
+	InstanceMemory
+)
+
+func errCommentNotFound() error {
+	return entities.NewNotFoundError("comment", "not found")
+}
+
+func errNotAuthor() error {
+	return entities.NewForbiddenError("comment", "only the comment or article author can delete a comment")
+}
+
+type CommentRepository interface {
+	PutComment(comment *entities.Comment) error
+	GetComment(articleId, commentId int64) (*entities.Comment, error)
+	GetComments(articleId int64) ([]entities.Comment, error)
+	DeleteComment(comment entities.Comment) error
+	// RenameAuthor gives the comments of oldUsername to newUsername, one page after cursor.
+	// It returns the cursor of the next page, "" once done.
+	RenameAuthor(oldUsername, newUsername, cursor string, limit int) (string, error)
+}
+
+func NewCommentRepository(instance int) (CommentRepository, error) {
+	switch instance {
+	case InstanceDynamodb:
+		return &dynamoRepository{}, nil
+	case InstanceMemory:
+		return NewMemoryRepository(memory.DB()), nil
+	default:
+		return nil, errors.New("repository instance not found")
+	}
+}
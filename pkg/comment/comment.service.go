@@ -0,0 +1,122 @@
+package comment
+
+import (
+	"time"
+
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/memory"
+	"github.com/ferjmc/cms/pkg/auth"
+	"github.com/ferjmc/cms/pkg/follow"
+	"github.com/ferjmc/cms/pkg/user"
+)
+
+type CommentService interface {
+	// PutComment adds a comment written by user to the article
+	PutComment(user *entities.User, article entities.Article, comment *entities.Comment) error
+	// GetComments retrieves the comments of an article, newest first
+	GetComments(article entities.Article) ([]entities.Comment, error)
+	// DeleteComment removes a comment, allowed for the comment author, the article author and the moderators
+	DeleteComment(user *entities.User, article entities.Article, commentId int64) error
+	// GetCommentRelatedProperties retrieves the author of each comment and whether user follows them
+	GetCommentRelatedProperties(user *entities.User, comments []entities.Comment) ([]entities.User, []bool, error)
+}
+
+func NewCommentService(r CommentRepository, u user.UserService, f follow.FollowService) CommentService {
+	return &commentService{
+		repository: r,
+		users:      u,
+		follows:    f,
+	}
+}
+
+func New(opts ...func(CommentService) CommentService) CommentService {
+	var serv CommentService
+	for _, opt := range opts {
+		serv = opt(serv)
+	}
+	// whitout opts retrieves service with dynamo by default
+	if len(opts) <= 0 {
+		if memory.Enabled() {
+			return WithMemory(serv)
+		}
+		return WithDynamoDB(serv)
+	}
+	return serv
+}
+
+func WithDynamoDB(serv CommentService) CommentService {
+	user := user.New(user.WithDynamoDB)
+	follow := follow.New(follow.WithDynamoDB)
+	repo, err := NewCommentRepository(InstanceDynamodb)
+	if err != nil {
+		return serv
+	}
+	return NewCommentService(repo, user, follow)
+}
+
+func WithMemory(serv CommentService) CommentService {
+	user := user.New(user.WithMemory)
+	follow := follow.New(follow.WithMemory)
+	repo, err := NewCommentRepository(InstanceMemory)
+	if err != nil {
+		return serv
+	}
+	return NewCommentService(repo, user, follow)
+}
+
+type commentService struct {
+	repository CommentRepository
+	users      user.UserService
+	follows    follow.FollowService
+}
+
+func (s *commentService) PutComment(user *entities.User, article entities.Article, comment *entities.Comment) error {
+	err := comment.Validate()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().UnixNano()
+	comment.ArticleId = article.ArticleId
+	comment.Author = user.Username
+	comment.CreatedAt = now
+	comment.UpdatedAt = now
+
+	return s.repository.PutComment(comment)
+}
+
+func (s *commentService) GetComments(article entities.Article) ([]entities.Comment, error) {
+	return s.repository.GetComments(article.ArticleId)
+}
+
+func (s *commentService) DeleteComment(user *entities.User, article entities.Article, commentId int64) error {
+	comment, err := s.repository.GetComment(article.ArticleId, commentId)
+	if err != nil {
+		return err
+	}
+
+	if user == nil || (comment.Author != user.Username && article.Author != user.Username && !auth.Can(user, auth.PermissionDeleteAnyComment)) {
+		return errNotAuthor()
+	}
+
+	return s.repository.DeleteComment(*comment)
+}
+
+func (s *commentService) GetCommentRelatedProperties(user *entities.User, comments []entities.Comment) ([]entities.User, []bool, error) {
+	authorUsernames := make([]string, 0, len(comments))
+	for _, comment := range comments {
+		authorUsernames = append(authorUsernames, comment.Author)
+	}
+
+	authors, err := s.users.GetUserListByUsername(authorUsernames)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	following, err := s.follows.IsFollowing(user, authorUsernames)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return authors, following, nil
+}
@@ -0,0 +1,144 @@
+package comment
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/dynamo"
+	"github.com/ferjmc/cms/pkg/rand"
+)
+
+type dynamoRepository struct{}
+
+func (d *dynamoRepository) PutComment(comment *entities.Comment) error {
+	const maxAttempt = 5
+
+	// Try to find a unique comment id
+	for attempt := 0; ; attempt++ {
+		err := putCommentWithRandomId(comment)
+
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= maxAttempt {
+			return err
+		}
+
+		if !dynamo.IsConditionalCheckFailed(err) {
+			return err
+		}
+
+		rand.CommentIdRand.RenewSeed()
+	}
+}
+
+func putCommentWithRandomId(comment *entities.Comment) error {
+	comment.CommentId = 1 + rand.CommentIdRand.Get().Int63n(entities.MaxCommentId-1) // range: [1, MaxCommentId)
+
+	item, err := dynamodbattribute.MarshalMap(comment)
+	if err != nil {
+		return err
+	}
+
+	putComment := dynamodb.PutItemInput{
+		TableName:           aws.String(dynamo.CommentTableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(CommentId)"),
+	}
+
+	_, err = dynamo.DynamoDB().PutItem(&putComment)
+
+	return err
+}
+
+func (d *dynamoRepository) GetComment(articleId, commentId int64) (*entities.Comment, error) {
+	key := dynamo.AWSObject{
+		"ArticleId": dynamo.Int64Value(articleId),
+		"CommentId": dynamo.Int64Value(commentId),
+	}
+
+	var comment entities.Comment
+	found, err := dynamo.GetItemByKey(dynamo.CommentTableName, key, &comment)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, errCommentNotFound()
+	}
+
+	return &comment, nil
+}
+
+func (d *dynamoRepository) GetComments(articleId int64) ([]entities.Comment, error) {
+	queryComments := dynamodb.QueryInput{
+		TableName:                 aws.String(dynamo.CommentTableName),
+		IndexName:                 aws.String("CreatedAt"),
+		KeyConditionExpression:    aws.String("ArticleId=:articleId"),
+		ExpressionAttributeValues: dynamo.Int64Key(":articleId", articleId),
+		ScanIndexForward:          aws.Bool(false),
+	}
+
+	const queryInitialCapacity = 16
+	items, err := dynamo.QueryItems(&queryComments, 0, queryInitialCapacity)
+	if err != nil {
+		return nil, err
+	}
+
+	comments := make([]entities.Comment, len(items))
+	err = dynamodbattribute.UnmarshalListOfMaps(items, &comments)
+	if err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+func (d *dynamoRepository) DeleteComment(comment entities.Comment) error {
+	deleteComment := dynamodb.DeleteItemInput{
+		TableName: aws.String(dynamo.CommentTableName),
+		Key: dynamo.AWSObject{
+			"ArticleId": dynamo.Int64Value(comment.ArticleId),
+			"CommentId": dynamo.Int64Value(comment.CommentId),
+		},
+	}
+
+	_, err := dynamo.DynamoDB().DeleteItem(&deleteComment)
+
+	return err
+}
+
+func (d *dynamoRepository) RenameAuthor(oldUsername, newUsername, cursor string, limit int) (string, error) {
+	queryComments := dynamodb.QueryInput{
+		TableName:                 aws.String(dynamo.CommentTableName),
+		IndexName:                 aws.String("Author"),
+		KeyConditionExpression:    aws.String("Author=:old"),
+		ExpressionAttributeValues: dynamo.StringKey(":old", oldUsername),
+		ProjectionExpression:      aws.String("ArticleId, CommentId"),
+	}
+
+	items, next, err := dynamo.QueryPage(&queryComments, cursor, limit)
+	if err != nil {
+		return "", err
+	}
+
+	for _, key := range items {
+		_, err = dynamo.DynamoDB().UpdateItem(&dynamodb.UpdateItemInput{
+			TableName:           aws.String(dynamo.CommentTableName),
+			Key:                 key,
+			UpdateExpression:    aws.String("SET Author=:new"),
+			ConditionExpression: aws.String("Author=:old"),
+			ExpressionAttributeValues: dynamo.AWSObject{
+				":old": dynamo.StringValue(oldUsername),
+				":new": dynamo.StringValue(newUsername),
+			},
+		})
+		if err != nil && !dynamo.IsConditionalCheckFailed(err) {
+			return "", err
+		}
+	}
+
+	return next, nil
+}
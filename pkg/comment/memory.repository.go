@@ -0,0 +1,101 @@
+package comment
+
+import (
+	"sort"
+
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/memory"
+	"github.com/ferjmc/cms/pkg/rand"
+)
+
+type memoryRepository struct {
+	store *memory.Store
+}
+
+// NewMemoryRepository returns a CommentRepository backed by store, mostly useful
+// for tests which need an isolated store
+func NewMemoryRepository(store *memory.Store) CommentRepository {
+	return &memoryRepository{
+		store: store,
+	}
+}
+
+func (m *memoryRepository) PutComment(comment *entities.Comment) error {
+	m.store.Lock()
+	defer m.store.Unlock()
+
+	comments, ok := m.store.Comments[comment.ArticleId]
+	if !ok {
+		comments = make(map[int64]entities.Comment)
+		m.store.Comments[comment.ArticleId] = comments
+	}
+
+	// Try to find a unique comment id
+	for {
+		comment.CommentId = 1 + rand.CommentIdRand.Get().Int63n(entities.MaxCommentId-1) // range: [1, MaxCommentId)
+		if _, ok := comments[comment.CommentId]; !ok {
+			break
+		}
+	}
+
+	comments[comment.CommentId] = *comment
+
+	return nil
+}
+
+func (m *memoryRepository) GetComment(articleId, commentId int64) (*entities.Comment, error) {
+	m.store.RLock()
+	defer m.store.RUnlock()
+
+	comment, ok := m.store.Comments[articleId][commentId]
+	if !ok {
+		return nil, errCommentNotFound()
+	}
+
+	return &comment, nil
+}
+
+func (m *memoryRepository) GetComments(articleId int64) ([]entities.Comment, error) {
+	m.store.RLock()
+	defer m.store.RUnlock()
+
+	comments := make([]entities.Comment, 0, len(m.store.Comments[articleId]))
+	for _, comment := range m.store.Comments[articleId] {
+		comments = append(comments, comment)
+	}
+
+	sort.Slice(comments, func(i, j int) bool {
+		if comments[i].CreatedAt != comments[j].CreatedAt {
+			return comments[i].CreatedAt > comments[j].CreatedAt
+		}
+		return comments[i].CommentId > comments[j].CommentId
+	})
+
+	return comments, nil
+}
+
+func (m *memoryRepository) DeleteComment(comment entities.Comment) error {
+	m.store.Lock()
+	defer m.store.Unlock()
+
+	delete(m.store.Comments[comment.ArticleId], comment.CommentId)
+
+	return nil
+}
+
+// RenameAuthor rewrites every comment at once, there is no next page
+func (m *memoryRepository) RenameAuthor(oldUsername, newUsername, cursor string, limit int) (string, error) {
+	m.store.Lock()
+	defer m.store.Unlock()
+
+	for _, comments := range m.store.Comments {
+		for commentId, comment := range comments {
+			if comment.Author == oldUsername {
+				comment.Author = newUsername
+				comments[commentId] = comment
+			}
+		}
+	}
+
+	return "", nil
+}
@@ -0,0 +1,172 @@
+package follow
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/dynamo"
+	"github.com/ferjmc/cms/internal/timeline"
+)
+
+// The rename steps move one page of follows at a time and may run again on the same page
+// after a failure, a follow which was already moved is skipped.
+
+func (d *dynamoRepository) RenameFollower(oldUsername, newUsername, cursor string, limit int) (string, error) {
+	queryFollows := dynamodb.QueryInput{
+		TableName:                 aws.String(dynamo.FollowTableName),
+		KeyConditionExpression:    aws.String("Follower=:follower"),
+		ExpressionAttributeValues: dynamo.StringKey(":follower", oldUsername),
+	}
+
+	follows, next, err := queryFollowPage(&queryFollows, cursor, limit)
+	if err != nil {
+		return "", err
+	}
+
+	for _, follow := range follows {
+		moved := follow
+		moved.Follower = newUsername
+
+		// The followers count of the publisher doesn't change
+		err = moveFollow(follow, moved)
+		if err != nil {
+			return "", err
+		}
+
+		if timeline.Enabled() {
+			err = timeline.Prune(follow.Follower, follow.Publisher)
+			if err != nil {
+				return "", err
+			}
+			err = timeline.Backfill(moved.Follower, moved.Publisher)
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return next, nil
+}
+
+func (d *dynamoRepository) RenamePublisher(oldUsername, newUsername, cursor string, limit int) (string, error) {
+	queryFollows := dynamodb.QueryInput{
+		TableName:                 aws.String(dynamo.FollowTableName),
+		IndexName:                 aws.String("Publisher"),
+		KeyConditionExpression:    aws.String("Publisher=:publisher"),
+		ExpressionAttributeValues: dynamo.StringKey(":publisher", oldUsername),
+	}
+
+	follows, next, err := queryFollowPage(&queryFollows, cursor, limit)
+	if err != nil {
+		return "", err
+	}
+
+	// Feeds must keep reading the articles of a popular publisher which were not fanned out
+	var publisher entities.Publisher
+	_, err = dynamo.GetItemByKey(dynamo.PublisherTableName, dynamo.StringKey("Publisher", oldUsername), &publisher)
+	if err != nil {
+		return "", err
+	}
+	if publisher.Popular == 1 {
+		err = timeline.MarkPopular(newUsername)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	for _, follow := range follows {
+		moved := follow
+		moved.Publisher = newUsername
+
+		// The follower is counted by the new publisher instead of the old one
+		err = moveFollow(follow, moved, timeline.CountFollowerItem(newUsername, 1), timeline.CountFollowerItem(oldUsername, -1))
+		if err != nil {
+			return "", err
+		}
+
+		// Until every follow is moved, the new publisher counts too few followers and its new
+		// articles are fanned out to the followers moved so far only
+		if timeline.Enabled() {
+			err = timeline.Backfill(moved.Follower, moved.Publisher)
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return next, nil
+}
+
+func queryFollowPage(queryFollows *dynamodb.QueryInput, cursor string, limit int) ([]entities.Follow, string, error) {
+	items, next, err := dynamo.QueryPage(queryFollows, cursor, limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	follows := make([]entities.Follow, len(items))
+	err = dynamodbattribute.UnmarshalListOfMaps(items, &follows)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return follows, next, nil
+}
+
+// moveFollow replaces follow by moved along with counts. When moved already exists, follow is
+// only deleted and uncounted.
+func moveFollow(follow, moved entities.Follow, counts ...*dynamodb.TransactWriteItem) error {
+	oldKey, err := dynamodbattribute.MarshalMap(follow)
+	if err != nil {
+		return err
+	}
+
+	item, err := dynamodbattribute.MarshalMap(moved)
+	if err != nil {
+		return err
+	}
+
+	deleteOld := &dynamodb.TransactWriteItem{
+		Delete: &dynamodb.Delete{
+			TableName:           aws.String(dynamo.FollowTableName),
+			Key:                 oldKey,
+			ConditionExpression: aws.String("attribute_exists(Follower)"),
+		},
+	}
+
+	transactItems := []*dynamodb.TransactWriteItem{
+		deleteOld,
+		{
+			Put: &dynamodb.Put{
+				TableName:           aws.String(dynamo.FollowTableName),
+				Item:                item,
+				ConditionExpression: aws.String("attribute_not_exists(Follower)"),
+			},
+		},
+	}
+
+	_, err = dynamo.DynamoDB().TransactWriteItems(&dynamodb.TransactWriteItemsInput{
+		TransactItems: append(transactItems, counts...),
+	})
+	canceled, ok := dynamo.AsTransactionCanceled(err)
+	if !ok || !canceled.AnyConditionFailed() {
+		return err
+	}
+	if canceled.ConditionFailed(0) {
+		// Already moved
+		return nil
+	}
+
+	// Followed again under the new username
+	_, err = dynamo.DynamoDB().TransactWriteItems(&dynamodb.TransactWriteItemsInput{
+		TransactItems: []*dynamodb.TransactWriteItem{
+			deleteOld,
+			timeline.CountFollowerItem(follow.Publisher, -1),
+		},
+	})
+	if dynamo.IsConditionalCheckFailed(err) {
+		return nil
+	}
+
+	return err
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/ferjmc/cms/entities"
 	"github.com/ferjmc/cms/internal/dynamo"
+	"github.com/ferjmc/cms/internal/timeline"
 )
 
 type dynamoRepository struct{}
@@ -67,14 +68,31 @@ func (d *dynamoRepository) Follow(follow entities.Follow) error {
 		return err
 	}
 
-	putFollow := dynamodb.PutItemInput{
-		TableName: aws.String(dynamo.FollowTableName),
-		Item:      item,
+	// Put the follow and count the new follower, unless already following
+	_, err = dynamo.DynamoDB().TransactWriteItems(&dynamodb.TransactWriteItemsInput{
+		TransactItems: []*dynamodb.TransactWriteItem{
+			{
+				Put: &dynamodb.Put{
+					TableName:           aws.String(dynamo.FollowTableName),
+					Item:                item,
+					ConditionExpression: aws.String("attribute_not_exists(Follower)"),
+				},
+			},
+			timeline.CountFollowerItem(follow.Publisher, 1),
+		},
+	})
+	if dynamo.IsConditionalCheckFailed(err) {
+		return nil
+	}
+	if err != nil {
+		return err
 	}
 
-	_, err = dynamo.DynamoDB().PutItem(&putFollow)
+	if timeline.Enabled() {
+		return timeline.Backfill(follow.Follower, follow.Publisher)
+	}
 
-	return err
+	return nil
 }
 
 func (d *dynamoRepository) Unfollow(follow entities.Follow) error {
@@ -83,12 +101,29 @@ func (d *dynamoRepository) Unfollow(follow entities.Follow) error {
 		return err
 	}
 
-	deleteFollow := dynamodb.DeleteItemInput{
-		TableName: aws.String(dynamo.FollowTableName),
-		Key:       item,
+	// Delete the follow and uncount the follower, unless not following
+	_, err = dynamo.DynamoDB().TransactWriteItems(&dynamodb.TransactWriteItemsInput{
+		TransactItems: []*dynamodb.TransactWriteItem{
+			{
+				Delete: &dynamodb.Delete{
+					TableName:           aws.String(dynamo.FollowTableName),
+					Key:                 item,
+					ConditionExpression: aws.String("attribute_exists(Follower)"),
+				},
+			},
+			timeline.CountFollowerItem(follow.Publisher, -1),
+		},
+	})
+	if dynamo.IsConditionalCheckFailed(err) {
+		return nil
+	}
+	if err != nil {
+		return err
 	}
 
-	_, err = dynamo.DynamoDB().DeleteItem(&deleteFollow)
+	if timeline.Enabled() {
+		return timeline.Prune(follow.Follower, follow.Publisher)
+	}
 
-	return err
+	return nil
 }
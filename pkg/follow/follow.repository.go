@@ -4,22 +4,30 @@ import (
 	"errors"
 
 	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/memory"
 )
 
 const (
 	InstanceDynamodb int = iota
+	InstanceMemory
 )
 
 type FollowRepository interface {
 	IsFollowing(follower *entities.User, publishers []string) ([]bool, error)
 	Follow(follow entities.Follow) error
 	Unfollow(follow entities.Follow) error
+	// RenameFollower and RenamePublisher give the follows of oldUsername, as a follower and as a publisher,
+	// to newUsername, one page after cursor. They return the cursor of the next page, "" once done.
+	RenameFollower(oldUsername, newUsername, cursor string, limit int) (string, error)
+	RenamePublisher(oldUsername, newUsername, cursor string, limit int) (string, error)
 }
 
 func NewFollowRepository(instance int) (FollowRepository, error) {
 	switch instance {
 	case InstanceDynamodb:
 		return &dynamoRepository{}, nil
+	case InstanceMemory:
+		return NewMemoryRepository(memory.DB()), nil
 	default:
 		return nil, errors.New("repository instance not found")
 	}
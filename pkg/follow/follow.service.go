@@ -1,6 +1,9 @@
 package follow
 
-import "github.com/ferjmc/cms/entities"
+import (
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/memory"
+)
 
 type FollowService interface {
 	// IsFollowing given a user and a list of publishers, retrieves a list
@@ -23,6 +26,9 @@ func New(opts ...func(FollowService) FollowService) FollowService {
 	}
 	// whitout opts retrieves service with dynamo by default
 	if len(opts) <= 0 {
+		if memory.Enabled() {
+			return WithMemory(serv)
+		}
 		return WithDynamoDB(serv)
 	}
 	return serv
@@ -36,6 +42,14 @@ func WithDynamoDB(serv FollowService) FollowService {
 	return NewFollowService(repo)
 }
 
+func WithMemory(serv FollowService) FollowService {
+	repo, err := NewFollowRepository(InstanceMemory)
+	if err != nil {
+		return serv
+	}
+	return NewFollowService(repo)
+}
+
 type followService struct {
 	repository FollowRepository
 }
@@ -0,0 +1,85 @@
+// Package followtest checks that a follow.FollowRepository implementation behaves
+// like the DynamoDB one, so every backend can be plugged into the same suite.
+package followtest
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/pkg/follow"
+)
+
+// Run executes the conformance suite. Usernames are unique per run,
+// so the suite can share a repository with other data.
+func Run(t *testing.T, newRepository func() follow.FollowRepository) {
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+	name := func(name string) string {
+		return name + "-" + suffix
+	}
+
+	t.Run("IsFollowing is aligned with the requested publishers", func(t *testing.T) {
+		repo := newRepository()
+		follower := entities.User{Username: name("follower")}
+		mustFollow(t, repo, follower.Username, name("alice"))
+		mustFollow(t, repo, follower.Username, name("carol"))
+
+		publishers := []string{name("carol"), name("bob"), name("alice"), name("carol")}
+		following, err := repo.IsFollowing(&follower, publishers)
+		if err != nil {
+			t.Fatalf("IsFollowing: %s", err)
+		}
+
+		expected := []bool{true, false, true, true}
+		if len(following) != len(expected) {
+			t.Fatalf("expected %d results, got %d", len(expected), len(following))
+		}
+		for i := range expected {
+			if following[i] != expected[i] {
+				t.Errorf("%s: expected %v, got %v", publishers[i], expected[i], following[i])
+			}
+		}
+	})
+
+	t.Run("Follow is idempotent and Unfollow removes it", func(t *testing.T) {
+		repo := newRepository()
+		follower := entities.User{Username: name("fan")}
+		mustFollow(t, repo, follower.Username, name("dave"))
+		mustFollow(t, repo, follower.Username, name("dave"))
+
+		err := repo.Unfollow(entities.Follow{Follower: follower.Username, Publisher: name("dave")})
+		if err != nil {
+			t.Fatalf("Unfollow: %s", err)
+		}
+
+		following, err := repo.IsFollowing(&follower, []string{name("dave")})
+		if err != nil {
+			t.Fatalf("IsFollowing: %s", err)
+		}
+		if len(following) != 1 || following[0] {
+			t.Errorf("expected [false] after unfollowing, got %v", following)
+		}
+	})
+
+	t.Run("Follows are directed", func(t *testing.T) {
+		repo := newRepository()
+		mustFollow(t, repo, name("erin"), name("frank"))
+
+		frank := entities.User{Username: name("frank")}
+		following, err := repo.IsFollowing(&frank, []string{name("erin")})
+		if err != nil {
+			t.Fatalf("IsFollowing: %s", err)
+		}
+		if len(following) != 1 || following[0] {
+			t.Errorf("frank doesn't follow erin, got %v", following)
+		}
+	})
+}
+
+func mustFollow(t *testing.T, repo follow.FollowRepository, follower, publisher string) {
+	t.Helper()
+	if err := repo.Follow(entities.Follow{Follower: follower, Publisher: publisher}); err != nil {
+		t.Fatalf("Follow(%s, %s): %s", follower, publisher, err)
+	}
+}
@@ -0,0 +1,82 @@
+package follow
+
+import (
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/memory"
+)
+
+type memoryRepository struct {
+	store *memory.Store
+}
+
+// NewMemoryRepository returns a FollowRepository backed by store, mostly useful
+// for tests which need an isolated store
+func NewMemoryRepository(store *memory.Store) FollowRepository {
+	return &memoryRepository{
+		store: store,
+	}
+}
+
+func (m *memoryRepository) IsFollowing(follower *entities.User, publishers []string) ([]bool, error) {
+	m.store.RLock()
+	defer m.store.RUnlock()
+
+	following := make([]bool, 0, len(publishers))
+	for _, publisher := range publishers {
+		following = append(following, m.store.Follows[entities.Follow{
+			Follower:  follower.Username,
+			Publisher: publisher,
+		}])
+	}
+
+	return following, nil
+}
+
+func (m *memoryRepository) Follow(follow entities.Follow) error {
+	m.store.Lock()
+	defer m.store.Unlock()
+
+	m.store.Follows[follow] = true
+
+	return nil
+}
+
+func (m *memoryRepository) Unfollow(follow entities.Follow) error {
+	m.store.Lock()
+	defer m.store.Unlock()
+
+	delete(m.store.Follows, follow)
+
+	return nil
+}
+
+// The memory rename steps move every follow at once, there is no next page
+func (m *memoryRepository) RenameFollower(oldUsername, newUsername, cursor string, limit int) (string, error) {
+	m.store.Lock()
+	defer m.store.Unlock()
+
+	for follow := range m.store.Follows {
+		if follow.Follower == oldUsername {
+			delete(m.store.Follows, follow)
+			follow.Follower = newUsername
+			m.store.Follows[follow] = true
+		}
+	}
+
+	return "", nil
+}
+
+func (m *memoryRepository) RenamePublisher(oldUsername, newUsername, cursor string, limit int) (string, error) {
+	m.store.Lock()
+	defer m.store.Unlock()
+
+	for follow := range m.store.Follows {
+		if follow.Publisher == oldUsername {
+			delete(m.store.Follows, follow)
+			follow.Publisher = newUsername
+			m.store.Follows[follow] = true
+		}
+	}
+
+	return "", nil
+}
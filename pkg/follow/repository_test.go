@@ -0,0 +1,30 @@
+package follow_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ferjmc/cms/internal/memory"
+	"github.com/ferjmc/cms/pkg/follow"
+	"github.com/ferjmc/cms/pkg/follow/followtest"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	followtest.Run(t, func() follow.FollowRepository {
+		return follow.NewMemoryRepository(memory.NewStore())
+	})
+}
+
+func TestDynamoDBRepository(t *testing.T) {
+	if os.Getenv("DYNAMODB_ENDPOINT") == "" {
+		t.Skip("DYNAMODB_ENDPOINT is not set")
+	}
+
+	followtest.Run(t, func() follow.FollowRepository {
+		repo, err := follow.NewFollowRepository(follow.InstanceDynamodb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return repo
+	})
+}
@@ -0,0 +1,81 @@
+package mail
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ferjmc/cms/internal/memory"
+)
+
+const (
+	MailerSMTP int = iota
+	MailerFile
+	MailerMemory
+)
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(message Message) error
+}
+
+// NewMailer returns the mailer of instance. The SMTP mailer is configured by
+// SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD and MAIL_FROM, the file
+// mailer writes every message to a file in MAIL_DIR, and the memory mailer keeps
+// them in the process wide Outbox. The last two are stand-ins for local use.
+func NewMailer(instance int) (Mailer, error) {
+	switch instance {
+	case MailerSMTP:
+		return newSMTPMailer()
+	case MailerFile:
+		return newFileMailer()
+	case MailerMemory:
+		return Outbox(), nil
+	default:
+		return nil, errors.New("mailer instance not found")
+	}
+}
+
+// New returns the mailer selected by MAILER: smtp, file or memory. Without it,
+// messages are kept in memory along with the memory repositories, otherwise sent by SMTP.
+// A misconfigured mailer is still returned, it fails to send every message.
+func New() Mailer {
+	var mailer Mailer
+	var err error
+
+	switch os.Getenv("MAILER") {
+	case "smtp":
+		mailer, err = NewMailer(MailerSMTP)
+	case "file":
+		mailer, err = NewMailer(MailerFile)
+	case "memory":
+		mailer, err = NewMailer(MailerMemory)
+	case "":
+		if memory.Enabled() {
+			mailer, err = NewMailer(MailerMemory)
+		} else {
+			mailer, err = NewMailer(MailerSMTP)
+		}
+	default:
+		err = fmt.Errorf("unknown MAILER %q", os.Getenv("MAILER"))
+	}
+
+	if err != nil {
+		return &failingMailer{err: err}
+	}
+	return mailer
+}
+
+type failingMailer struct {
+	err error
+}
+
+func (m *failingMailer) Send(message Message) error {
+	return m.err
+}
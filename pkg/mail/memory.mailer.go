@@ -0,0 +1,47 @@
+package mail
+
+import (
+	"log"
+	"sync"
+)
+
+// MemoryMailer keeps the messages instead of sending them, and logs them so
+// that they can be read when running the local server
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+var once sync.Once
+var outbox *MemoryMailer
+
+// Outbox returns the process wide MemoryMailer
+func Outbox() *MemoryMailer {
+	once.Do(func() {
+		outbox = &MemoryMailer{}
+	})
+	return outbox
+}
+
+func (m *MemoryMailer) Send(message Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, message)
+	log.Printf("mail to %s: %s\n%s", message.To, message.Subject, message.Body)
+	return nil
+}
+
+// Messages returns the messages sent to to, oldest first
+func (m *MemoryMailer) Messages(to string) []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	messages := make([]Message, 0)
+	for _, message := range m.messages {
+		if message.To == to {
+			messages = append(messages, message)
+		}
+	}
+	return messages
+}
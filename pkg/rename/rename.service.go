@@ -0,0 +1,155 @@
+// Package rename runs the username migrations recorded when a user is renamed. Each migration
+// rewrites the articles, comments, favorites and follows still naming the old username, one page
+// at a time, and saves its progress after every page so it resumes where it stopped.
+package rename
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/memory"
+	"github.com/ferjmc/cms/pkg/article"
+	"github.com/ferjmc/cms/pkg/comment"
+	"github.com/ferjmc/cms/pkg/follow"
+	"github.com/ferjmc/cms/pkg/user"
+)
+
+type RenameService interface {
+	// MigratePending runs the pending migrations until they are done or until deadline,
+	// and returns how many were completed
+	MigratePending(deadline time.Time) (int, error)
+}
+
+// Repositories holds the repositories of the rows naming users
+type Repositories struct {
+	Articles article.ArticleRepository
+	Comments comment.CommentRepository
+	Follows  follow.FollowRepository
+}
+
+func NewRenameService(u user.UserService, r Repositories) RenameService {
+	return &renameService{
+		users:        u,
+		repositories: r,
+	}
+}
+
+func New(opts ...func(RenameService) RenameService) RenameService {
+	var serv RenameService
+	for _, opt := range opts {
+		serv = opt(serv)
+	}
+	// whitout opts retrieves service with dynamo by default
+	if len(opts) <= 0 {
+		if memory.Enabled() {
+			return WithMemory(serv)
+		}
+		return WithDynamoDB(serv)
+	}
+	return serv
+}
+
+func WithDynamoDB(serv RenameService) RenameService {
+	return withInstance(serv, user.WithDynamoDB, article.InstanceDynamodb, comment.InstanceDynamodb, follow.InstanceDynamodb)
+}
+
+func WithMemory(serv RenameService) RenameService {
+	return withInstance(serv, user.WithMemory, article.InstanceMemory, comment.InstanceMemory, follow.InstanceMemory)
+}
+
+func withInstance(serv RenameService, userOpt func(user.UserService) user.UserService, articleInstance, commentInstance, followInstance int) RenameService {
+	articles, err := article.NewArticleRepository(articleInstance)
+	if err != nil {
+		return serv
+	}
+	comments, err := comment.NewCommentRepository(commentInstance)
+	if err != nil {
+		return serv
+	}
+	follows, err := follow.NewFollowRepository(followInstance)
+	if err != nil {
+		return serv
+	}
+
+	return NewRenameService(user.New(userOpt), Repositories{
+		Articles: articles,
+		Comments: comments,
+		Follows:  follows,
+	})
+}
+
+// Number of migrations read at once, and of rows rewritten per page
+const (
+	migrationBatchSize = 10
+	pageSize           = 25
+)
+
+type renameService struct {
+	users        user.UserService
+	repositories Repositories
+}
+
+// step rewrites a page of rows of oldUsername and returns the cursor of the next one
+type step func(oldUsername, newUsername, cursor string, limit int) (string, error)
+
+func (s *renameService) steps() map[string]step {
+	return map[string]step{
+		entities.UsernameMigrationStepArticles:  s.repositories.Articles.RenameAuthor,
+		entities.UsernameMigrationStepComments:  s.repositories.Comments.RenameAuthor,
+		entities.UsernameMigrationStepFavorites: s.repositories.Articles.RenameFavorites,
+		entities.UsernameMigrationStepFollowing: s.repositories.Follows.RenameFollower,
+		entities.UsernameMigrationStepFollowers: s.repositories.Follows.RenamePublisher,
+	}
+}
+
+func (s *renameService) MigratePending(deadline time.Time) (int, error) {
+	migrations, err := s.users.GetPendingUsernameMigrations(migrationBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	completed := 0
+	for _, migration := range migrations {
+		err = s.migrate(&migration, deadline)
+		if err != nil {
+			return completed, err
+		}
+		if !migration.Done() {
+			// Out of time, the next run resumes it
+			break
+		}
+		completed++
+	}
+
+	return completed, nil
+}
+
+func (s *renameService) migrate(migration *entities.UsernameMigration, deadline time.Time) error {
+	// The user may have been renamed again meanwhile, the rows go straight to its current username
+	target, err := s.users.GetUserByUsernameOrAlias(migration.NewUsername)
+	if err != nil {
+		return err
+	}
+
+	steps := s.steps()
+	for !migration.Done() && time.Now().Before(deadline) {
+		run, ok := steps[migration.Step]
+		if !ok {
+			return fmt.Errorf("migration of %s: unknown step %q", migration.OldUsername, migration.Step)
+		}
+
+		next, err := run(migration.OldUsername, target.Username, migration.Cursor, pageSize)
+		if err != nil {
+			return err
+		}
+
+		migration.Advance(next, time.Now().UTC().UnixNano())
+		err = s.users.UpdateUsernameMigration(*migration)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package rename
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/memory"
+	"github.com/ferjmc/cms/pkg/article"
+	"github.com/ferjmc/cms/pkg/comment"
+	"github.com/ferjmc/cms/pkg/follow"
+	"github.com/ferjmc/cms/pkg/user"
+)
+
+func TestMigratePending(t *testing.T) {
+	store := memory.NewStore()
+	users := user.NewMemoryRepository(store)
+	articles := article.NewMemoryRepository(store)
+	comments := comment.NewMemoryRepository(store)
+	follows := follow.NewMemoryRepository(store)
+	serv := NewRenameService(user.NewUserService(users), Repositories{
+		Articles: articles,
+		Comments: comments,
+		Follows:  follows,
+	})
+
+	for _, username := range []string{"alice", "bob"} {
+		err := users.PutUser(entities.User{Username: username, Email: username + "@fake.com", PasswordHash: []byte("hash")})
+		if err != nil {
+			t.Fatalf("PutUser: %s", err)
+		}
+	}
+
+	now := time.Now().UTC().UnixNano()
+	put := func(author string) entities.Article {
+		a := entities.Article{Title: "title of " + author, Description: "description", Body: "body", Author: author, UpdatedBy: author, CreatedAt: now, UpdatedAt: now}
+		if err := articles.PutArticle(&a); err != nil {
+			t.Fatalf("PutArticle: %s", err)
+		}
+		return a
+	}
+	aliceArticle := put("alice")
+	bobArticle := put("bob")
+
+	if _, err := articles.FavoriteArticle("alice", bobArticle.ArticleId); err != nil {
+		t.Fatalf("FavoriteArticle: %s", err)
+	}
+	aliceComment := entities.Comment{Body: "comment", Author: "alice"}
+	if err := comments.PutComment(&aliceComment); err != nil {
+		t.Fatalf("PutComment: %s", err)
+	}
+	for _, f := range []entities.Follow{{Follower: "alice", Publisher: "bob"}, {Follower: "bob", Publisher: "alice"}} {
+		if err := follows.Follow(f); err != nil {
+			t.Fatalf("Follow: %s", err)
+		}
+	}
+
+	// Renamed twice before the first migration runs
+	rename := func(from, to string) {
+		old, err := users.UserByUsername(from)
+		if err != nil {
+			t.Fatalf("UserByUsername: %s", err)
+		}
+		renamed := *old
+		renamed.Username = to
+		if err := users.RenameUser(*old, renamed); err != nil {
+			t.Fatalf("RenameUser: %s", err)
+		}
+	}
+	rename("alice", "alicia")
+	rename("alicia", "ali")
+
+	completed, err := serv.MigratePending(time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("MigratePending: %s", err)
+	}
+	if completed != 2 {
+		t.Errorf("expected 2 completed migrations, got %d", completed)
+	}
+
+	stored, err := articles.GetArticleById(aliceArticle.ArticleId)
+	if err != nil || stored.Author != "ali" || stored.UpdatedBy != "ali" {
+		t.Errorf("expected the article of ali, got %+v (%v)", stored, err)
+	}
+
+	favorited, err := articles.IsArticleFavoritedByUser(&entities.User{Username: "ali"}, []entities.Article{bobArticle})
+	if err != nil || !favorited[0] {
+		t.Errorf("expected ali to favorite the article of bob, got %v (%v)", favorited, err)
+	}
+
+	storedComment, err := comments.GetComment(aliceComment.ArticleId, aliceComment.CommentId)
+	if err != nil || storedComment.Author != "ali" {
+		t.Errorf("expected the comment of ali, got %+v (%v)", storedComment, err)
+	}
+
+	following, err := follows.IsFollowing(&entities.User{Username: "ali"}, []string{"bob"})
+	if err != nil || !following[0] {
+		t.Errorf("expected ali to follow bob, got %v (%v)", following, err)
+	}
+	following, err = follows.IsFollowing(&entities.User{Username: "bob"}, []string{"ali", "alice"})
+	if err != nil || !following[0] || following[1] {
+		t.Errorf("expected bob to follow ali only, got %v (%v)", following, err)
+	}
+
+	pending, err := users.PendingUsernameMigrations(10)
+	if err != nil || len(pending) != 0 {
+		t.Errorf("expected no pending migration, got %+v (%v)", pending, err)
+	}
+}
+
+func TestMigratePendingResumes(t *testing.T) {
+	store := memory.NewStore()
+	users := user.NewMemoryRepository(store)
+	serv := NewRenameService(user.NewUserService(users), Repositories{
+		Articles: article.NewMemoryRepository(store),
+		Comments: comment.NewMemoryRepository(store),
+		Follows:  follow.NewMemoryRepository(store),
+	})
+
+	alice := entities.User{Username: "alice", Email: "alice@fake.com", PasswordHash: []byte("hash")}
+	if err := users.PutUser(alice); err != nil {
+		t.Fatalf("PutUser: %s", err)
+	}
+	renamed := alice
+	renamed.Username = "alicia"
+	if err := users.RenameUser(alice, renamed); err != nil {
+		t.Fatalf("RenameUser: %s", err)
+	}
+
+	// Past the deadline, nothing runs
+	completed, err := serv.MigratePending(time.Now().Add(-time.Second))
+	if err != nil || completed != 0 {
+		t.Fatalf("expected no completed migration, got %d (%v)", completed, err)
+	}
+
+	pending, err := users.PendingUsernameMigrations(10)
+	if err != nil || len(pending) != 1 || pending[0].Step != entities.UsernameMigrationSteps[0] {
+		t.Fatalf("expected the migration to wait at its first step, got %+v (%v)", pending, err)
+	}
+
+	completed, err = serv.MigratePending(time.Now().Add(time.Minute))
+	if err != nil || completed != 1 {
+		t.Errorf("expected the migration to complete, got %d (%v)", completed, err)
+	}
+}
@@ -0,0 +1,115 @@
+package session
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/dynamo"
+)
+
+type dynamoRepository struct{}
+
+func (d *dynamoRepository) PutSession(session entities.Session) error {
+	item, err := dynamodbattribute.MarshalMap(session)
+	if err != nil {
+		return err
+	}
+
+	putSession := dynamodb.PutItemInput{
+		TableName:           aws.String(dynamo.SessionTableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(SessionId)"),
+	}
+
+	_, err = dynamo.DynamoDB().PutItem(&putSession)
+
+	return err
+}
+
+func (d *dynamoRepository) GetSession(sessionId string) (*entities.Session, error) {
+	var session entities.Session
+	found, err := dynamo.GetItemByKey(dynamo.SessionTableName, dynamo.StringKey("SessionId", sessionId), &session)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, ErrSessionNotFound
+	}
+
+	return &session, nil
+}
+
+func (d *dynamoRepository) RotateSession(session entities.Session, refreshTokenHash []byte, expiresAt int64) error {
+	updateSession := dynamodb.UpdateItemInput{
+		TableName:           aws.String(dynamo.SessionTableName),
+		Key:                 dynamo.StringKey("SessionId", session.SessionId),
+		UpdateExpression:    aws.String("SET PreviousTokenHash=:oldHash, RefreshTokenHash=:newHash, ExpiresAt=:expiresAt, #ttl=:ttl"),
+		ConditionExpression: aws.String("RefreshTokenHash=:oldHash"),
+		// TTL is a reserved word
+		ExpressionAttributeNames: map[string]*string{"#ttl": aws.String("TTL")},
+		ExpressionAttributeValues: dynamo.AWSObject{
+			":oldHash":   dynamo.BlobValue(session.RefreshTokenHash),
+			":newHash":   dynamo.BlobValue(refreshTokenHash),
+			":expiresAt": dynamo.Int64Value(expiresAt),
+			":ttl":       dynamo.Int64Value(session.TTL),
+		},
+	}
+
+	_, err := dynamo.DynamoDB().UpdateItem(&updateSession)
+	if dynamo.IsConditionalCheckFailed(err) {
+		return ErrSessionChanged
+	}
+
+	return err
+}
+
+func (d *dynamoRepository) DeleteSession(sessionId string) error {
+	deleteSession := dynamodb.DeleteItemInput{
+		TableName: aws.String(dynamo.SessionTableName),
+		Key:       dynamo.StringKey("SessionId", sessionId),
+	}
+
+	_, err := dynamo.DynamoDB().DeleteItem(&deleteSession)
+
+	return err
+}
+
+func (d *dynamoRepository) DeleteUserSessions(username string) error {
+	querySessions := dynamodb.QueryInput{
+		TableName:                 aws.String(dynamo.SessionTableName),
+		IndexName:                 aws.String("Username"),
+		KeyConditionExpression:    aws.String("Username=:username"),
+		ExpressionAttributeValues: dynamo.StringKey(":username", username),
+		ProjectionExpression:      aws.String("SessionId"),
+	}
+
+	requests := make([]*dynamodb.WriteRequest, 0)
+	var unmarshalErr error
+
+	err := dynamo.DynamoDB().QueryPages(&querySessions, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		sessions := make([]entities.Session, 0, len(page.Items))
+		unmarshalErr = dynamodbattribute.UnmarshalListOfMaps(page.Items, &sessions)
+		if unmarshalErr != nil {
+			return false
+		}
+
+		for _, session := range sessions {
+			requests = append(requests, &dynamodb.WriteRequest{
+				DeleteRequest: &dynamodb.DeleteRequest{
+					Key: dynamo.StringKey("SessionId", session.SessionId),
+				},
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	if unmarshalErr != nil {
+		return unmarshalErr
+	}
+
+	return dynamo.BatchWriteItems(dynamo.SessionTableName, requests)
+}
@@ -0,0 +1,85 @@
+package session
+
+import (
+	"bytes"
+
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/memory"
+)
+
+type memoryRepository struct {
+	store *memory.Store
+}
+
+// NewMemoryRepository returns a SessionRepository backed by store, mostly useful
+// for tests which need an isolated store
+func NewMemoryRepository(store *memory.Store) SessionRepository {
+	return &memoryRepository{
+		store: store,
+	}
+}
+
+func (m *memoryRepository) PutSession(session entities.Session) error {
+	m.store.Lock()
+	defer m.store.Unlock()
+
+	session.RefreshTokenHash = memory.CopyBytes(session.RefreshTokenHash)
+	session.PreviousTokenHash = memory.CopyBytes(session.PreviousTokenHash)
+	m.store.Sessions[session.SessionId] = session
+
+	return nil
+}
+
+func (m *memoryRepository) GetSession(sessionId string) (*entities.Session, error) {
+	m.store.RLock()
+	defer m.store.RUnlock()
+
+	session, ok := m.store.Sessions[sessionId]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+
+	session.RefreshTokenHash = memory.CopyBytes(session.RefreshTokenHash)
+	session.PreviousTokenHash = memory.CopyBytes(session.PreviousTokenHash)
+	return &session, nil
+}
+
+func (m *memoryRepository) RotateSession(session entities.Session, refreshTokenHash []byte, expiresAt int64) error {
+	m.store.Lock()
+	defer m.store.Unlock()
+
+	stored, ok := m.store.Sessions[session.SessionId]
+	if !ok || !bytes.Equal(stored.RefreshTokenHash, session.RefreshTokenHash) {
+		return ErrSessionChanged
+	}
+
+	stored.PreviousTokenHash = stored.RefreshTokenHash
+	stored.RefreshTokenHash = memory.CopyBytes(refreshTokenHash)
+	stored.ExpiresAt = expiresAt
+	stored.TTL = session.TTL
+	m.store.Sessions[session.SessionId] = stored
+
+	return nil
+}
+
+func (m *memoryRepository) DeleteSession(sessionId string) error {
+	m.store.Lock()
+	defer m.store.Unlock()
+
+	delete(m.store.Sessions, sessionId)
+
+	return nil
+}
+
+func (m *memoryRepository) DeleteUserSessions(username string) error {
+	m.store.Lock()
+	defer m.store.Unlock()
+
+	for sessionId, session := range m.store.Sessions {
+		if session.Username == username {
+			delete(m.store.Sessions, sessionId)
+		}
+	}
+
+	return nil
+}
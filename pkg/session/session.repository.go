@@ -0,0 +1,37 @@
+package session
+
+import (
+	"errors"
+
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/memory"
+)
+
+const (
+	InstanceDynamodb int = iota
+	InstanceMemory
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+var ErrSessionChanged = errors.New("session refreshed or deleted meanwhile")
+
+type SessionRepository interface {
+	PutSession(session entities.Session) error
+	GetSession(sessionId string) (*entities.Session, error)
+	// RotateSession replaces the refresh token of session, the current one becomes the previous one.
+	// It fails with ErrSessionChanged if the session was refreshed or deleted since it was read.
+	RotateSession(session entities.Session, refreshTokenHash []byte, expiresAt int64) error
+	DeleteSession(sessionId string) error
+	DeleteUserSessions(username string) error
+}
+
+func NewSessionRepository(instance int) (SessionRepository, error) {
+	switch instance {
+	case InstanceDynamodb:
+		return &dynamoRepository{}, nil
+	case InstanceMemory:
+		return NewMemoryRepository(memory.DB()), nil
+	default:
+		return nil, errors.New("repository instance not found")
+	}
+}
@@ -0,0 +1,244 @@
+package session
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/memory"
+	"github.com/ferjmc/cms/pkg/auth"
+	"github.com/ferjmc/cms/pkg/user"
+)
+
+// A session ends when its refresh token isn't used for RefreshTokenExpiration
+const RefreshTokenExpiration = 30 * 24 * time.Hour
+
+// Tokens are a short lived access token and the refresh token renewing it
+type Tokens struct {
+	Token        string
+	RefreshToken string
+}
+
+type SessionService interface {
+	// Create starts a new session of username
+	Create(username string) (Tokens, error)
+	// Refresh renews the tokens of the session of refreshToken, which can't be used again.
+	// Using it again ends the session, it may have been stolen. So does revoking the tokens of the user.
+	Refresh(refreshToken string) (string, Tokens, error)
+	// Logout ends the session of refreshToken
+	Logout(refreshToken string) error
+	// LogoutEverywhere ends every session of username
+	LogoutEverywhere(username string) error
+}
+
+func NewSessionService(r SessionRepository, u user.UserService) SessionService {
+	return &sessionService{
+		repository: r,
+		users:      u,
+		auth:       auth.New(),
+	}
+}
+
+func New(opts ...func(SessionService) SessionService) SessionService {
+	var serv SessionService
+	for _, opt := range opts {
+		serv = opt(serv)
+	}
+	// whitout opts retrieves service with dynamo by default
+	if len(opts) <= 0 {
+		if memory.Enabled() {
+			return WithMemory(serv)
+		}
+		return WithDynamoDB(serv)
+	}
+	return serv
+}
+
+func WithDynamoDB(serv SessionService) SessionService {
+	user := user.New(user.WithDynamoDB)
+	repo, err := NewSessionRepository(InstanceDynamodb)
+	if err != nil {
+		return serv
+	}
+	return NewSessionService(repo, user)
+}
+
+func WithMemory(serv SessionService) SessionService {
+	user := user.New(user.WithMemory)
+	repo, err := NewSessionRepository(InstanceMemory)
+	if err != nil {
+		return serv
+	}
+	return NewSessionService(repo, user)
+}
+
+type sessionService struct {
+	repository SessionRepository
+	users      user.UserService
+	auth       auth.Auth
+}
+
+func (s *sessionService) Create(username string) (Tokens, error) {
+	sessionId, err := randomToken(16)
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	secret, err := randomToken(32)
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(RefreshTokenExpiration)
+
+	session := entities.Session{
+		SessionId:        sessionId,
+		Username:         username,
+		RefreshTokenHash: hashSecret(secret),
+		CreatedAt:        now.UnixNano(),
+		ExpiresAt:        expiresAt.UnixNano(),
+		TTL:              expiresAt.Unix(),
+	}
+
+	err = s.repository.PutSession(session)
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	return s.newTokens(session, secret)
+}
+
+func (s *sessionService) Refresh(refreshToken string) (string, Tokens, error) {
+	session, secretHash, err := s.getSession(refreshToken)
+	if err != nil {
+		return "", Tokens{}, err
+	}
+
+	if subtle.ConstantTimeCompare(secretHash, session.RefreshTokenHash) != 1 {
+		if subtle.ConstantTimeCompare(secretHash, session.PreviousTokenHash) == 1 {
+			// Someone else holds the current refresh token, none of them can be trusted
+			err = s.repository.DeleteSession(session.SessionId)
+			if err != nil {
+				return "", Tokens{}, err
+			}
+			return "", Tokens{}, entities.NewUnauthorizedError("refreshToken", "has already been used")
+		}
+		return "", Tokens{}, entities.NewUnauthorizedError("refreshToken", "invalid")
+	}
+
+	err = s.checkNotRevoked(*session)
+	if err != nil {
+		return "", Tokens{}, err
+	}
+
+	secret, err := randomToken(32)
+	if err != nil {
+		return "", Tokens{}, err
+	}
+
+	expiresAt := time.Now().UTC().Add(RefreshTokenExpiration)
+	session.TTL = expiresAt.Unix()
+
+	err = s.repository.RotateSession(*session, hashSecret(secret), expiresAt.UnixNano())
+	if err == ErrSessionChanged {
+		return "", Tokens{}, entities.NewUnauthorizedError("refreshToken", "has already been used")
+	}
+	if err != nil {
+		return "", Tokens{}, err
+	}
+
+	tokens, err := s.newTokens(*session, secret)
+	return session.Username, tokens, err
+}
+
+func (s *sessionService) Logout(refreshToken string) error {
+	session, secretHash, err := s.getSession(refreshToken)
+	if err != nil {
+		return err
+	}
+
+	if subtle.ConstantTimeCompare(secretHash, session.RefreshTokenHash) != 1 {
+		return entities.NewUnauthorizedError("refreshToken", "invalid")
+	}
+
+	return s.repository.DeleteSession(session.SessionId)
+}
+
+func (s *sessionService) LogoutEverywhere(username string) error {
+	return s.repository.DeleteUserSessions(username)
+}
+
+// checkNotRevoked ends the session when the tokens of its user were revoked after it started,
+// like by a password change, or when its user was renamed or deleted
+func (s *sessionService) checkNotRevoked(session entities.Session) error {
+	user, err := s.users.GetUserByUsername(session.Username)
+	_, notFound := err.(entities.NotFoundError)
+	if err != nil && !notFound {
+		return err
+	}
+
+	if !notFound && session.CreatedAt >= user.TokensRevokedAt {
+		return nil
+	}
+
+	err = s.repository.DeleteSession(session.SessionId)
+	if err != nil {
+		return err
+	}
+	return entities.NewUnauthorizedError("refreshToken", "revoked")
+}
+
+// getSession returns the unexpired session of refreshToken and the hash of its secret
+func (s *sessionService) getSession(refreshToken string) (*entities.Session, []byte, error) {
+	parts := strings.SplitN(refreshToken, ".", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, nil, entities.NewUnauthorizedError("refreshToken", "invalid")
+	}
+
+	session, err := s.repository.GetSession(parts[0])
+	if err == ErrSessionNotFound {
+		return nil, nil, entities.NewUnauthorizedError("refreshToken", "invalid")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if time.Now().UTC().UnixNano() >= session.ExpiresAt {
+		return nil, nil, entities.NewUnauthorizedError("refreshToken", "expired")
+	}
+
+	return session, hashSecret(parts[1]), nil
+}
+
+// newTokens returns a new access token of session and the refresh token holding secret
+func (s *sessionService) newTokens(session entities.Session, secret string) (Tokens, error) {
+	token, err := s.auth.GenerateToken(session.Username, session.SessionId)
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	return Tokens{
+		Token:        token,
+		RefreshToken: session.SessionId + "." + secret,
+	}, nil
+}
+
+func randomToken(size int) (string, error) {
+	token := make([]byte, size)
+	_, err := rand.Read(token)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+// Secrets are random, a fast hash is enough to keep them out of the table
+func hashSecret(secret string) []byte {
+	hash := sha256.Sum256([]byte(secret))
+	return hash[:]
+}
@@ -0,0 +1,178 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/memory"
+	"github.com/ferjmc/cms/pkg/auth"
+	"github.com/ferjmc/cms/pkg/user"
+)
+
+func newTestService(t *testing.T) (*sessionService, *memory.Store) {
+	t.Helper()
+	store := memory.NewStore()
+	users := user.NewUserService(user.NewMemoryRepository(store))
+	for _, username := range []string{"alice", "bob"} {
+		err := users.PutUser(entities.User{Username: username, Email: username + "@fake.com"}, "123456")
+		if err != nil {
+			t.Fatalf("PutUser: %s", err)
+		}
+	}
+
+	return &sessionService{
+		repository: NewMemoryRepository(store),
+		users:      users,
+		auth:       auth.NewWithKeyStore(auth.NewMemoryKeyStore(store)),
+	}, store
+}
+
+func isUnauthorizedError(err error) bool {
+	_, ok := err.(entities.UnauthorizedError)
+	return ok
+}
+
+func TestRefreshRotatesTheRefreshToken(t *testing.T) {
+	s, _ := newTestService(t)
+
+	tokens, err := s.Create("alice")
+	if err != nil {
+		t.Fatalf("Create: %s", err)
+	}
+
+	username, refreshed, err := s.Refresh(tokens.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh: %s", err)
+	}
+	if username != "alice" || refreshed.RefreshToken == tokens.RefreshToken || refreshed.Token == "" {
+		t.Errorf("expected new tokens of alice, got %s %+v", username, refreshed)
+	}
+
+	claims, err := s.auth.VerifyToken(refreshed.Token)
+	if err != nil || claims.Username != "alice" {
+		t.Errorf("VerifyToken = %+v, %v", claims, err)
+	}
+
+	_, again, err := s.Refresh(refreshed.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh: %s", err)
+	}
+
+	// The first refresh token was used twice, the session ends
+	_, _, err = s.Refresh(refreshed.RefreshToken)
+	if !isUnauthorizedError(err) {
+		t.Errorf("expected an unauthorized error reusing a refresh token, got %v", err)
+	}
+	_, _, err = s.Refresh(again.RefreshToken)
+	if !isUnauthorizedError(err) {
+		t.Errorf("expected the session to end after a reuse, got %v", err)
+	}
+}
+
+func TestRefreshRejectsInvalidTokens(t *testing.T) {
+	s, store := newTestService(t)
+
+	tokens, err := s.Create("alice")
+	if err != nil {
+		t.Fatalf("Create: %s", err)
+	}
+
+	for _, refreshToken := range []string{"", "nodot", "unknown.secret", tokens.RefreshToken + "x"} {
+		_, _, err = s.Refresh(refreshToken)
+		if !isUnauthorizedError(err) {
+			t.Errorf("Refresh(%q): expected an unauthorized error, got %v", refreshToken, err)
+		}
+	}
+
+	// Expire the session
+	for sessionId, session := range store.Sessions {
+		session.ExpiresAt = session.CreatedAt
+		store.Sessions[sessionId] = session
+	}
+
+	_, _, err = s.Refresh(tokens.RefreshToken)
+	if !isUnauthorizedError(err) {
+		t.Errorf("expected an unauthorized error refreshing an expired session, got %v", err)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	s, _ := newTestService(t)
+
+	first, err := s.Create("alice")
+	if err != nil {
+		t.Fatalf("Create: %s", err)
+	}
+	second, err := s.Create("alice")
+	if err != nil {
+		t.Fatalf("Create: %s", err)
+	}
+	other, err := s.Create("bob")
+	if err != nil {
+		t.Fatalf("Create: %s", err)
+	}
+
+	err = s.Logout(first.RefreshToken)
+	if err != nil {
+		t.Fatalf("Logout: %s", err)
+	}
+	if _, _, err = s.Refresh(first.RefreshToken); !isUnauthorizedError(err) {
+		t.Errorf("expected the session to end, got %v", err)
+	}
+
+	second.RefreshToken, err = refresh(s, second.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh: %s", err)
+	}
+
+	err = s.LogoutEverywhere("alice")
+	if err != nil {
+		t.Fatalf("LogoutEverywhere: %s", err)
+	}
+	if _, _, err = s.Refresh(second.RefreshToken); !isUnauthorizedError(err) {
+		t.Errorf("expected every session of alice to end, got %v", err)
+	}
+	if _, _, err = s.Refresh(other.RefreshToken); err != nil {
+		t.Errorf("the sessions of bob must not end: %s", err)
+	}
+}
+
+func refresh(s *sessionService, refreshToken string) (string, error) {
+	_, tokens, err := s.Refresh(refreshToken)
+	return tokens.RefreshToken, err
+}
+
+func TestRefreshRejectsRevokedTokens(t *testing.T) {
+	s, _ := newTestService(t)
+
+	tokens, err := s.Create("alice")
+	if err != nil {
+		t.Fatalf("Create: %s", err)
+	}
+
+	// Like a password reset, without ending the sessions
+	_, err = s.users.ResetPassword("alice", "654321")
+	if err != nil {
+		t.Fatalf("ResetPassword: %s", err)
+	}
+
+	if _, _, err = s.Refresh(tokens.RefreshToken); !isUnauthorizedError(err) {
+		t.Errorf("expected a session started before the revocation to end, got %v", err)
+	}
+
+	tokens, err = s.Create("alice")
+	if err != nil {
+		t.Fatalf("Create: %s", err)
+	}
+	if _, err = refresh(s, tokens.RefreshToken); err != nil {
+		t.Errorf("a session started after the revocation must be refreshed: %s", err)
+	}
+
+	tokens, err = s.Create("nobody")
+	if err != nil {
+		t.Fatalf("Create: %s", err)
+	}
+	if _, _, err = s.Refresh(tokens.RefreshToken); !isUnauthorizedError(err) {
+		t.Errorf("expected the session of a missing user to end, got %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package tag
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/dynamo"
+)
+
+type dynamoRepository struct{}
+
+func (d *dynamoRepository) GetTopTags(prefix string, limit int) ([]entities.Tag, error) {
+	// ArticleCount is the range key of the index, it can't be filtered on
+	queryTags := dynamodb.QueryInput{
+		TableName:                 aws.String(dynamo.TagTableName),
+		IndexName:                 aws.String("ArticleCount"),
+		KeyConditionExpression:    aws.String("Dummy=:zero AND ArticleCount > :zero"),
+		ExpressionAttributeValues: dynamo.IntKey(":zero", 0),
+		Limit:                     aws.Int64(int64(limit)),
+		ScanIndexForward:          aws.Bool(false),
+	}
+	if prefix != "" {
+		queryTags.FilterExpression = aws.String("begins_with(Tag, :prefix)")
+		queryTags.ExpressionAttributeValues[":prefix"] = dynamo.StringValue(prefix)
+	}
+
+	// Limit is applied before the filter, so keep reading pages until enough tags matched
+	items := make([]dynamo.AWSObject, 0, limit)
+
+	err := dynamo.DynamoDB().QueryPages(&queryTags, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			if len(items) >= limit {
+				break
+			}
+			items = append(items, item)
+		}
+		return len(items) < limit
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make([]entities.Tag, len(items))
+	err = dynamodbattribute.UnmarshalListOfMaps(items, &tags)
+	if err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
@@ -0,0 +1,43 @@
+package tag
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/memory"
+)
+
+type memoryRepository struct {
+	store *memory.Store
+}
+
+// NewMemoryRepository returns a TagRepository backed by store, mostly useful
+// for tests which need an isolated store
+func NewMemoryRepository(store *memory.Store) TagRepository {
+	return &memoryRepository{
+		store: store,
+	}
+}
+
+func (m *memoryRepository) GetTopTags(prefix string, limit int) ([]entities.Tag, error) {
+	m.store.RLock()
+	defer m.store.RUnlock()
+
+	tags := make([]entities.Tag, 0, len(m.store.Tags))
+	for _, tag := range m.store.Tags {
+		if tag.ArticleCount > 0 && strings.HasPrefix(tag.Tag, prefix) {
+			tags = append(tags, tag)
+		}
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].ArticleCount != tags[j].ArticleCount {
+			return tags[i].ArticleCount > tags[j].ArticleCount
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+
+	_, end := memory.Page(len(tags), 0, limit)
+	return tags[:end], nil
+}
@@ -0,0 +1,36 @@
+package tag_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ferjmc/cms/internal/memory"
+	"github.com/ferjmc/cms/pkg/article"
+	"github.com/ferjmc/cms/pkg/tag"
+	"github.com/ferjmc/cms/pkg/tag/tagtest"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	tagtest.Run(t, func() (tag.TagRepository, article.ArticleRepository) {
+		store := memory.NewStore()
+		return tag.NewMemoryRepository(store), article.NewMemoryRepository(store)
+	})
+}
+
+func TestDynamoDBRepository(t *testing.T) {
+	if os.Getenv("DYNAMODB_ENDPOINT") == "" {
+		t.Skip("DYNAMODB_ENDPOINT is not set")
+	}
+
+	tagtest.Run(t, func() (tag.TagRepository, article.ArticleRepository) {
+		tags, err := tag.NewTagRepository(tag.InstanceDynamodb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		articles, err := article.NewArticleRepository(article.InstanceDynamodb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tags, articles
+	})
+}
@@ -0,0 +1,30 @@
+package tag
+
+import (
+	"errors"
+
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/memory"
+)
+
+const (
+	InstanceDynamodb int = iota
+	InstanceMemory
+)
+
+type TagRepository interface {
+	// GetTopTags retrieves at most limit tags starting with prefix, ordered by
+	// descending article count. Tags no longer used by any article are skipped.
+	GetTopTags(prefix string, limit int) ([]entities.Tag, error)
+}
+
+func NewTagRepository(instance int) (TagRepository, error) {
+	switch instance {
+	case InstanceDynamodb:
+		return &dynamoRepository{}, nil
+	case InstanceMemory:
+		return NewMemoryRepository(memory.DB()), nil
+	default:
+		return nil, errors.New("repository instance not found")
+	}
+}
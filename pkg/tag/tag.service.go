@@ -0,0 +1,69 @@
+package tag
+
+import (
+	"fmt"
+
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/memory"
+)
+
+const MaxTagsLimit = 100
+
+type TagService interface {
+	// GetTags retrieves the most used tags, optionally only the ones starting
+	// with prefix, to autocomplete tags while editing an article
+	GetTags(prefix string, limit int) ([]entities.Tag, error)
+}
+
+func NewTagService(r TagRepository) TagService {
+	return &tagService{
+		repository: r,
+	}
+}
+
+func New(opts ...func(TagService) TagService) TagService {
+	var serv TagService
+	for _, opt := range opts {
+		serv = opt(serv)
+	}
+	// whitout opts retrieves service with dynamo by default
+	if len(opts) <= 0 {
+		if memory.Enabled() {
+			return WithMemory(serv)
+		}
+		return WithDynamoDB(serv)
+	}
+	return serv
+}
+
+func WithDynamoDB(serv TagService) TagService {
+	repo, err := NewTagRepository(InstanceDynamodb)
+	if err != nil {
+		return serv
+	}
+	return NewTagService(repo)
+}
+
+func WithMemory(serv TagService) TagService {
+	repo, err := NewTagRepository(InstanceMemory)
+	if err != nil {
+		return serv
+	}
+	return NewTagService(repo)
+}
+
+type tagService struct {
+	repository TagRepository
+}
+
+func (s *tagService) GetTags(prefix string, limit int) ([]entities.Tag, error) {
+	if limit <= 0 {
+		return nil, entities.NewInputError("limit", "must be positive")
+	}
+
+	if limit > MaxTagsLimit {
+		return nil, entities.NewInputError("limit", fmt.Sprintf("must be smaller or equal to %d", MaxTagsLimit))
+	}
+
+	return s.repository.GetTopTags(prefix, limit)
+}
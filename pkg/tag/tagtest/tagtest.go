@@ -0,0 +1,102 @@
+// Package tagtest checks that a tag.TagRepository implementation behaves like the
+// DynamoDB one, so every backend can be plugged into the same suite.
+package tagtest
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/pkg/article"
+	"github.com/ferjmc/cms/pkg/tag"
+)
+
+// Run executes the conformance suite. newRepositories must return a tag repository
+// and the article repository sharing its storage, which counts the articles of each tag.
+// Tags start with a prefix unique per run, so the suite can share storage with other data.
+func Run(t *testing.T, newRepositories func() (tag.TagRepository, article.ArticleRepository)) {
+	prefix := "t" + strconv.FormatInt(time.Now().UnixNano(), 36) + "-"
+
+	clock := time.Now().UTC().UnixNano()
+	putArticle := func(t *testing.T, repo article.ArticleRepository, tags ...string) entities.Article {
+		t.Helper()
+		clock += int64(time.Millisecond)
+		a := &entities.Article{
+			Title:       "title " + strconv.FormatInt(clock, 10),
+			Description: "description",
+			Body:        "body",
+			TagList:     tags,
+			CreatedAt:   clock,
+			UpdatedAt:   clock,
+			Author:      prefix + "author",
+		}
+		if err := repo.PutArticle(a); err != nil {
+			t.Fatalf("PutArticle: %s", err)
+		}
+		return *a
+	}
+
+	t.Run("GetTopTags orders the tags by descending article count", func(t *testing.T) {
+		tags, articles := newRepositories()
+		p := prefix + "order-"
+		putArticle(t, articles, p+"a", p+"b", p+"c")
+		putArticle(t, articles, p+"a", p+"c")
+		putArticle(t, articles, p+"a")
+
+		top, err := tags.GetTopTags(p, 10)
+		if err != nil {
+			t.Fatalf("GetTopTags: %s", err)
+		}
+		expectTags(t, top, []string{p + "a", p + "c", p + "b"}, []int64{3, 2, 1})
+
+		top, err = tags.GetTopTags(p, 2)
+		if err != nil {
+			t.Fatalf("GetTopTags: %s", err)
+		}
+		expectTags(t, top, []string{p + "a", p + "c"}, []int64{3, 2})
+	})
+
+	t.Run("GetTopTags only returns the tags starting with prefix", func(t *testing.T) {
+		tags, articles := newRepositories()
+		p := prefix + "prefix-"
+		putArticle(t, articles, p+"go", p+"golang", p+"rust")
+
+		top, err := tags.GetTopTags(p+"go", 10)
+		if err != nil {
+			t.Fatalf("GetTopTags: %s", err)
+		}
+		if len(top) != 2 || !strings.HasPrefix(top[0].Tag, p+"go") || !strings.HasPrefix(top[1].Tag, p+"go") {
+			t.Errorf("expected the 2 tags starting with %sgo, got %+v", p, top)
+		}
+	})
+
+	t.Run("GetTopTags skips the tags no longer used", func(t *testing.T) {
+		tags, articles := newRepositories()
+		p := prefix + "unused-"
+		putArticle(t, articles, p+"kept")
+		deleted := putArticle(t, articles, p+"gone")
+		if err := articles.DeleteArticle(deleted); err != nil {
+			t.Fatalf("DeleteArticle: %s", err)
+		}
+
+		top, err := tags.GetTopTags(p, 10)
+		if err != nil {
+			t.Fatalf("GetTopTags: %s", err)
+		}
+		expectTags(t, top, []string{p + "kept"}, []int64{1})
+	})
+}
+
+func expectTags(t *testing.T, tags []entities.Tag, names []string, counts []int64) {
+	t.Helper()
+	if len(tags) != len(names) {
+		t.Fatalf("expected tags %v, got %+v", names, tags)
+	}
+	for i, tag := range tags {
+		if tag.Tag != names[i] || tag.ArticleCount != counts[i] {
+			t.Errorf("expected tag %d to be %s with %d articles, got %+v", i, names[i], counts[i], tag)
+		}
+	}
+}
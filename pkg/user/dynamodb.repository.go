@@ -1,11 +1,14 @@
 package user
 
 import (
-	"log"
+	"bytes"
+	"reflect"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
 	"github.com/ferjmc/cms/entities"
 	"github.com/ferjmc/cms/internal/dynamo"
 )
@@ -50,15 +53,18 @@ func (d *dynamoRepository) PutUser(user entities.User) error {
 	}
 
 	_, err = dynamo.DynamoDB().TransactWriteItems(&transaction)
-	if err != nil {
-		log.Printf("ERROR: during transaction %s", err)
-		// TODO: distinguish:
-		// NewInputError("username", "has already been taken")
-		// NewInputError("email", "has already been taken")
-		return err
+	if canceled, ok := dynamo.AsTransactionCanceled(err); ok && canceled.AnyConditionFailed() {
+		conflictErr := entities.ConflictError{}
+		if canceled.ConditionFailed(0) {
+			conflictErr["username"] = []string{"has already been taken"}
+		}
+		if canceled.ConditionFailed(1) {
+			conflictErr["email"] = []string{"has already been taken"}
+		}
+		return conflictErr
 	}
 
-	return nil
+	return err
 }
 
 func (d *dynamoRepository) UserByUsername(username string) (*entities.User, error) {
@@ -68,7 +74,7 @@ func (d *dynamoRepository) UserByUsername(username string) (*entities.User, erro
 		return nil, err
 	}
 	if !found {
-		return nil, entities.NewInputError("username", "not found")
+		return nil, entities.NewNotFoundError("username", "not found")
 	}
 
 	return &user, nil
@@ -83,13 +89,23 @@ func (d *dynamoRepository) UsernameByEmail(email string) (string, error) {
 	}
 
 	if !found {
-		return "", entities.NewInputError("email", "not found")
+		return "", entities.NewNotFoundError("email", "not found")
 	}
 
 	return emailUser.Username, nil
 }
 
 func (d *dynamoRepository) UpdateUser(oldUser, newUser entities.User) error {
+	if oldUser.Username != newUser.Username {
+		return entities.NewInputError("username", "can't be changed")
+	}
+
+	// Only the changed attributes are written, concurrent updates of other fields are kept
+	update := userUpdate(oldUser, newUser)
+	if dynamo.IsUpdateBuilderEmpty(update) {
+		return nil
+	}
+
 	transactItems := make([]*dynamodb.TransactWriteItem, 0, 3)
 
 	if oldUser.Email != newUser.Email {
@@ -122,29 +138,262 @@ func (d *dynamoRepository) UpdateUser(oldUser, newUser entities.User) error {
 		})
 	}
 
-	newUserItem, err := dynamodbattribute.MarshalMap(newUser)
+	expr, err := expression.NewBuilder().
+		WithUpdate(update).
+		WithCondition(userCondition(oldUser, newUser)).
+		Build()
 	if err != nil {
 		return err
 	}
 
 	// Update user info
 	transactItems = append(transactItems, &dynamodb.TransactWriteItem{
-		Put: &dynamodb.Put{
+		Update: &dynamodb.Update{
 			TableName:                 aws.String(dynamo.UserTableName),
-			Item:                      newUserItem,
-			ConditionExpression:       aws.String("Email = :email"),
-			ExpressionAttributeValues: dynamo.StringKey(":email", oldUser.Email),
+			Key:                       dynamo.StringKey("Username", oldUser.Username),
+			UpdateExpression:          expr.Update(),
+			ConditionExpression:       expr.Condition(),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
 		},
 	})
 
 	_, err = dynamo.DynamoDB().TransactWriteItems(&dynamodb.TransactWriteItemsInput{
 		TransactItems: transactItems,
 	})
+	if canceled, ok := dynamo.AsTransactionCanceled(err); ok && canceled.AnyConditionFailed() {
+		// The new email, when it changes, is the first item
+		if oldUser.Email != newUser.Email && canceled.ConditionFailed(0) {
+			return entities.NewConflictError("email", "has already been taken")
+		}
+		return entities.NewConflictError("user", "has been modified, please retry")
+	}
+
+	return err
+}
+
+func (d *dynamoRepository) RenameUser(oldUser, newUser entities.User) error {
+	now := time.Now().UTC().UnixNano()
+
+	newUserItem, err := dynamodbattribute.MarshalMap(newUser)
+	if err != nil {
+		return err
+	}
+
+	aliasItem, err := dynamodbattribute.MarshalMap(entities.UsernameAlias{
+		Alias:     oldUser.Username,
+		Username:  newUser.Username,
+		CreatedAt: now,
+	})
+	if err != nil {
+		return err
+	}
+
+	emailUserItem, err := dynamodbattribute.MarshalMap(entities.EmailUser{
+		Email:    newUser.Email,
+		Username: newUser.Username,
+	})
+	if err != nil {
+		return err
+	}
+
+	migrationItem, err := dynamodbattribute.MarshalMap(entities.NewUsernameMigration(oldUser.Username, newUser.Username, now))
 	if err != nil {
 		return err
 	}
 
-	return nil
+	// Move the user and its email, reserve the old username and start the migration of its rows.
+	// The new username must be free, neither a user nor the alias of one.
+	transactItems := []*dynamodb.TransactWriteItem{
+		{
+			Put: &dynamodb.Put{
+				TableName:           aws.String(dynamo.UserTableName),
+				Item:                newUserItem,
+				ConditionExpression: aws.String("attribute_not_exists(Username)"),
+			},
+		},
+		{
+			ConditionCheck: &dynamodb.ConditionCheck{
+				TableName:           aws.String(dynamo.UsernameAliasTableName),
+				Key:                 dynamo.StringKey("Alias", newUser.Username),
+				ConditionExpression: aws.String("attribute_not_exists(Alias)"),
+			},
+		},
+		{
+			Delete: &dynamodb.Delete{
+				TableName:                 aws.String(dynamo.UserTableName),
+				Key:                       dynamo.StringKey("Username", oldUser.Username),
+				ConditionExpression:       aws.String("Email = :email"),
+				ExpressionAttributeValues: dynamo.StringKey(":email", oldUser.Email),
+			},
+		},
+		{
+			Put: &dynamodb.Put{
+				TableName:           aws.String(dynamo.UsernameAliasTableName),
+				Item:                aliasItem,
+				ConditionExpression: aws.String("attribute_not_exists(Alias)"),
+			},
+		},
+		{
+			Put: &dynamodb.Put{
+				TableName:           aws.String(dynamo.UsernameMigrationTableName),
+				Item:                migrationItem,
+				ConditionExpression: aws.String("attribute_not_exists(OldUsername)"),
+			},
+		},
+	}
+
+	changingEmail := newUser.Email != oldUser.Email
+	if changingEmail {
+		// The new email must be free, the old one is released
+		transactItems = append(transactItems,
+			&dynamodb.TransactWriteItem{
+				Put: &dynamodb.Put{
+					TableName:           aws.String(dynamo.EmailUserTableName),
+					Item:                emailUserItem,
+					ConditionExpression: aws.String("attribute_not_exists(Email)"),
+				},
+			},
+			&dynamodb.TransactWriteItem{
+				Delete: &dynamodb.Delete{
+					TableName:                 aws.String(dynamo.EmailUserTableName),
+					Key:                       dynamo.StringKey("Email", oldUser.Email),
+					ConditionExpression:       aws.String("Username = :username"),
+					ExpressionAttributeValues: dynamo.StringKey(":username", oldUser.Username),
+				},
+			},
+		)
+	} else {
+		transactItems = append(transactItems, &dynamodb.TransactWriteItem{
+			Put: &dynamodb.Put{
+				TableName:                 aws.String(dynamo.EmailUserTableName),
+				Item:                      emailUserItem,
+				ConditionExpression:       aws.String("Username = :username"),
+				ExpressionAttributeValues: dynamo.StringKey(":username", oldUser.Username),
+			},
+		})
+	}
+
+	_, err = dynamo.DynamoDB().TransactWriteItems(&dynamodb.TransactWriteItemsInput{TransactItems: transactItems})
+	if canceled, ok := dynamo.AsTransactionCanceled(err); ok && canceled.AnyConditionFailed() {
+		conflictErr := entities.ConflictError{}
+		if canceled.ConditionFailed(0) || canceled.ConditionFailed(1) {
+			conflictErr["username"] = []string{"has already been taken"}
+		}
+		// The new email, when it changes, is the item after the migration
+		if changingEmail && canceled.ConditionFailed(5) {
+			conflictErr["email"] = []string{"has already been taken"}
+		}
+		if len(conflictErr) == 0 {
+			return entities.NewConflictError("user", "has been modified, please retry")
+		}
+		return conflictErr
+	}
+
+	return err
+}
+
+func (d *dynamoRepository) UsernameAlias(alias string) (*entities.UsernameAlias, error) {
+	var usernameAlias entities.UsernameAlias
+	found, err := dynamo.GetItemByKey(dynamo.UsernameAliasTableName, dynamo.StringKey("Alias", alias), &usernameAlias)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, entities.NewNotFoundError("username", "not found")
+	}
+
+	return &usernameAlias, nil
+}
+
+func (d *dynamoRepository) PendingUsernameMigrations(limit int) ([]entities.UsernameMigration, error) {
+	// Only pending migrations have the key of the sparse index Pending
+	queryMigrations := dynamodb.QueryInput{
+		TableName:                 aws.String(dynamo.UsernameMigrationTableName),
+		IndexName:                 aws.String("Pending"),
+		KeyConditionExpression:    aws.String("Pending=:one"),
+		ExpressionAttributeValues: dynamo.IntKey(":one", 1),
+	}
+
+	items, _, err := dynamo.QueryPage(&queryMigrations, "", limit)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]entities.UsernameMigration, len(items))
+	err = dynamodbattribute.UnmarshalListOfMaps(items, &migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	return migrations, nil
+}
+
+func (d *dynamoRepository) UpdateUsernameMigration(migration entities.UsernameMigration) error {
+	item, err := dynamodbattribute.MarshalMap(migration)
+	if err != nil {
+		return err
+	}
+
+	_, err = dynamo.DynamoDB().PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String(dynamo.UsernameMigrationTableName),
+		Item:      item,
+	})
+
+	return err
+}
+
+// userCondition checks that the user still has the email of oldUser and, when they change,
+// its roles, so concurrent grants and revokes don't overwrite each other
+func userCondition(oldUser, newUser entities.User) expression.ConditionBuilder {
+	condition := expression.Name("Email").Equal(expression.Value(oldUser.Email))
+	if reflect.DeepEqual(newUser.Roles, oldUser.Roles) {
+		return condition
+	}
+
+	roles := expression.Name("Roles")
+	if len(oldUser.Roles) == 0 {
+		// Users without roles have none stored, a null or an empty list
+		return condition.And(expression.Or(
+			expression.AttributeNotExists(roles),
+			expression.AttributeType(roles, expression.Null),
+			expression.Size(roles).Equal(expression.Value(0)),
+		))
+	}
+
+	return condition.And(roles.Equal(expression.Value(oldUser.Roles)))
+}
+
+// userUpdate sets the attributes of newUser which differ from oldUser
+func userUpdate(oldUser, newUser entities.User) expression.UpdateBuilder {
+	var update expression.UpdateBuilder
+	set := func(name string, value interface{}) {
+		update = update.Set(expression.Name(name), expression.Value(value))
+	}
+
+	if newUser.Email != oldUser.Email {
+		set("Email", newUser.Email)
+	}
+	if !bytes.Equal(newUser.PasswordHash, oldUser.PasswordHash) {
+		set("PasswordHash", newUser.PasswordHash)
+	}
+	if newUser.Image != oldUser.Image {
+		set("Image", newUser.Image)
+	}
+	if newUser.Bio != oldUser.Bio {
+		set("Bio", newUser.Bio)
+	}
+	if newUser.TokensRevokedAt != oldUser.TokensRevokedAt {
+		set("TokensRevokedAt", newUser.TokensRevokedAt)
+	}
+	if newUser.Verified != oldUser.Verified {
+		set("Verified", newUser.Verified)
+	}
+	if !reflect.DeepEqual(newUser.Roles, oldUser.Roles) {
+		set("Roles", newUser.Roles)
+	}
+
+	return update
 }
 
 func (d *dynamoRepository) GetUserListByUsername(usernames []string) ([]entities.User, error) {
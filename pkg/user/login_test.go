@@ -0,0 +1,75 @@
+package user
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/memory"
+	"golang.org/x/crypto/scrypt"
+)
+
+func TestLogin(t *testing.T) {
+	repo := NewMemoryRepository(memory.NewStore())
+	serv := NewUserService(repo)
+
+	err := serv.PutUser(entities.User{Username: "alice", Email: "alice@fake.com"}, "123456")
+	if err != nil {
+		t.Fatalf("PutUser: %s", err)
+	}
+
+	t.Run("It must retrieve the user with the right password", func(t *testing.T) {
+		user, err := serv.Login("alice@fake.com", "123456")
+		if err != nil {
+			t.Fatalf("Login: %s", err)
+		}
+		if user.Username != "alice" {
+			t.Errorf("expected alice, got %s", user.Username)
+		}
+	})
+
+	t.Run("It must reject a wrong password", func(t *testing.T) {
+		_, err := serv.Login("alice@fake.com", "654321")
+		if _, ok := err.(entities.UnauthorizedError); !ok {
+			t.Errorf("expected an unauthorized error, instead: %v", err)
+		}
+	})
+
+	t.Run("It must reject an unknown email like a wrong password", func(t *testing.T) {
+		_, err := serv.Login("nobody@fake.com", "123456")
+		if _, ok := err.(entities.UnauthorizedError); !ok {
+			t.Errorf("expected an unauthorized error, instead: %v", err)
+		}
+	})
+
+	t.Run("It must upgrade a legacy password hash", func(t *testing.T) {
+		// Legacy hashes are raw scrypt keys made with a salt shared by every user
+		legacyHash, err := scrypt.Key([]byte("123456"), []byte("KU2YVXA7BSNExJIvemcdz61eL86IJDCC"), 32768, 8, 1, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = repo.PutUser(entities.User{Username: "bob", Email: "bob@fake.com", PasswordHash: legacyHash})
+		if err != nil {
+			t.Fatalf("PutUser: %s", err)
+		}
+
+		_, err = serv.Login("bob@fake.com", "123456")
+		if err != nil {
+			t.Fatalf("Login: %s", err)
+		}
+
+		bob, err := repo.UserByUsername("bob")
+		if err != nil {
+			t.Fatalf("UserByUsername: %s", err)
+		}
+		if bytes.Equal(bob.PasswordHash, legacyHash) || !bytes.HasPrefix(bob.PasswordHash, []byte("$argon2id$")) {
+			t.Errorf("expected an argon2id hash, got %q", bob.PasswordHash)
+		}
+
+		_, err = serv.Login("bob@fake.com", "123456")
+		if err != nil {
+			t.Errorf("Login after the upgrade: %s", err)
+		}
+	})
+}
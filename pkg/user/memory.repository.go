@@ -0,0 +1,235 @@
+package user
+
+import (
+	"bytes"
+	"reflect"
+	"time"
+
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/memory"
+)
+
+type memoryRepository struct {
+	store *memory.Store
+}
+
+// NewMemoryRepository returns a UserRepository backed by store, mostly useful
+// for tests which need an isolated store
+func NewMemoryRepository(store *memory.Store) UserRepository {
+	return &memoryRepository{
+		store: store,
+	}
+}
+
+func (m *memoryRepository) PutUser(user entities.User) error {
+	m.store.Lock()
+	defer m.store.Unlock()
+
+	// Same conditions as the DynamoDB transaction: username and email are unique
+	conflictErr := entities.ConflictError{}
+	if _, ok := m.store.Users[user.Username]; ok {
+		conflictErr["username"] = []string{"has already been taken"}
+	}
+	if _, ok := m.store.EmailUsers[user.Email]; ok {
+		conflictErr["email"] = []string{"has already been taken"}
+	}
+	if len(conflictErr) > 0 {
+		return conflictErr
+	}
+
+	m.store.Users[user.Username] = copyUser(user)
+	m.store.EmailUsers[user.Email] = entities.EmailUser{
+		Email:    user.Email,
+		Username: user.Username,
+	}
+
+	return nil
+}
+
+func (m *memoryRepository) UserByUsername(username string) (*entities.User, error) {
+	m.store.RLock()
+	defer m.store.RUnlock()
+
+	user, ok := m.store.Users[username]
+	if !ok {
+		return nil, entities.NewNotFoundError("username", "not found")
+	}
+
+	user = copyUser(user)
+	return &user, nil
+}
+
+func (m *memoryRepository) UsernameByEmail(email string) (string, error) {
+	m.store.RLock()
+	defer m.store.RUnlock()
+
+	emailUser, ok := m.store.EmailUsers[email]
+	if !ok {
+		return "", entities.NewNotFoundError("email", "not found")
+	}
+
+	return emailUser.Username, nil
+}
+
+func (m *memoryRepository) UpdateUser(oldUser, newUser entities.User) error {
+	m.store.Lock()
+	defer m.store.Unlock()
+
+	if oldUser.Username != newUser.Username {
+		return entities.NewInputError("username", "can't be changed")
+	}
+
+	current, ok := m.store.Users[newUser.Username]
+	if !ok || current.Email != oldUser.Email {
+		return entities.NewConflictError("user", "has been modified, please retry")
+	}
+	if !reflect.DeepEqual(newUser.Roles, oldUser.Roles) && !sameRoles(current.Roles, oldUser.Roles) {
+		return entities.NewConflictError("user", "has been modified, please retry")
+	}
+
+	if oldUser.Email != newUser.Email {
+		if _, ok := m.store.EmailUsers[newUser.Email]; ok {
+			return entities.NewConflictError("email", "has already been taken")
+		}
+
+		delete(m.store.EmailUsers, oldUser.Email)
+		m.store.EmailUsers[newUser.Email] = entities.EmailUser{
+			Email:    newUser.Email,
+			Username: newUser.Username,
+		}
+	}
+
+	// Like the DynamoDB update, only the changed fields are written
+	m.store.Users[newUser.Username] = copyUser(mergeUser(current, oldUser, newUser))
+
+	return nil
+}
+
+func (m *memoryRepository) GetUserListByUsername(usernames []string) ([]entities.User, error) {
+	m.store.RLock()
+	defer m.store.RUnlock()
+
+	users := make([]entities.User, 0, len(usernames))
+	for _, username := range usernames {
+		users = append(users, copyUser(m.store.Users[username]))
+	}
+
+	return users, nil
+}
+
+func (m *memoryRepository) RenameUser(oldUser, newUser entities.User) error {
+	m.store.Lock()
+	defer m.store.Unlock()
+
+	// Same conditions as the DynamoDB transaction
+	conflictErr := entities.ConflictError{}
+	_, taken := m.store.Users[newUser.Username]
+	_, reserved := m.store.UsernameAliases[newUser.Username]
+	if taken || reserved {
+		conflictErr["username"] = []string{"has already been taken"}
+	}
+	if _, taken := m.store.EmailUsers[newUser.Email]; taken && newUser.Email != oldUser.Email {
+		conflictErr["email"] = []string{"has already been taken"}
+	}
+	if len(conflictErr) > 0 {
+		return conflictErr
+	}
+
+	current, ok := m.store.Users[oldUser.Username]
+	if !ok || current.Email != oldUser.Email {
+		return entities.NewConflictError("user", "has been modified, please retry")
+	}
+
+	now := time.Now().UTC().UnixNano()
+
+	delete(m.store.Users, oldUser.Username)
+	delete(m.store.EmailUsers, oldUser.Email)
+	m.store.Users[newUser.Username] = copyUser(newUser)
+	m.store.EmailUsers[newUser.Email] = entities.EmailUser{
+		Email:    newUser.Email,
+		Username: newUser.Username,
+	}
+	m.store.UsernameAliases[oldUser.Username] = entities.UsernameAlias{
+		Alias:     oldUser.Username,
+		Username:  newUser.Username,
+		CreatedAt: now,
+	}
+	m.store.UsernameMigrations[oldUser.Username] = entities.NewUsernameMigration(oldUser.Username, newUser.Username, now)
+
+	return nil
+}
+
+func (m *memoryRepository) UsernameAlias(alias string) (*entities.UsernameAlias, error) {
+	m.store.RLock()
+	defer m.store.RUnlock()
+
+	usernameAlias, ok := m.store.UsernameAliases[alias]
+	if !ok {
+		return nil, entities.NewNotFoundError("username", "not found")
+	}
+
+	return &usernameAlias, nil
+}
+
+func (m *memoryRepository) PendingUsernameMigrations(limit int) ([]entities.UsernameMigration, error) {
+	m.store.RLock()
+	defer m.store.RUnlock()
+
+	migrations := make([]entities.UsernameMigration, 0)
+	for _, migration := range m.store.UsernameMigrations {
+		if !migration.Done() && len(migrations) < limit {
+			migrations = append(migrations, migration)
+		}
+	}
+
+	return migrations, nil
+}
+
+func (m *memoryRepository) UpdateUsernameMigration(migration entities.UsernameMigration) error {
+	m.store.Lock()
+	defer m.store.Unlock()
+
+	m.store.UsernameMigrations[migration.OldUsername] = migration
+
+	return nil
+}
+
+// sameRoles compares roles like the DynamoDB condition, no roles being nil or empty
+func sameRoles(roles, otherRoles []string) bool {
+	if len(roles) == 0 {
+		return len(otherRoles) == 0
+	}
+	return reflect.DeepEqual(roles, otherRoles)
+}
+
+// mergeUser applies to current the fields of newUser which differ from oldUser
+func mergeUser(current, oldUser, newUser entities.User) entities.User {
+	if newUser.Email != oldUser.Email {
+		current.Email = newUser.Email
+	}
+	if !bytes.Equal(newUser.PasswordHash, oldUser.PasswordHash) {
+		current.PasswordHash = newUser.PasswordHash
+	}
+	if newUser.Image != oldUser.Image {
+		current.Image = newUser.Image
+	}
+	if newUser.Bio != oldUser.Bio {
+		current.Bio = newUser.Bio
+	}
+	if newUser.TokensRevokedAt != oldUser.TokensRevokedAt {
+		current.TokensRevokedAt = newUser.TokensRevokedAt
+	}
+	if newUser.Verified != oldUser.Verified {
+		current.Verified = newUser.Verified
+	}
+	if !reflect.DeepEqual(newUser.Roles, oldUser.Roles) {
+		current.Roles = newUser.Roles
+	}
+	return current
+}
+
+func copyUser(user entities.User) entities.User {
+	user.PasswordHash = memory.CopyBytes(user.PasswordHash)
+	user.Roles = memory.CopyStrings(user.Roles)
+	return user
+}
@@ -4,10 +4,11 @@ import (
 	"testing"
 
 	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/memory"
 )
 
 func TestPutUser(t *testing.T) {
-	repo := NewMockUserRepository()
+	repo := NewMemoryRepository(memory.NewStore())
 	serv := NewUserService(repo)
 
 	t.Run("It must return an error with a password in blank", func(t *testing.T) {
@@ -31,17 +32,33 @@ func TestPutUser(t *testing.T) {
 			t.Errorf("error must be nil, instead: %s", err)
 		}
 	})
+
+	t.Run("It must reject a taken username", func(t *testing.T) {
+		user := entities.User{
+			Username: "ferjmc",
+			Email:    "other@fake.com",
+		}
+		err := serv.PutUser(user, "123456")
+		if _, ok := err.(entities.ConflictError); !ok {
+			t.Errorf("expected a conflict error, instead: %v", err)
+		}
+	})
 }
 
 func TestGetUserByUsername(t *testing.T) {
-	repo := NewMockUserRepository()
+	repo := NewMemoryRepository(memory.NewStore())
 	serv := NewUserService(repo)
 
+	err := serv.PutUser(entities.User{Username: "username", Email: "email@fake.com"}, "123456")
+	if err != nil {
+		t.Fatalf("error must be nil, instead: %s", err)
+	}
+
 	t.Run("Given username string retrieve user object with same username atribute", func(t *testing.T) {
 		username := "username"
 		user, err := serv.GetUserByUsername(username)
 		if err != nil {
-			t.Error("if repository found the username it can't return an error")
+			t.Fatal("if repository found the username it can't return an error")
 		}
 		if username != user.Username {
 			t.Errorf("given username: %s, user.username: %s are not equal", username, user.Username)
@@ -53,4 +70,10 @@ func TestGetUserByUsername(t *testing.T) {
 			t.Error("error is nil while username is blank")
 		}
 	})
+	t.Run("It must return error if username doesn't exist", func(t *testing.T) {
+		_, err := serv.GetUserByUsername("missing")
+		if err == nil {
+			t.Error("error is nil while username doesn't exist")
+		}
+	})
 }
@@ -0,0 +1,30 @@
+package user_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ferjmc/cms/internal/memory"
+	"github.com/ferjmc/cms/pkg/user"
+	"github.com/ferjmc/cms/pkg/user/usertest"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	usertest.Run(t, func() user.UserRepository {
+		return user.NewMemoryRepository(memory.NewStore())
+	})
+}
+
+func TestDynamoDBRepository(t *testing.T) {
+	if os.Getenv("DYNAMODB_ENDPOINT") == "" {
+		t.Skip("DYNAMODB_ENDPOINT is not set")
+	}
+
+	usertest.Run(t, func() user.UserRepository {
+		repo, err := user.NewUserRepository(user.InstanceDynamodb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return repo
+	})
+}
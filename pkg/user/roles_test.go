@@ -0,0 +1,83 @@
+package user
+
+import (
+	"testing"
+
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/memory"
+)
+
+func TestRoles(t *testing.T) {
+	repo := NewMemoryRepository(memory.NewStore())
+	serv := NewUserService(repo)
+
+	for _, username := range []string{"alice", "bob"} {
+		err := serv.PutUser(entities.User{Username: username, Email: username + "@fake.com"}, "123456")
+		if err != nil {
+			t.Fatalf("PutUser: %s", err)
+		}
+	}
+
+	admin := &entities.User{Username: "root", Roles: []string{entities.RoleAdmin}}
+
+	t.Run("It must grant a role once", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			alice, err := serv.GrantRole(admin, "alice", entities.RoleEditor)
+			if err != nil {
+				t.Fatalf("GrantRole: %s", err)
+			}
+			if len(alice.Roles) != 1 || !alice.HasRole(entities.RoleEditor) {
+				t.Errorf("expected alice to be an editor, got %v", alice.Roles)
+			}
+		}
+
+		stored, err := repo.UserByUsername("alice")
+		if err != nil || !stored.HasRole(entities.RoleEditor) {
+			t.Errorf("the role must be stored, got %+v (%v)", stored, err)
+		}
+	})
+
+	t.Run("It must only let admins manage roles", func(t *testing.T) {
+		editor, err := repo.UserByUsername("alice")
+		if err != nil {
+			t.Fatalf("UserByUsername: %s", err)
+		}
+
+		_, err = serv.GrantRole(editor, "bob", entities.RoleEditor)
+		if _, ok := err.(entities.ForbiddenError); !ok {
+			t.Errorf("expected a forbidden error, instead: %v", err)
+		}
+		_, err = serv.GrantRole(nil, "bob", entities.RoleEditor)
+		if _, ok := err.(entities.ForbiddenError); !ok {
+			t.Errorf("expected a forbidden error without a user, instead: %v", err)
+		}
+	})
+
+	t.Run("It must reject unknown roles and users", func(t *testing.T) {
+		_, err := serv.GrantRole(admin, "bob", "owner")
+		if _, ok := err.(entities.InputError); !ok {
+			t.Errorf("expected an input error, instead: %v", err)
+		}
+		_, err = serv.GrantRole(admin, "nobody", entities.RoleEditor)
+		if _, ok := err.(entities.NotFoundError); !ok {
+			t.Errorf("expected a not found error, instead: %v", err)
+		}
+	})
+
+	t.Run("It must revoke a role", func(t *testing.T) {
+		alice, err := serv.RevokeRole(admin, "alice", entities.RoleEditor)
+		if err != nil {
+			t.Fatalf("RevokeRole: %s", err)
+		}
+		if len(alice.Roles) != 0 {
+			t.Errorf("expected no role left, got %v", alice.Roles)
+		}
+	})
+
+	t.Run("It must not let an admin revoke its own admin role", func(t *testing.T) {
+		_, err := serv.RevokeRole(admin, admin.Username, entities.RoleAdmin)
+		if _, ok := err.(entities.ForbiddenError); !ok {
+			t.Errorf("expected a forbidden error, instead: %v", err)
+		}
+	})
+}
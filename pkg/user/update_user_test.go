@@ -0,0 +1,196 @@
+package user
+
+import (
+	"testing"
+
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/memory"
+	"github.com/ferjmc/cms/pkg/auth"
+)
+
+func TestPatchUser(t *testing.T) {
+	repo := NewMemoryRepository(memory.NewStore())
+	serv := &userService{repository: repo}
+
+	err := serv.PutUser(entities.User{Username: "alice", Email: "alice@fake.com", Bio: "bio of alice"}, "123456")
+	if err != nil {
+		t.Fatalf("PutUser: %s", err)
+	}
+
+	current := func() entities.User {
+		t.Helper()
+		user, err := repo.UserByUsername("alice")
+		if err != nil {
+			t.Fatalf("UserByUsername: %s", err)
+		}
+		return *user
+	}
+
+	str := func(s string) *string { return &s }
+
+	t.Run("It must only change the fields sent", func(t *testing.T) {
+		before := current()
+		user, err := serv.patchUser(before, entities.UserPatch{Image: str("alice.png")})
+		if err != nil {
+			t.Fatalf("patchUser: %s", err)
+		}
+
+		stored := current()
+		if user.Image != "alice.png" || stored.Image != "alice.png" {
+			t.Errorf("expected the image to change, got %q stored %q", user.Image, stored.Image)
+		}
+		if stored.Bio != "bio of alice" || stored.Email != "alice@fake.com" {
+			t.Errorf("the other fields must be kept, got %+v", stored)
+		}
+		if string(stored.PasswordHash) != string(before.PasswordHash) || stored.TokensRevokedAt != before.TokensRevokedAt {
+			t.Error("the password must be kept without a new one")
+		}
+	})
+
+	t.Run("It must clear a field sent empty", func(t *testing.T) {
+		_, err := serv.patchUser(current(), entities.UserPatch{Bio: str("")})
+		if err != nil {
+			t.Fatalf("patchUser: %s", err)
+		}
+		if bio := current().Bio; bio != "" {
+			t.Errorf("expected an empty bio, got %q", bio)
+		}
+	})
+
+	t.Run("It must require the current password to change it", func(t *testing.T) {
+		for _, currentPassword := range []string{"", "654321"} {
+			_, err := serv.patchUser(current(), entities.UserPatch{Password: str("abcdef"), CurrentPassword: currentPassword})
+			if inputErr, ok := err.(entities.InputError); !ok || inputErr["currentPassword"] == nil {
+				t.Errorf("CurrentPassword %q: expected an input error, got %v", currentPassword, err)
+			}
+		}
+
+		before := current()
+		_, err := serv.patchUser(before, entities.UserPatch{Password: str("abcdef"), CurrentPassword: "123456"})
+		if err != nil {
+			t.Fatalf("patchUser: %s", err)
+		}
+
+		stored := current()
+		match, _, err := auth.New().VerifyPassword("abcdef", stored.PasswordHash)
+		if err != nil || !match {
+			t.Errorf("the new password must be stored, got %v (%v)", match, err)
+		}
+		if stored.TokensRevokedAt <= before.TokensRevokedAt {
+			t.Error("changing the password must revoke the tokens")
+		}
+	})
+
+	t.Run("It must reject a short password", func(t *testing.T) {
+		_, err := serv.patchUser(current(), entities.UserPatch{Password: str("abc"), CurrentPassword: "abcdef"})
+		if _, ok := err.(entities.InputError); !ok {
+			t.Errorf("expected an input error, got %v", err)
+		}
+	})
+
+	t.Run("It must unverify a new email", func(t *testing.T) {
+		verified, err := serv.VerifyEmail("alice", "alice@fake.com")
+		if err != nil {
+			t.Fatalf("VerifyEmail: %s", err)
+		}
+
+		user, err := serv.patchUser(*verified, entities.UserPatch{Email: str("alice@other.com")})
+		if err != nil {
+			t.Fatalf("patchUser: %s", err)
+		}
+		if user.Verified || current().Verified {
+			t.Error("a new email must not be verified")
+		}
+	})
+}
+
+func TestRenameUser(t *testing.T) {
+	repo := NewMemoryRepository(memory.NewStore())
+	serv := &userService{repository: repo}
+
+	for _, username := range []string{"alice", "bob"} {
+		err := serv.PutUser(entities.User{Username: username, Email: username + "@fake.com"}, "123456")
+		if err != nil {
+			t.Fatalf("PutUser: %s", err)
+		}
+	}
+
+	str := func(s string) *string { return &s }
+
+	alice, err := repo.UserByUsername("alice")
+	if err != nil {
+		t.Fatalf("UserByUsername: %s", err)
+	}
+
+	t.Run("It must rename and update the other fields", func(t *testing.T) {
+		user, err := serv.patchUser(*alice, entities.UserPatch{Username: str("alicia"), Bio: str("renamed")})
+		if err != nil {
+			t.Fatalf("patchUser: %s", err)
+		}
+		if user.Username != "alicia" || user.Bio != "renamed" || user.TokensRevokedAt <= alice.TokensRevokedAt {
+			t.Errorf("expected alicia with a new bio and revoked tokens, got %+v", user)
+		}
+
+		stored, err := repo.UserByUsername("alicia")
+		if err != nil || stored.Bio != "renamed" {
+			t.Errorf("expected the renamed user to be stored, got %+v (%v)", stored, err)
+		}
+
+		migrations, err := serv.GetPendingUsernameMigrations(10)
+		if err != nil || len(migrations) != 1 || migrations[0].OldUsername != "alice" || migrations[0].NewUsername != "alicia" {
+			t.Errorf("expected the migration of alice, got %+v (%v)", migrations, err)
+		}
+	})
+
+	t.Run("It must find a user by its former usernames", func(t *testing.T) {
+		alicia, err := repo.UserByUsername("alicia")
+		if err != nil {
+			t.Fatalf("UserByUsername: %s", err)
+		}
+		_, err = serv.patchUser(*alicia, entities.UserPatch{Username: str("ali")})
+		if err != nil {
+			t.Fatalf("patchUser: %s", err)
+		}
+
+		for _, username := range []string{"alice", "alicia", "ali"} {
+			user, err := serv.GetUserByUsernameOrAlias(username)
+			if err != nil || user.Username != "ali" {
+				t.Errorf("%s: expected ali, got %+v (%v)", username, user, err)
+			}
+		}
+
+		if _, err := serv.GetUserByUsernameOrAlias("nobody"); err == nil {
+			t.Error("expected an error for an unknown username")
+		}
+	})
+
+	t.Run("It must neither rename nor revoke the tokens of a user whose new email is taken", func(t *testing.T) {
+		bob, err := repo.UserByUsername("bob")
+		if err != nil {
+			t.Fatalf("UserByUsername: %s", err)
+		}
+		_, err = serv.patchUser(*bob, entities.UserPatch{Username: str("robert"), Email: str("alice@fake.com")})
+		if conflictErr, ok := err.(entities.ConflictError); !ok || len(conflictErr["email"]) != 1 {
+			t.Errorf("expected the email to be taken, got %v", err)
+		}
+
+		stored, err := repo.UserByUsername("bob")
+		if err != nil || stored.TokensRevokedAt != bob.TokensRevokedAt {
+			t.Errorf("expected bob to be left unchanged, got %+v (%v)", stored, err)
+		}
+		if _, err := repo.UserByUsername("robert"); err == nil {
+			t.Error("bob must not be renamed")
+		}
+	})
+
+	t.Run("It must not take a former username", func(t *testing.T) {
+		bob, err := repo.UserByUsername("bob")
+		if err != nil {
+			t.Fatalf("UserByUsername: %s", err)
+		}
+		_, err = serv.patchUser(*bob, entities.UserPatch{Username: str("alice")})
+		if _, ok := err.(entities.ConflictError); !ok {
+			t.Errorf("expected a conflict error, got %v", err)
+		}
+	})
+}
@@ -4,10 +4,12 @@ import (
 	"errors"
 
 	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/memory"
 )
 
 const (
 	InstanceDynamodb int = iota
+	InstanceMemory
 )
 
 type UserRepository interface {
@@ -16,12 +18,22 @@ type UserRepository interface {
 	UsernameByEmail(email string) (string, error)
 	UpdateUser(oldUser, newUser entities.User) error
 	GetUserListByUsername(usernames []string) ([]entities.User, error)
+	// RenameUser moves oldUser to the username of newUser, reserves the old username as an alias
+	// and records the migration of the rows still referencing it. Every other field of newUser,
+	// its email included, is written along. Usernames, aliases and emails are unique.
+	RenameUser(oldUser, newUser entities.User) error
+	UsernameAlias(alias string) (*entities.UsernameAlias, error)
+	// PendingUsernameMigrations returns at most limit migrations which are not done
+	PendingUsernameMigrations(limit int) ([]entities.UsernameMigration, error)
+	UpdateUsernameMigration(migration entities.UsernameMigration) error
 }
 
 func NewUserRepository(instance int) (UserRepository, error) {
 	switch instance {
 	case InstanceDynamodb:
 		return &dynamoRepository{}, nil
+	case InstanceMemory:
+		return NewMemoryRepository(memory.DB()), nil
 	default:
 		return nil, errors.New("repository instance not found")
 	}
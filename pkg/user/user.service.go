@@ -1,7 +1,11 @@
 package user
 
 import (
+	"log"
+	"time"
+
 	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/internal/memory"
 	"github.com/ferjmc/cms/pkg/auth"
 )
 
@@ -10,11 +14,40 @@ type UserService interface {
 	PutUser(user entities.User, password string) error
 	// GetUserByUsername retrieves a user object from a username string
 	GetUserByUsername(username string) (*entities.User, error)
+	// GetUserByUsernameOrAlias retrieves a user from its username or one of its former usernames
+	GetUserByUsernameOrAlias(username string) (*entities.User, error)
 	GetUsernameByEmail(email string) (string, error)
 	GetUserByEmail(email string) (*entities.User, error)
+	// GetCurrentUser retrieves the user of an access token, unless the tokens of the user were revoked since it was issued
 	GetCurrentUser(authorization string) (*entities.User, string, error)
-	UpdateUser(authorization string, newUser entities.User) (*entities.User, string, error)
+	// GetOptionalUser is GetCurrentUser for the endpoints open to anonymous readers, it returns a nil
+	// user without an Authorization header. Invalid, expired and revoked tokens are still rejected.
+	GetOptionalUser(authorization string) (*entities.User, string, error)
+	// UpdateUser only changes the fields of user set in patch, user being the current one as read by
+	// GetCurrentUser. Changing the password requires the current one and revokes the tokens of the user,
+	// changing the email unverifies it. Changing the username revokes the tokens too, the old username
+	// is reserved and its rows are migrated in the background.
+	UpdateUser(user *entities.User, patch entities.UserPatch) (*entities.User, error)
+	// ResetPassword replaces the password of username and revokes its tokens. Like VerifyEmail,
+	// it follows a rename made after the token naming username was sent, and returns the current username.
+	ResetPassword(username, password string) (string, error)
+	// VerifyEmail marks email as verified, as long as it's still the email of username
+	VerifyEmail(username, email string) (*entities.User, error)
+	// RevokeTokens rejects every access token of user issued until now
+	RevokeTokens(user *entities.User) error
 	GetUserListByUsername(usernames []string) ([]entities.User, error)
+	// GrantRole adds role to the roles of username, admin must be allowed to manage roles.
+	// Like RevokeRole, it returns a conflict when the roles changed meanwhile.
+	GrantRole(admin *entities.User, username, role string) (*entities.User, error)
+	// RevokeRole removes role from the roles of username, admins can't revoke their own admin role
+	RevokeRole(admin *entities.User, username, role string) (*entities.User, error)
+	// Login retrieves the user with email if password matches, outdated password
+	// hashes are replaced by a new one made with the current algorithm
+	Login(email, password string) (*entities.User, error)
+	// GetPendingUsernameMigrations and UpdateUsernameMigration let the rename service run and resume
+	// the migrations of the rows referencing former usernames
+	GetPendingUsernameMigrations(limit int) ([]entities.UsernameMigration, error)
+	UpdateUsernameMigration(migration entities.UsernameMigration) error
 }
 
 func NewUserService(r UserRepository) UserService {
@@ -30,6 +63,9 @@ func New(opts ...func(UserService) UserService) UserService {
 	}
 	// whitout opts retrieves service with dynamo by default
 	if len(opts) <= 0 {
+		if memory.Enabled() {
+			return WithMemory(serv)
+		}
 		return WithDynamoDB(serv)
 	}
 	return serv
@@ -43,6 +79,14 @@ func WithDynamoDB(serv UserService) UserService {
 	return NewUserService(repo)
 }
 
+func WithMemory(serv UserService) UserService {
+	repo, err := NewUserRepository(InstanceMemory)
+	if err != nil {
+		return serv
+	}
+	return NewUserService(repo)
+}
+
 type userService struct {
 	repository UserRepository
 }
@@ -53,7 +97,7 @@ func (s *userService) PutUser(user entities.User, password string) error {
 		return err
 	}
 
-	passHash, err := auth.New().Scrypt(password)
+	passHash, err := auth.New().HashPassword(password)
 	if err != nil {
 		return err
 	}
@@ -75,6 +119,27 @@ func (s *userService) GetUserByUsername(username string) (*entities.User, error)
 	return s.repository.UserByUsername(username)
 }
 
+// maxAliasChain bounds the number of renames followed to resolve a former username
+const maxAliasChain = 10
+
+func (s *userService) GetUserByUsernameOrAlias(username string) (*entities.User, error) {
+	for i := 0; i < maxAliasChain; i++ {
+		user, err := s.GetUserByUsername(username)
+		if _, ok := err.(entities.NotFoundError); !ok {
+			return user, err
+		}
+
+		// A user renamed again is found by following its aliases
+		alias, err := s.repository.UsernameAlias(username)
+		if err != nil {
+			return nil, err
+		}
+		username = alias.Username
+	}
+
+	return nil, entities.NewNotFoundError("username", "not found")
+}
+
 func (s *userService) GetUsernameByEmail(email string) (string, error) {
 	return s.repository.UsernameByEmail(email)
 }
@@ -88,35 +153,172 @@ func (s *userService) GetUserByEmail(email string) (*entities.User, error) {
 }
 
 func (s *userService) GetCurrentUser(authorization string) (*entities.User, string, error) {
-	authService := auth.New()
-	username, token, err := authService.VerifyAuthorization(authorization)
+	claims, token, err := auth.New().VerifyAuthorization(authorization)
 	if err != nil {
 		return nil, "", err
 	}
-	user, err := s.GetUserByUsername(username)
+
+	user, err := s.userOfClaims(claims)
 	if err != nil {
 		return nil, "", err
 	}
 	return user, token, nil
 }
 
-func (s *userService) UpdateUser(authorization string, newUser entities.User) (*entities.User, string, error) {
-	err := newUser.Validate()
-	if err != nil {
+func (s *userService) GetOptionalUser(authorization string) (*entities.User, string, error) {
+	claims, token, err := auth.New().VerifyOptionalAuthorization(authorization)
+	if err != nil || claims == nil {
 		return nil, "", err
 	}
 
-	oldUser, token, err := s.GetCurrentUser(authorization)
+	user, err := s.userOfClaims(*claims)
 	if err != nil {
 		return nil, "", err
 	}
+	return user, token, nil
+}
+
+// userOfClaims retrieves the user of verified claims, unless its tokens were revoked since they were issued
+func (s *userService) userOfClaims(claims auth.Claims) (*entities.User, error) {
+	user, err := s.GetUserByUsername(claims.Username)
+	if _, ok := err.(entities.NotFoundError); ok {
+		return nil, entities.NewUnauthorizedError("Authorization", "user not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if claims.IssuedAt < user.TokensRevokedAt {
+		return nil, entities.NewUnauthorizedError("Authorization", "token revoked")
+	}
+	return user, nil
+}
+
+func (s *userService) UpdateUser(user *entities.User, patch entities.UserPatch) (*entities.User, error) {
+	return s.patchUser(*user, patch)
+}
+
+func (s *userService) patchUser(oldUser entities.User, patch entities.UserPatch) (*entities.User, error) {
+	newUser := patch.Apply(oldUser)
+	newUser.Verified = oldUser.Verified && newUser.Email == oldUser.Email
+
+	if patch.Password != nil {
+		err := s.changePassword(&newUser, *patch.Password, patch.CurrentPassword)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	err = s.repository.UpdateUser(*oldUser, newUser)
+	err := newUser.Validate()
 	if err != nil {
-		return nil, "", err
+		return nil, err
+	}
+
+	if newUser.Username != oldUser.Username {
+		// The tokens name the old username, they can't be used anymore.
+		// The other fields are renamed along, the whole change fails or succeeds.
+		newUser.TokensRevokedAt = time.Now().UTC().UnixNano()
+		err = s.repository.RenameUser(oldUser, newUser)
+	} else {
+		err = s.repository.UpdateUser(oldUser, newUser)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &newUser, nil
+}
+
+// changePassword replaces the password hash of user once currentPassword matches it
+func (s *userService) changePassword(user *entities.User, password, currentPassword string) error {
+	err := entities.ValidatePassword(password)
+	if err != nil {
+		return err
+	}
+
+	if currentPassword == "" {
+		return entities.NewInputError("currentPassword", "can't be blank")
+	}
+
+	authService := auth.New()
+	match, _, err := authService.VerifyPassword(currentPassword, user.PasswordHash)
+	if err != nil {
+		return err
+	}
+	if !match {
+		return entities.NewInputError("currentPassword", "is invalid")
+	}
+
+	user.PasswordHash, err = authService.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	user.TokensRevokedAt = time.Now().UTC().UnixNano()
+
+	return nil
+}
+
+func (s *userService) ResetPassword(username, password string) (string, error) {
+	err := entities.ValidatePassword(password)
+	if err != nil {
+		return "", err
+	}
+
+	user, err := s.GetUserByUsernameOrAlias(username)
+	if err != nil {
+		return "", err
+	}
+
+	passHash, err := auth.New().HashPassword(password)
+	if err != nil {
+		return "", err
+	}
+
+	newUser := *user
+	newUser.PasswordHash = passHash
+	newUser.TokensRevokedAt = time.Now().UTC().UnixNano()
+	err = s.repository.UpdateUser(*user, newUser)
+	if err != nil {
+		return "", err
 	}
 
-	return &newUser, token, nil
+	return user.Username, nil
+}
+
+func (s *userService) VerifyEmail(username, email string) (*entities.User, error) {
+	user, err := s.GetUserByUsernameOrAlias(username)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Email != email {
+		return nil, entities.NewConflictError("email", "has changed since the token was sent")
+	}
+
+	if user.Verified {
+		return user, nil
+	}
+
+	newUser := *user
+	newUser.Verified = true
+	err = s.repository.UpdateUser(*user, newUser)
+	if err != nil {
+		return nil, err
+	}
+
+	return &newUser, nil
+}
+
+func (s *userService) RevokeTokens(user *entities.User) error {
+	newUser := *user
+	newUser.TokensRevokedAt = time.Now().UTC().UnixNano()
+
+	err := s.repository.UpdateUser(*user, newUser)
+	if err != nil {
+		return err
+	}
+
+	user.TokensRevokedAt = newUser.TokensRevokedAt
+	return nil
 }
 
 func (s *userService) GetUserListByUsername(usernames []string) ([]entities.User, error) {
@@ -126,3 +328,125 @@ func (s *userService) GetUserListByUsername(usernames []string) ([]entities.User
 
 	return s.repository.GetUserListByUsername(usernames)
 }
+
+func (s *userService) GrantRole(admin *entities.User, username, role string) (*entities.User, error) {
+	err := auth.Authorize(admin, auth.PermissionManageRoles)
+	if err != nil {
+		return nil, err
+	}
+
+	err = entities.ValidateRole(role)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.GetUserByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.HasRole(role) {
+		return user, nil
+	}
+
+	newUser := *user
+	newUser.Roles = append(append(make([]string, 0, len(user.Roles)+1), user.Roles...), role)
+	err = s.repository.UpdateUser(*user, newUser)
+	if err != nil {
+		return nil, err
+	}
+
+	return &newUser, nil
+}
+
+func (s *userService) RevokeRole(admin *entities.User, username, role string) (*entities.User, error) {
+	err := auth.Authorize(admin, auth.PermissionManageRoles)
+	if err != nil {
+		return nil, err
+	}
+
+	err = entities.ValidateRole(role)
+	if err != nil {
+		return nil, err
+	}
+
+	// There would be nobody left to grant it back
+	if username == admin.Username && role == entities.RoleAdmin {
+		return nil, entities.NewForbiddenError("role", "admins can't revoke their own admin role")
+	}
+
+	user, err := s.GetUserByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.HasRole(role) {
+		return user, nil
+	}
+
+	newUser := *user
+	newUser.Roles = make([]string, 0, len(user.Roles))
+	for _, granted := range user.Roles {
+		if granted != role {
+			newUser.Roles = append(newUser.Roles, granted)
+		}
+	}
+
+	err = s.repository.UpdateUser(*user, newUser)
+	if err != nil {
+		return nil, err
+	}
+
+	return &newUser, nil
+}
+
+func (s *userService) Login(email, password string) (*entities.User, error) {
+	// Unknown emails and wrong passwords can't be told apart
+	invalidCredentials := entities.NewUnauthorizedError("email or password", "is invalid")
+
+	user, err := s.GetUserByEmail(email)
+	if _, ok := err.(entities.NotFoundError); ok {
+		return nil, invalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	authService := auth.New()
+	match, outdated, err := authService.VerifyPassword(password, user.PasswordHash)
+	if err != nil {
+		return nil, err
+	}
+	if !match {
+		return nil, invalidCredentials
+	}
+
+	if outdated {
+		// The login succeeds anyway, the hash is upgraded on a later login
+		err = s.rehashPassword(*user, password)
+		if err != nil {
+			log.Printf("ERROR: rehash of the password of %s: %s", user.Username, err)
+		}
+	}
+
+	return user, nil
+}
+
+func (s *userService) rehashPassword(user entities.User, password string) error {
+	passHash, err := auth.New().HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	newUser := user
+	newUser.PasswordHash = passHash
+	return s.repository.UpdateUser(user, newUser)
+}
+
+func (s *userService) GetPendingUsernameMigrations(limit int) ([]entities.UsernameMigration, error) {
+	return s.repository.PendingUsernameMigrations(limit)
+}
+
+func (s *userService) UpdateUsernameMigration(migration entities.UsernameMigration) error {
+	return s.repository.UpdateUsernameMigration(migration)
+}
@@ -0,0 +1,341 @@
+// Package usertest checks that a user.UserRepository implementation behaves
+// like the DynamoDB one, so every backend can be plugged into the same suite.
+package usertest
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ferjmc/cms/entities"
+	"github.com/ferjmc/cms/pkg/user"
+)
+
+// Run executes the conformance suite. Usernames and emails are unique per run,
+// so the suite can share a repository with other data.
+func Run(t *testing.T, newRepository func() user.UserRepository) {
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+	newUser := func(name string) entities.User {
+		return entities.User{
+			Username:     name + "-" + suffix,
+			Email:        name + "-" + suffix + "@fake.com",
+			PasswordHash: []byte("$argon2id$hash"),
+			Bio:          "bio of " + name,
+		}
+	}
+
+	t.Run("PutUser stores the user by username and email", func(t *testing.T) {
+		repo := newRepository()
+		alice := newUser("alice")
+		mustPutUser(t, repo, alice)
+
+		found, err := repo.UserByUsername(alice.Username)
+		if err != nil {
+			t.Fatalf("UserByUsername: %s", err)
+		}
+		if found.Email != alice.Email || found.Bio != alice.Bio {
+			t.Errorf("stored user %+v, found %+v", alice, *found)
+		}
+
+		username, err := repo.UsernameByEmail(alice.Email)
+		if err != nil {
+			t.Fatalf("UsernameByEmail: %s", err)
+		}
+		if username != alice.Username {
+			t.Errorf("expected username %s, got %s", alice.Username, username)
+		}
+	})
+
+	t.Run("PutUser rejects a duplicate username", func(t *testing.T) {
+		repo := newRepository()
+		bob := newUser("bob")
+		mustPutUser(t, repo, bob)
+
+		duplicate := newUser("bob")
+		duplicate.Email = "other-" + duplicate.Email
+		expectTaken(t, repo.PutUser(duplicate), "username")
+
+		if _, err := repo.UsernameByEmail(duplicate.Email); err == nil {
+			t.Error("the email of a rejected user must not be registered")
+		}
+	})
+
+	t.Run("PutUser rejects a duplicate email", func(t *testing.T) {
+		repo := newRepository()
+		carol := newUser("carol")
+		mustPutUser(t, repo, carol)
+
+		duplicate := newUser("carol")
+		duplicate.Username = "other-" + duplicate.Username
+		expectTaken(t, repo.PutUser(duplicate), "email")
+
+		if _, err := repo.UserByUsername(duplicate.Username); err == nil {
+			t.Error("a user rejected for its email must not be stored")
+		}
+	})
+
+	t.Run("PutUser reports every taken field", func(t *testing.T) {
+		repo := newRepository()
+		ivan := newUser("ivan")
+		mustPutUser(t, repo, ivan)
+
+		expectTaken(t, repo.PutUser(ivan), "username", "email")
+	})
+
+	t.Run("UpdateUser moves the email", func(t *testing.T) {
+		repo := newRepository()
+		dave := newUser("dave")
+		mustPutUser(t, repo, dave)
+
+		updated := dave
+		updated.Email = "new-" + dave.Email
+		updated.Bio = "new bio"
+		if err := repo.UpdateUser(dave, updated); err != nil {
+			t.Fatalf("UpdateUser: %s", err)
+		}
+
+		if _, err := repo.UsernameByEmail(dave.Email); err == nil {
+			t.Error("the old email must be released")
+		}
+
+		username, err := repo.UsernameByEmail(updated.Email)
+		if err != nil || username != dave.Username {
+			t.Errorf("the new email must point to %s, got %q (%v)", dave.Username, username, err)
+		}
+
+		found, err := repo.UserByUsername(dave.Username)
+		if err != nil {
+			t.Fatalf("UserByUsername: %s", err)
+		}
+		if found.Email != updated.Email || found.Bio != updated.Bio {
+			t.Errorf("expected %+v, found %+v", updated, *found)
+		}
+	})
+
+	t.Run("UpdateUser rejects a taken email", func(t *testing.T) {
+		repo := newRepository()
+		erin := newUser("erin")
+		frank := newUser("frank")
+		mustPutUser(t, repo, erin)
+		mustPutUser(t, repo, frank)
+
+		updated := erin
+		updated.Email = frank.Email
+		expectTaken(t, repo.UpdateUser(erin, updated), "email")
+
+		username, err := repo.UsernameByEmail(frank.Email)
+		if err != nil || username != frank.Username {
+			t.Errorf("the taken email must still point to %s, got %q (%v)", frank.Username, username, err)
+		}
+
+		found, err := repo.UserByUsername(erin.Username)
+		if err != nil || found.Email != erin.Email {
+			t.Errorf("the rejected update must not change the user, found %+v (%v)", found, err)
+		}
+	})
+
+	t.Run("UpdateUser only writes the changed fields", func(t *testing.T) {
+		repo := newRepository()
+		judy := newUser("judy")
+		mustPutUser(t, repo, judy)
+
+		// Both updates start from the same copy, neither must undo the other
+		withImage := judy
+		withImage.Image = "judy.png"
+		withBio := judy
+		withBio.Bio = "new bio"
+		if err := repo.UpdateUser(judy, withImage); err != nil {
+			t.Fatalf("UpdateUser: %s", err)
+		}
+		if err := repo.UpdateUser(judy, withBio); err != nil {
+			t.Fatalf("UpdateUser: %s", err)
+		}
+
+		found, err := repo.UserByUsername(judy.Username)
+		if err != nil {
+			t.Fatalf("UserByUsername: %s", err)
+		}
+		if found.Image != withImage.Image || found.Bio != withBio.Bio {
+			t.Errorf("expected both updates, found %+v", *found)
+		}
+	})
+
+	t.Run("UpdateUser rejects roles changed concurrently", func(t *testing.T) {
+		repo := newRepository()
+		lena := newUser("lena")
+		mustPutUser(t, repo, lena)
+
+		// Two grants start from the same copy, the second must not drop the first
+		editor := lena
+		editor.Roles = []string{entities.RoleEditor}
+		admin := lena
+		admin.Roles = []string{entities.RoleAdmin}
+		if err := repo.UpdateUser(lena, editor); err != nil {
+			t.Fatalf("UpdateUser: %s", err)
+		}
+		if _, ok := repo.UpdateUser(lena, admin).(entities.ConflictError); !ok {
+			t.Errorf("expected a conflict error granting from stale roles")
+		}
+
+		both := editor
+		both.Roles = []string{entities.RoleEditor, entities.RoleAdmin}
+		if err := repo.UpdateUser(editor, both); err != nil {
+			t.Fatalf("UpdateUser: %s", err)
+		}
+
+		found, err := repo.UserByUsername(lena.Username)
+		if err != nil || len(found.Roles) != 2 {
+			t.Errorf("expected both roles, found %+v (%v)", found, err)
+		}
+	})
+
+	t.Run("RenameUser moves the user and reserves the old username", func(t *testing.T) {
+		repo := newRepository()
+		kate := newUser("kate")
+		mustPutUser(t, repo, kate)
+
+		renamed := kate
+		renamed.Username = "renamed-" + kate.Username
+		if err := repo.RenameUser(kate, renamed); err != nil {
+			t.Fatalf("RenameUser: %s", err)
+		}
+
+		if _, err := repo.UserByUsername(kate.Username); err == nil {
+			t.Error("the old username must be released")
+		}
+
+		found, err := repo.UserByUsername(renamed.Username)
+		if err != nil || found.Email != kate.Email || found.Bio != kate.Bio {
+			t.Errorf("expected the user under the new username, found %+v (%v)", found, err)
+		}
+
+		username, err := repo.UsernameByEmail(kate.Email)
+		if err != nil || username != renamed.Username {
+			t.Errorf("the email must point to %s, got %q (%v)", renamed.Username, username, err)
+		}
+
+		alias, err := repo.UsernameAlias(kate.Username)
+		if err != nil || alias.Username != renamed.Username {
+			t.Errorf("the old username must be an alias of %s, got %+v (%v)", renamed.Username, alias, err)
+		}
+	})
+
+	t.Run("RenameUser rejects a taken or reserved username", func(t *testing.T) {
+		repo := newRepository()
+		leo := newUser("leo")
+		mallory := newUser("mallory")
+		mustPutUser(t, repo, leo)
+		mustPutUser(t, repo, mallory)
+
+		renamed := leo
+		renamed.Username = mallory.Username
+		expectTaken(t, repo.RenameUser(leo, renamed), "username")
+
+		renamed.Username = "renamed-" + leo.Username
+		if err := repo.RenameUser(leo, renamed); err != nil {
+			t.Fatalf("RenameUser: %s", err)
+		}
+
+		// The former username of leo stays reserved
+		reserved := mallory
+		reserved.Username = leo.Username
+		expectTaken(t, repo.RenameUser(mallory, reserved), "username")
+
+		if _, err := repo.UserByUsername(mallory.Username); err != nil {
+			t.Errorf("a rejected rename must not move the user: %v", err)
+		}
+	})
+
+	t.Run("RenameUser changes the email along, unless it is taken", func(t *testing.T) {
+		repo := newRepository()
+		nina := newUser("nina")
+		oscar := newUser("oscar")
+		mustPutUser(t, repo, nina)
+		mustPutUser(t, repo, oscar)
+
+		renamed := nina
+		renamed.Username = "renamed-" + nina.Username
+		renamed.Email = oscar.Email
+		renamed.Bio = "renamed"
+		expectTaken(t, repo.RenameUser(nina, renamed), "email")
+
+		found, err := repo.UserByUsername(nina.Username)
+		if err != nil || found.Bio != nina.Bio {
+			t.Errorf("a rejected rename must not change the user, found %+v (%v)", found, err)
+		}
+
+		renamed.Email = "renamed-" + nina.Email
+		if err := repo.RenameUser(nina, renamed); err != nil {
+			t.Fatalf("RenameUser: %s", err)
+		}
+
+		found, err = repo.UserByUsername(renamed.Username)
+		if err != nil || found.Email != renamed.Email || found.Bio != renamed.Bio {
+			t.Errorf("expected the renamed user with its new email and bio, found %+v (%v)", found, err)
+		}
+		if username, err := repo.UsernameByEmail(renamed.Email); err != nil || username != renamed.Username {
+			t.Errorf("the new email must point to %s, got %q (%v)", renamed.Username, username, err)
+		}
+		if _, err := repo.UsernameByEmail(nina.Email); err == nil {
+			t.Error("the old email must be released")
+		}
+	})
+
+	t.Run("GetUserListByUsername keeps the requested order", func(t *testing.T) {
+		repo := newRepository()
+		grace := newUser("grace")
+		heidi := newUser("heidi")
+		mustPutUser(t, repo, grace)
+		mustPutUser(t, repo, heidi)
+
+		missing := "missing-" + suffix
+		users, err := repo.GetUserListByUsername([]string{heidi.Username, missing, grace.Username, heidi.Username})
+		if err != nil {
+			t.Fatalf("GetUserListByUsername: %s", err)
+		}
+
+		expected := []string{heidi.Username, "", grace.Username, heidi.Username}
+		if len(users) != len(expected) {
+			t.Fatalf("expected %d users, got %d", len(expected), len(users))
+		}
+		for i, username := range expected {
+			if users[i].Username != username {
+				t.Errorf("users[%d]: expected %q, got %q", i, username, users[i].Username)
+			}
+		}
+	})
+
+	t.Run("Unknown users are reported", func(t *testing.T) {
+		repo := newRepository()
+		if _, err := repo.UserByUsername("missing-" + suffix); err == nil {
+			t.Error("expected an error for an unknown username")
+		}
+		if _, err := repo.UsernameByEmail("missing-" + suffix + "@fake.com"); err == nil {
+			t.Error("expected an error for an unknown email")
+		}
+	})
+}
+
+func mustPutUser(t *testing.T, repo user.UserRepository, u entities.User) {
+	t.Helper()
+	if err := repo.PutUser(u); err != nil {
+		t.Fatalf("PutUser(%s): %s", u.Username, err)
+	}
+}
+
+// expectTaken checks that err is a conflict error for exactly fields
+func expectTaken(t *testing.T, err error, fields ...string) {
+	t.Helper()
+	conflictErr, ok := err.(entities.ConflictError)
+	if !ok {
+		t.Fatalf("expected a conflict error for %v, got %v", fields, err)
+	}
+	if len(conflictErr) != len(fields) {
+		t.Errorf("expected a conflict error for %v, got %s", fields, conflictErr)
+	}
+	for _, field := range fields {
+		if messages := conflictErr[field]; len(messages) != 1 || messages[0] != "has already been taken" {
+			t.Errorf("expected %s to be taken, got %s", field, conflictErr)
+		}
+	}
+}